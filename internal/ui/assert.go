@@ -0,0 +1,465 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// assertion is a parsed check of a request's assertion list. Assertions are
+// stored on the request as the text the user typed, e.g.
+//
+//	status == 200
+//	header Content-Type matches json
+//	json .items[0].id == 42
+//	time < 500ms
+//	body matches "ok":\s*true
+//	schema ./user.schema.json
+type assertion struct {
+	subject string // status, header, json, time, body or schema
+	target  string // header name, JSON path or schema source
+	op      string // ==, !=, <, <=, >, >=, exists, matches, contains, type
+	value   string
+	num     float64
+	re      *regexp.Regexp
+	path    jqNode
+	want    any // decoded JSON operand of json == / !=
+	limit   time.Duration
+}
+
+// testResult is the outcome of one assertion after a send.
+type testResult struct {
+	spec   string
+	pass   bool
+	detail string // the actual value, or why the check failed
+}
+
+var jsonTypes = map[string]bool{
+	"string": true, "number": true, "boolean": true, "null": true,
+	"array": true, "object": true, "integer": true,
+}
+
+// cutWord splits off the first space-separated word of s.
+func cutWord(s string) (word, rest string) {
+	s = strings.TrimSpace(s)
+	word, rest, _ = strings.Cut(s, " ")
+	return word, strings.TrimSpace(rest)
+}
+
+func isCompareOp(op string) bool {
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		return true
+	}
+	return false
+}
+
+// parseJSONPath parses a jq path such as .items[0].id. JSONPath-style $.a.b
+// and $[0] are accepted too.
+func parseJSONPath(s string) (jqNode, error) {
+	path := s
+	if p, ok := strings.CutPrefix(path, "$"); ok {
+		path = "." + strings.TrimPrefix(p, ".")
+	}
+	q, err := jqParse(path)
+	if s == "" || err != nil {
+		return nil, fmt.Errorf("bad JSON path %q", s)
+	}
+	return q, nil
+}
+
+// parseAssertion parses the text form of an assertion.
+func parseAssertion(spec string) (assertion, error) {
+	subject, rest := cutWord(spec)
+	a := assertion{subject: subject}
+	switch subject {
+	case "status":
+		a.op, a.value = cutWord(rest)
+		n, err := strconv.Atoi(a.value)
+		if !isCompareOp(a.op) || err != nil {
+			return a, errors.New("usage: status <op> <code>")
+		}
+		a.num = float64(n)
+	case "header":
+		a.target, rest = cutWord(rest)
+		a.op, a.value = cutWord(rest)
+		switch {
+		case a.target == "":
+			return a, errors.New("usage: header <name> exists|matches <regex>|== <value>")
+		case a.op == "exists" && a.value == "":
+		case a.op == "matches":
+			re, err := regexp.Compile(a.value)
+			if err != nil {
+				return a, fmt.Errorf("bad regex: %w", err)
+			}
+			a.re = re
+		case a.op == "==" || a.op == "!=":
+		default:
+			return a, errors.New("usage: header <name> exists|matches <regex>|== <value>")
+		}
+	case "json":
+		a.target, rest = cutWord(rest)
+		a.op, a.value = cutWord(rest)
+		q, err := parseJSONPath(a.target)
+		if err != nil {
+			return a, err
+		}
+		a.path = q
+		switch {
+		case a.op == "exists" && a.value == "":
+		case a.op == "type":
+			if !jsonTypes[a.value] {
+				return a, errors.New("type must be string, number, integer, boolean, null, array or object")
+			}
+		case a.op == "matches":
+			re, err := regexp.Compile(a.value)
+			if err != nil {
+				return a, fmt.Errorf("bad regex: %w", err)
+			}
+			a.re = re
+		case a.op == "==" || a.op == "!=":
+			// a value that is not valid JSON is taken as a string
+			if v, err := jqDecode([]byte(a.value)); err == nil && a.value != "" {
+				a.want = v
+			} else {
+				a.want = a.value
+			}
+		case isCompareOp(a.op):
+			n, err := strconv.ParseFloat(a.value, 64)
+			if err != nil {
+				return a, fmt.Errorf("%s needs a number", a.op)
+			}
+			a.num = n
+		default:
+			return a, errors.New("usage: json <path> exists|type <t>|matches <regex>|<op> <value>")
+		}
+	case "time":
+		a.op, a.value = cutWord(rest)
+		d, err := time.ParseDuration(a.value)
+		if ms, errMS := strconv.Atoi(a.value); errMS == nil {
+			d, err = time.Duration(ms)*time.Millisecond, nil
+		}
+		if (a.op != "<" && a.op != "<=") || err != nil {
+			return a, errors.New("usage: time < <duration>, e.g. time < 500ms")
+		}
+		a.limit = d
+	case "body":
+		a.op, a.value = cutWord(rest)
+		switch a.op {
+		case "matches":
+			re, err := regexp.Compile(a.value)
+			if err != nil {
+				return a, fmt.Errorf("bad regex: %w", err)
+			}
+			a.re = re
+		case "contains":
+			if a.value == "" {
+				return a, errors.New("usage: body contains <text>")
+			}
+		default:
+			return a, errors.New("usage: body matches <regex>|contains <text>")
+		}
+	case "schema":
+		a.target = rest
+		if a.target == "" {
+			return a, errors.New("usage: schema <file>|<inline JSON schema>")
+		}
+		if strings.HasPrefix(a.target, "{") {
+			if _, err := jqDecode([]byte(a.target)); err != nil {
+				return a, fmt.Errorf("bad schema: %w", err)
+			}
+		}
+	default:
+		return a, fmt.Errorf("unknown assertion %q (status, header, json, time, body or schema)", subject)
+	}
+	return a, nil
+}
+
+// runAssertions checks a response against the text assertions of a request.
+// An assertion that does not parse fails with the parse error.
+func runAssertions(specs []string, resp response) []testResult {
+	if len(specs) == 0 || resp.statusCode == 0 {
+		return nil
+	}
+	ev := &assertEnv{resp: resp}
+	results := make([]testResult, 0, len(specs))
+	for _, spec := range specs {
+		a, err := parseAssertion(spec)
+		if err != nil {
+			results = append(results, testResult{spec: spec, detail: err.Error()})
+			continue
+		}
+		pass, detail := ev.check(a)
+		results = append(results, testResult{spec: spec, pass: pass, detail: detail})
+	}
+	return results
+}
+
+// assertEnv decodes the JSON body once for all assertions of a send.
+type assertEnv struct {
+	resp    response
+	decoded bool
+	doc     any
+	docErr  error
+}
+
+func (e *assertEnv) json() (any, error) {
+	if !e.decoded {
+		e.decoded = true
+		e.doc, e.docErr = jqDecode(e.resp.body)
+		if e.docErr != nil {
+			e.docErr = errors.New("body is not JSON")
+		}
+	}
+	return e.doc, e.docErr
+}
+
+func (e *assertEnv) check(a assertion) (bool, string) {
+	resp := e.resp
+	switch a.subject {
+	case "status":
+		return compareNum(float64(resp.statusCode), a.op, a.num), "got " + strconv.Itoa(resp.statusCode)
+	case "header":
+		vals, ok := resp.header[http.CanonicalHeaderKey(a.target)]
+		if !ok {
+			// a missing header differs from every value
+			return a.op == "!=", "missing"
+		}
+		got := strings.Join(vals, ", ")
+		switch a.op {
+		case "exists":
+			return true, clip(got)
+		case "matches":
+			return a.re.MatchString(got), "got " + clip(got)
+		case "==":
+			return got == a.value, "got " + clip(got)
+		default:
+			return got != a.value, "got " + clip(got)
+		}
+	case "json":
+		doc, err := e.json()
+		if err != nil {
+			return false, err.Error()
+		}
+		out, err := a.path.eval(doc)
+		if err != nil {
+			return false, err.Error()
+		}
+		if len(out) == 0 || (out[0] == nil && a.op == "exists") {
+			return false, "missing"
+		}
+		v := out[0]
+		got := "got " + clip(jqScalarText(v))
+		switch a.op {
+		case "exists":
+			return true, clip(jqScalarText(v))
+		case "type":
+			t := jqType(v)
+			if a.value == "integer" {
+				f, ok := jqToFloat(v)
+				return ok && f == float64(int64(f)), "got " + t
+			}
+			return t == a.value, "got " + t
+		case "matches":
+			s, ok := v.(string)
+			if !ok {
+				s = jqScalarText(v)
+			}
+			return a.re.MatchString(s), got
+		case "==":
+			return canonicalJSON(v) == canonicalJSON(a.want), got
+		case "!=":
+			return canonicalJSON(v) != canonicalJSON(a.want), got
+		default:
+			f, ok := jqToFloat(v)
+			if !ok {
+				return false, got + ", not a number"
+			}
+			return compareNum(f, a.op, a.num), got
+		}
+	case "time":
+		took := resp.duration.Round(time.Millisecond)
+		pass := resp.duration < a.limit || (a.op == "<=" && resp.duration == a.limit)
+		return pass, "took " + took.String()
+	case "body":
+		if a.op == "contains" {
+			if strings.Contains(string(resp.body), a.value) {
+				return true, "found"
+			}
+			return false, "not found"
+		}
+		if a.re.Match(resp.body) {
+			return true, "matched"
+		}
+		return false, "no match"
+	case "schema":
+		doc, err := e.json()
+		if err != nil {
+			return false, err.Error()
+		}
+		schema, err := loadSchema(a.target)
+		if err != nil {
+			return false, err.Error()
+		}
+		errs := validateSchema(schema, doc)
+		switch len(errs) {
+		case 0:
+			return true, "valid"
+		case 1:
+			return false, errs[0]
+		}
+		return false, fmt.Sprintf("%s (+%d more)", errs[0], len(errs)-1)
+	}
+	return false, "unknown assertion"
+}
+
+// loadSchema decodes an inline schema, or reads it from a file.
+func loadSchema(src string) (any, error) {
+	data := []byte(src)
+	if !strings.HasPrefix(src, "{") {
+		var err error
+		if data, err = os.ReadFile(src); err != nil {
+			return nil, err
+		}
+	}
+	schema, err := jqDecode(data)
+	if err != nil {
+		return nil, fmt.Errorf("bad schema: %w", err)
+	}
+	return schema, nil
+}
+
+func compareNum(got float64, op string, want float64) bool {
+	switch op {
+	case "==":
+		return got == want
+	case "!=":
+		return got != want
+	case "<":
+		return got < want
+	case "<=":
+		return got <= want
+	case ">":
+		return got > want
+	case ">=":
+		return got >= want
+	}
+	return false
+}
+
+// clip shortens an actual value for the one-line detail of a result.
+func clip(s string) string {
+	const maxLen = 60
+	if r := []rune(s); len(r) > maxLen {
+		return string(r[:maxLen-1]) + "…"
+	}
+	return s
+}
+
+// testsPassed counts the passing results.
+func testsPassed(results []testResult) int {
+	n := 0
+	for _, r := range results {
+		if r.pass {
+			n++
+		}
+	}
+	return n
+}
+
+// renderTests lists the assertion results of the last send.
+func (m Model) renderTests(resp response, w int) string {
+	dim := m.theme.dim()
+	if len(resp.tests) == 0 {
+		return dim.Render("  no assertions — add one with ") + m.theme.keyHint(":assert status == 200")
+	}
+	passed := testsPassed(resp.tests)
+	summary := m.theme.successStyle().Render(fmt.Sprintf("  %d/%d passed", passed, len(resp.tests)))
+	if passed < len(resp.tests) {
+		summary = m.theme.errStyle().Bold(true).Render(fmt.Sprintf("  %d/%d passed", passed, len(resp.tests)))
+	}
+	lines := []string{summary, ""}
+	for _, r := range resp.tests {
+		mark := m.theme.successStyle().Render("  ✓ ")
+		detail := dim.Render("  " + r.detail)
+		if !r.pass {
+			mark = m.theme.errStyle().Bold(true).Render("  ✗ ")
+			detail = m.theme.errStyle().Render("  " + r.detail)
+		}
+		line := mark + m.theme.text().Render(r.spec) + detail
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(w).Render(line))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// execAssert handles ":assert [spec]": with a spec it adds an assertion to the
+// active request and checks the response on screen against it; alone it
+// lists the assertions.
+func (m Model) execAssert(spec string) Model {
+	if m.activeFolderIdx < 0 {
+		m.cmdError = "no active request"
+		return m
+	}
+	r := &m.folders[m.activeFolderIdx].requests[m.activeReqIdx]
+	if spec == "" {
+		if len(r.assertions) == 0 {
+			m.cmdInfo = "no assertions"
+			return m
+		}
+		parts := make([]string, len(r.assertions))
+		for i, s := range r.assertions {
+			parts[i] = fmt.Sprintf("%d: %s", i+1, s)
+		}
+		m.cmdInfo = strings.Join(parts, " · ")
+		return m
+	}
+	if _, err := parseAssertion(spec); err != nil {
+		m.cmdError = err.Error()
+		return m
+	}
+	r.assertions = append(r.assertions, spec)
+	m = m.retest()
+	m.cmdInfo = fmt.Sprintf("added assertion %d", len(r.assertions))
+	return m
+}
+
+// execUnassert handles ":unassert <n>".
+func (m Model) execUnassert(args []string) Model {
+	if m.activeFolderIdx < 0 {
+		m.cmdError = "no active request"
+		return m
+	}
+	r := &m.folders[m.activeFolderIdx].requests[m.activeReqIdx]
+	n, err := 0, errors.New("usage: unassert <n>")
+	if len(args) == 1 {
+		n, err = strconv.Atoi(args[0])
+	}
+	if err != nil || n < 1 || n > len(r.assertions) {
+		m.cmdError = "usage: unassert <n> (:assert lists them)"
+		return m
+	}
+	r.assertions = append(r.assertions[:n-1:n-1], r.assertions[n:]...)
+	m = m.retest()
+	m.cmdInfo = fmt.Sprintf("removed assertion %d", n)
+	return m
+}
+
+// retest re-evaluates the response on screen after the assertions of the
+// active request changed, when that request is the one the response is for.
+func (m Model) retest() Model {
+	if m.resp == nil || m.resp.statusCode == 0 || m.activeFolderIdx < 0 ||
+		m.resp.folderIdx != m.activeFolderIdx || m.resp.reqIdx != m.activeReqIdx {
+		return m
+	}
+	resp := *m.resp
+	resp.tests = append(runAssertions(m.folders[m.activeFolderIdx].requests[m.activeReqIdx].assertions, resp), resp.scriptTests...)
+	m.resp = &resp
+	return m
+}
@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestHeaderAssertions(t *testing.T) {
+	resp := response{statusCode: 200, header: http.Header{"Content-Type": {"application/json"}}}
+	tests := []struct {
+		spec string
+		want bool
+	}{
+		{"header Content-Type == application/json", true},
+		{"header content-type != text/html", true},
+		{"header Content-Type matches ^application/", true},
+		{"header X-Trace exists", false},
+		{"header X-Trace == abc", false},
+		{"header X-Trace matches .*", false},
+		{"header X-Trace != abc", true},
+	}
+	for _, tt := range tests {
+		got := runAssertions([]string{tt.spec}, resp)
+		if len(got) != 1 || got[0].pass != tt.want {
+			t.Errorf("%s: %+v, want pass %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestRetestOnlyTheSentRequest(t *testing.T) {
+	m := New()
+	m.folders = []folder{{name: "F", requests: []request{
+		{method: "GET", name: "sent", assertions: []string{"status == 200"}},
+		{method: "GET", name: "other"},
+	}}}
+	m.activeFolderIdx, m.activeReqIdx = 0, 1
+	resp := response{statusCode: 200, folderIdx: 0, reqIdx: 0}
+	resp.tests = runAssertions(m.folders[0].requests[0].assertions, resp)
+	m.resp = &resp
+
+	m = m.execAssert("status == 404")
+	if got := m.resp.tests; len(got) != 1 || !got[0].pass {
+		t.Errorf("another request's assertion changed the tests: %+v", got)
+	}
+	m.activeFolderIdx = -1
+	m = m.retest() // nothing selected must not panic
+
+	m.activeFolderIdx, m.activeReqIdx = 0, 0
+	m = m.execAssert("status == 404")
+	if got := m.resp.tests; len(got) != 2 || got[1].pass {
+		t.Errorf("the sent request's assertion was not evaluated: %+v", got)
+	}
+}
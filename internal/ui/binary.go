@@ -0,0 +1,289 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const hexRowBytes = 16
+
+// hexLine renders row i of a hex dump: the offset, sixteen bytes in two
+// groups of eight, and the printable ASCII. NUL bytes are dimmed so padding
+// and sparse data stand out.
+func hexLine(data []byte, i int) synLine {
+	start := i * hexRowBytes
+	row := data[start:min(start+hexRowBytes, len(data))]
+	spans := []synSpan{{synComment, fmt.Sprintf("%08x  ", start)}}
+	for j := 0; j < hexRowBytes; j++ {
+		sep := " "
+		if j == 7 {
+			sep = "  "
+		}
+		if j >= len(row) {
+			spans = append(spans, synSpan{synPlain, "  " + sep})
+			continue
+		}
+		kind := synPlain
+		if row[j] == 0 {
+			kind = synPunct
+		}
+		spans = append(spans, synSpan{kind, fmt.Sprintf("%02x", row[j])}, synSpan{synPlain, sep})
+	}
+	ascii := make([]byte, len(row))
+	for j, c := range row {
+		if c >= 0x20 && c < 0x7f {
+			ascii[j] = c
+		} else {
+			ascii[j] = '.'
+		}
+	}
+	return synLine{spans: append(spans,
+		synSpan{synPunct, " |"}, synSpan{synString, string(ascii)}, synSpan{synPunct, "|"})}
+}
+
+// imageFormat recognizes the image formats tuiman can preview by signature.
+func imageFormat(body []byte) string {
+	switch {
+	case bytes.HasPrefix(body, []byte("\x89PNG\r\n\x1a\n")):
+		return "PNG"
+	case bytes.HasPrefix(body, []byte("\xff\xd8\xff")):
+		return "JPEG"
+	case bytes.HasPrefix(body, []byte("GIF87a")), bytes.HasPrefix(body, []byte("GIF89a")):
+		return "GIF"
+	}
+	return ""
+}
+
+// imageInfo is a decoded image and the facts shown above its preview.
+type imageInfo struct {
+	format  string
+	width   int
+	height  int
+	img     image.Image
+	details [][2]string
+
+	// The half-block preview is cached per size since styling every cell
+	// is too slow to repeat on each frame.
+	previewW, previewH int
+	preview            string
+}
+
+// maxImagePixels bounds the images tuiman decodes for a preview. A few
+// bytes of compressed data can claim gigapixel dimensions, and decoding
+// allocates the full bitmap up front.
+const maxImagePixels = 25_000_000
+
+func decodeImage(body []byte) (*imageInfo, error) {
+	format := imageFormat(body)
+	if format == "" {
+		return nil, fmt.Errorf("unrecognized image signature")
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	info := &imageInfo{format: format, width: cfg.Width, height: cfg.Height}
+	info.details = [][2]string{
+		{"Format", format},
+		{"Dimensions", fmt.Sprintf("%d × %d px", cfg.Width, cfg.Height)},
+		{"Color", colorModelName(cfg.ColorModel)},
+		{"Size", formatBytes(len(body))},
+	}
+	switch format {
+	case "PNG":
+		// IHDR is always the first chunk: width, height, bit depth, color type, …, interlace
+		if len(body) >= 29 && string(body[12:16]) == "IHDR" {
+			info.details = append(info.details, [2]string{"Bit depth", fmt.Sprint(body[24])})
+			if body[28] == 1 {
+				info.details = append(info.details, [2]string{"Interlace", "Adam7"})
+			}
+		}
+	case "JPEG":
+		if jpegProgressive(body) {
+			info.details = append(info.details, [2]string{"Encoding", "progressive"})
+		} else {
+			info.details = append(info.details, [2]string{"Encoding", "baseline"})
+		}
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > maxImagePixels {
+		info.details = append(info.details, [2]string{"Preview",
+			fmt.Sprintf("none, the image is over %d megapixels", maxImagePixels/1_000_000)})
+		return info, nil
+	}
+
+	if format == "GIF" {
+		// the first frame is the preview; the rest only count
+		g, err := gif.DecodeAll(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		info.img = g.Image[0]
+		if len(g.Image) > 1 {
+			loop := "loops forever"
+			if g.LoopCount > 0 {
+				loop = fmt.Sprintf("loops %d×", g.LoopCount)
+			} else if g.LoopCount < 0 {
+				loop = "plays once"
+			}
+			info.details = append(info.details, [2]string{"Frames", fmt.Sprintf("%d (%s)", len(g.Image), loop)})
+		}
+	} else if info.img, _, err = image.Decode(bytes.NewReader(body)); err != nil {
+		return nil, err
+	}
+	if term := graphicsTerminal(os.Getenv); term != "" {
+		info.details = append(info.details, [2]string{"Preview",
+			"half blocks only; " + term + " image graphics are not supported"})
+	}
+	return info, nil
+}
+
+// graphicsTerminal names the terminal when it can draw real images, so the
+// image view can say that it only draws half blocks there.
+func graphicsTerminal(getenv func(string) string) string {
+	switch {
+	case getenv("KITTY_WINDOW_ID") != "", strings.Contains(getenv("TERM"), "kitty"):
+		return "kitty"
+	case strings.Contains(getenv("TERM"), "ghostty"), getenv("TERM_PROGRAM") == "ghostty":
+		return "Ghostty"
+	case getenv("TERM_PROGRAM") == "WezTerm":
+		return "WezTerm"
+	case getenv("TERM_PROGRAM") == "iTerm.app":
+		return "iTerm2"
+	case strings.Contains(getenv("TERM"), "sixel"), strings.HasPrefix(getenv("TERM"), "mlterm"):
+		return "sixel"
+	}
+	return ""
+}
+
+func colorModelName(model color.Model) string {
+	switch m := model.(type) {
+	case color.Palette:
+		return fmt.Sprintf("paletted, %d colors", len(m))
+	}
+	switch model {
+	case color.RGBAModel, color.NRGBAModel:
+		return "RGBA"
+	case color.RGBA64Model, color.NRGBA64Model:
+		return "RGBA, 16-bit"
+	case color.GrayModel:
+		return "grayscale"
+	case color.Gray16Model:
+		return "grayscale, 16-bit"
+	case color.YCbCrModel:
+		return "YCbCr"
+	case color.CMYKModel:
+		return "CMYK"
+	}
+	return "unknown"
+}
+
+// jpegProgressive walks the JPEG markers looking for a progressive frame (SOF2).
+func jpegProgressive(body []byte) bool {
+	for i := 2; i+4 <= len(body); {
+		if body[i] != 0xff {
+			return false
+		}
+		marker := body[i+1]
+		switch {
+		case marker == 0xc2:
+			return true
+		case marker == 0xc0 || marker == 0xc1 || marker == 0xda:
+			return false
+		}
+		i += 2 + int(binary.BigEndian.Uint16(body[i+2:]))
+	}
+	return false
+}
+
+// renderImage shows the image metadata followed, unless hidden, by a preview
+// drawn with half blocks: each cell is two pixels, the upper one as the
+// foreground of ▀ and the lower one as its background.
+func (m Model) renderImage(info *imageInfo, w, h int) string {
+	var lines []string
+	for _, d := range info.details {
+		lines = append(lines, m.theme.dim().Render(fmt.Sprintf("  %-12s", d[0]))+m.theme.textMuted().Render(d[1]))
+	}
+	rows := h - len(lines) - 1
+	if info.img == nil || m.hideImagePreview || rows < 2 {
+		return strings.Join(lines, "\n")
+	}
+	cols := w - 4
+	if info.previewW != cols || info.previewH != rows {
+		info.preview = halfBlocks(info.img, cols, rows)
+		info.previewW, info.previewH = cols, rows
+	}
+	return strings.Join(lines, "\n") + "\n\n" + info.preview
+}
+
+// halfBlocks scales img to fit cols × rows cells, keeping its aspect ratio,
+// and never enlarges it.
+func halfBlocks(img image.Image, cols, rows int) string {
+	b := img.Bounds()
+	scale := min(float64(cols)/float64(b.Dx()), float64(rows*2)/float64(b.Dy()), 1)
+	outW := max(1, int(float64(b.Dx())*scale))
+	outH := max(2, int(float64(b.Dy())*scale))
+
+	px := func(x, y int) (color.NRGBA, bool) {
+		c := averageColor(img, b.Min.X+x*b.Dx()/outW, b.Min.Y+y*b.Dy()/outH,
+			b.Min.X+(x+1)*b.Dx()/outW, b.Min.Y+(y+1)*b.Dy()/outH)
+		return c, c.A >= 128
+	}
+	hex := func(c color.NRGBA) lipgloss.Color {
+		return lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B))
+	}
+
+	var out []string
+	for y := 0; y < outH; y += 2 {
+		var sb strings.Builder
+		sb.WriteString("  ")
+		for x := 0; x < outW; x++ {
+			top, topOK := px(x, y)
+			bottom, bottomOK := color.NRGBA{}, false
+			if y+1 < outH {
+				bottom, bottomOK = px(x, y+1)
+			}
+			// transparent pixels show the terminal background
+			switch {
+			case topOK && bottomOK:
+				sb.WriteString(lipgloss.NewStyle().Foreground(hex(top)).Background(hex(bottom)).Render("▀"))
+			case topOK:
+				sb.WriteString(lipgloss.NewStyle().Foreground(hex(top)).Render("▀"))
+			case bottomOK:
+				sb.WriteString(lipgloss.NewStyle().Foreground(hex(bottom)).Render("▄"))
+			default:
+				sb.WriteString(" ")
+			}
+		}
+		out = append(out, sb.String())
+	}
+	return strings.Join(out, "\n")
+}
+
+// averageColor averages a grid of at most 4×4 samples over the rectangle,
+// which is enough to keep small previews of detailed images from aliasing.
+func averageColor(img image.Image, x0, y0, x1, y1 int) color.NRGBA {
+	x1, y1 = max(x1, x0+1), max(y1, y0+1)
+	stepX, stepY := max(1, (x1-x0)/4), max(1, (y1-y0)/4)
+	var r, g, b, a, n uint32
+	for y := y0; y < y1; y += stepY {
+		for x := x0; x < x1; x += stepX {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			r += uint32(c.R)
+			g += uint32(c.G)
+			b += uint32(c.B)
+			a += uint32(c.A)
+			n++
+		}
+	}
+	return color.NRGBA{uint8(r / n), uint8(g / n), uint8(b / n), uint8(a / n)}
+}
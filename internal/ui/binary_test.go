@@ -0,0 +1,88 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestDecodeImageSkipsHugePreviews(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	// Claim 100000 × 100000 pixels in IHDR; decoding would allocate 10 GB.
+	body := buf.Bytes()
+	binary.BigEndian.PutUint32(body[16:], 100000)
+	binary.BigEndian.PutUint32(body[20:], 100000)
+	binary.BigEndian.PutUint32(body[29:], crc32.ChecksumIEEE(body[12:29]))
+
+	info, err := decodeImage(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.img != nil {
+		t.Error("decoded an image over the pixel limit")
+	}
+	if info.width != 100000 || info.height != 100000 {
+		t.Errorf("dimensions %d × %d", info.width, info.height)
+	}
+	m := New()
+	if out := m.renderImage(info, 80, 30); !strings.Contains(out, "megapixels") {
+		t.Errorf("no note about the skipped preview:\n%s", out)
+	}
+}
+
+func TestDecodeImageCountsGIFFrames(t *testing.T) {
+	pal := color.Palette{color.Black, color.White}
+	g := &gif.GIF{LoopCount: 3}
+	for range 3 {
+		g.Image = append(g.Image, image.NewPaletted(image.Rect(0, 0, 2, 2), pal))
+		g.Delay = append(g.Delay, 10)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	info, err := decodeImage(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.img == nil {
+		t.Fatal("no preview image")
+	}
+	var frames string
+	for _, d := range info.details {
+		if d[0] == "Frames" {
+			frames = d[1]
+		}
+	}
+	if frames != "3 (loops 3×)" {
+		t.Errorf("frames %q", frames)
+	}
+}
+
+func TestGraphicsTerminal(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{"TERM": "xterm-kitty"}, "kitty"},
+		{map[string]string{"KITTY_WINDOW_ID": "1", "TERM": "xterm-256color"}, "kitty"},
+		{map[string]string{"TERM_PROGRAM": "WezTerm"}, "WezTerm"},
+		{map[string]string{"TERM_PROGRAM": "iTerm.app"}, "iTerm2"},
+		{map[string]string{"TERM": "xterm-256color", "TERM_PROGRAM": "Apple_Terminal"}, ""},
+	}
+	for _, tt := range tests {
+		getenv := func(k string) string { return tt.env[k] }
+		if got := graphicsTerminal(getenv); got != tt.want {
+			t.Errorf("%v: %q, want %q", tt.env, got, tt.want)
+		}
+	}
+}
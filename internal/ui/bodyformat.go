@@ -0,0 +1,332 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// bodyFormat selects how the Body tab presents a response.
+type bodyFormat int
+
+const (
+	formatAuto bodyFormat = iota // follow the Content-Type
+	formatJSON
+	formatXML
+	formatHTML
+	formatHTMLText // HTML reduced to its readable text
+	formatYAML
+	formatImage // PNG, JPEG or GIF: metadata and a preview
+	formatHex   // hex dump for binary bodies
+	formatRaw
+	formatCount
+)
+
+var bodyFormatNames = []string{"auto", "json", "xml", "html", "text", "yaml", "image", "hex", "raw"}
+
+func (f bodyFormat) String() string { return bodyFormatNames[f] }
+
+func parseBodyFormat(s string) (bodyFormat, bool) {
+	for i, name := range bodyFormatNames {
+		if name == s {
+			return bodyFormat(i), true
+		}
+	}
+	return formatAuto, false
+}
+
+// detectFormat picks a format from the Content-Type. JSON is also sniffed by
+// isJSON, so a JSON body served as text/plain is still shown as JSON; images
+// are recognized by their signature and other binary bodies by their bytes.
+func detectFormat(h http.Header, body []byte) bodyFormat {
+	if isJSON(h, body) {
+		return formatJSON
+	}
+	if imageFormat(body) != "" {
+		return formatImage
+	}
+	ct, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	switch {
+	case ct == "text/html" || ct == "application/xhtml+xml":
+		return formatHTML
+	case ct == "application/xml" || ct == "text/xml" || strings.HasSuffix(ct, "+xml"):
+		return formatXML
+	case ct == "application/yaml" || ct == "application/x-yaml" || ct == "text/yaml" ||
+		ct == "text/x-yaml" || strings.HasSuffix(ct, "+yaml"):
+		return formatYAML
+	case ct == "" || ct == "text/plain":
+		trimmed := bytes.TrimSpace(body)
+		if bytes.HasPrefix(trimmed, []byte("<?xml")) {
+			return formatXML
+		}
+	}
+	if isBinary(body) {
+		return formatHex
+	}
+	return formatRaw
+}
+
+// isBinary reports whether body looks like binary data rather than text:
+// it has a NUL byte, is not UTF-8, or is mostly control characters.
+func isBinary(body []byte) bool {
+	sample := body[:min(len(body), 8192)]
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return true
+	}
+	if len(sample) < len(body) {
+		// don't judge a rune cut in half by the sample boundary
+		for i := 0; i < 3 && len(sample) > 0 && !utf8.Valid(sample); i++ {
+			sample = sample[:len(sample)-1]
+		}
+	}
+	if !utf8.Valid(sample) {
+		return true
+	}
+	control := 0
+	for _, c := range sample {
+		if c < 0x20 && c != '\n' && c != '\r' && c != '\t' && c != '\f' {
+			control++
+		}
+	}
+	return control*10 > len(sample)
+}
+
+// synKind classifies a span of highlighted text.
+type synKind int
+
+const (
+	synPlain  synKind = iota
+	synPunct          // brackets, separators, markers
+	synKey            // element names and mapping keys
+	synAttr           // attribute names, anchors, tags
+	synString         // attribute values and string scalars
+	synNumber
+	synBool
+	synNull
+	synComment
+	synHeading // headings in text-only HTML
+)
+
+type synSpan struct {
+	kind synKind
+	text string
+}
+
+// synLine is one line of a formatted body. Indentation is kept as a depth so
+// spans never carry leading whitespace.
+type synLine struct {
+	depth int
+	spans []synSpan
+}
+
+// prettyBody is a non-JSON body after formatting. Like jsonDoc it is built
+// once per response and format, and only the visible rows are styled.
+type prettyBody struct {
+	format bodyFormat
+	lines  []synLine
+	note   string     // why formatting fell back, if it did
+	hex    []byte     // body of a hex dump; its rows are built on demand
+	image  *imageInfo // decoded image and its metadata
+}
+
+// lineCount is the number of scrollable rows.
+func (p *prettyBody) lineCount() int {
+	if p.format == formatHex {
+		return (len(p.hex) + hexRowBytes - 1) / hexRowBytes
+	}
+	return len(p.lines)
+}
+
+func (p *prettyBody) line(i int) synLine {
+	if p.format == formatHex {
+		return hexLine(p.hex, i)
+	}
+	return p.lines[i]
+}
+
+// formatBody formats body as f. A body that does not parse as f falls back
+// to raw lines with a note explaining why.
+func formatBody(f bodyFormat, body []byte) *prettyBody {
+	var lines []synLine
+	var err error
+	switch f {
+	case formatXML:
+		lines, err = formatXMLBody(body)
+	case formatHTML:
+		lines = formatHTMLBody(body)
+	case formatHTMLText:
+		lines = htmlText(body)
+	case formatYAML:
+		lines = highlightYAML(body)
+	case formatImage:
+		var img *imageInfo
+		if img, err = decodeImage(body); err == nil {
+			return &prettyBody{format: f, image: img}
+		}
+	case formatHex:
+		return &prettyBody{format: f, hex: body}
+	default:
+		f = formatRaw
+	}
+	p := &prettyBody{format: f, lines: lines}
+	if err != nil {
+		p.format = formatRaw
+		p.note = "not valid " + f.String() + ": " + err.Error()
+	}
+	if p.format == formatRaw && isBinary(body) {
+		p.format, p.hex = formatHex, body
+	} else if p.format == formatRaw {
+		p.lines = rawLines(body)
+	}
+	return p
+}
+
+func rawLines(body []byte) []synLine {
+	if len(body) == 0 {
+		return nil
+	}
+	var lines []synLine
+	for _, l := range strings.Split(strings.TrimRight(string(body), "\n"), "\n") {
+		lines = append(lines, synLine{spans: []synSpan{{synPlain, printable(strings.TrimRight(l, "\r"))}}})
+	}
+	return lines
+}
+
+// printable replaces invalid UTF-8 and control characters, which would
+// otherwise move the cursor and break the pane layout.
+func printable(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r >= 0x20 && r != 0x7f {
+			return r
+		}
+		return '·'
+	}, strings.ToValidUTF8(s, "\uFFFD"))
+}
+
+// effectiveFormat is the override when one is set, otherwise the detected format.
+func (m Model) effectiveFormat() bodyFormat {
+	if m.bodyFormat != formatAuto {
+		return m.bodyFormat
+	}
+	if m.resp == nil {
+		return formatRaw
+	}
+	return m.resp.format
+}
+
+// showingJSON reports whether the Body tab is the interactive JSON viewer.
+func (m Model) showingJSON() bool {
+	return m.responseTab == respTabBody && m.jv != nil && m.effectiveFormat() == formatJSON
+}
+
+// setBodyFormat applies a format override to the current response.
+func (m Model) setBodyFormat(f bodyFormat) Model {
+	m = m.clearSearch()
+	m.bodyFormat = f
+	m.respScroll = 0
+	m.bodyCursor = 0
+	if m.resp == nil {
+		return m
+	}
+	eff := m.effectiveFormat()
+	if eff == formatJSON && m.jvFull == nil {
+		// Forced to JSON; only possible when the body actually is JSON.
+		if doc := jsonBodyDoc(m.resp.body); doc != nil {
+			m.resp.json = doc
+			m.resp.jsonValue, _ = jqDecode(m.resp.body)
+			m.jvFull = newJSONView(doc)
+			m.jv = m.jvFull
+		}
+	}
+	if eff != formatJSON || m.jvFull == nil {
+		if m.pretty == nil || m.pretty.format != eff {
+			m.pretty = formatBody(eff, m.resp.body)
+		}
+		if eff == formatJSON {
+			m.pretty.note = "not valid json"
+		}
+	}
+	return m
+}
+
+func jsonBodyDoc(body []byte) *jsonDoc {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) == 0 || !json.Valid(trimmed) {
+		return nil
+	}
+	return parseJSONDoc(trimmed)
+}
+
+// renderPrettyBody renders the Body tab for every format but interactive JSON.
+func (m Model) renderPrettyBody(w, h int) string {
+	dim := m.theme.dim()
+	p := m.pretty
+	var summary string
+	switch p.format {
+	case formatHTMLText:
+		summary = "HTML text · " + plural(len(p.lines), "line")
+	case formatHex:
+		summary = "binary · " + formatBytes(len(p.hex))
+	case formatImage:
+		summary = p.image.format
+	default:
+		summary = strings.ToUpper(p.format.String()) + " · " + plural(len(p.lines), "line")
+	}
+	hint := dim.Render("  "+summary+"  ") +
+		m.theme.keyHint("v") + dim.Render("format: "+m.bodyFormat.String()+"  ")
+	if p.format == formatImage {
+		hint += m.theme.keyHint("i") + dim.Render("preview  ")
+	} else {
+		hint += m.theme.keyHint("/") + dim.Render("search  ")
+	}
+	hint += m.theme.keyHint("w") + dim.Render("save")
+	if p.note != "" {
+		hint += "  " + m.theme.errStyle().Render(p.note)
+	}
+	out := []string{lipgloss.NewStyle().MaxWidth(w).Render(hint)}
+	if m.searching {
+		out[0] = m.renderSearchBar(w)
+	}
+	if p.format == formatImage {
+		return out[0] + "\n" + m.renderImage(p.image, w, h-1)
+	}
+	if p.lineCount() == 0 {
+		return out[0] + "\n" + dim.Render("  (empty)")
+	}
+	end := min(m.respScroll+h-1, p.lineCount())
+	for i := m.respScroll; i < end; i++ {
+		out = append(out, m.renderSynLine(p.line(i), i, w))
+	}
+	return strings.Join(out, "\n")
+}
+
+// renderSynLine renders row i of a formatted body.
+func (m Model) renderSynLine(l synLine, i, w int) string {
+	pieces := make([]piece, len(l.spans))
+	for j, s := range l.spans {
+		pieces[j] = piece{m.theme.syntaxStyle(s.kind), s.text}
+	}
+	line := "  " + strings.Repeat("  ", l.depth) + m.renderPieces(pieces, i)
+	return lipgloss.NewStyle().MaxWidth(w).Render(line)
+}
+
+// execFormat handles ":format <auto|json|xml|html|text|yaml|raw>".
+func (m Model) execFormat(args []string) Model {
+	if len(args) == 0 {
+		m.cmdInfo = "format " + m.bodyFormat.String() + " (showing " + m.effectiveFormat().String() + ")"
+		return m
+	}
+	f, ok := parseBodyFormat(args[0])
+	if len(args) != 1 || !ok {
+		m.cmdError = "usage: format <" + strings.Join(bodyFormatNames, "|") + ">"
+		return m
+	}
+	m = m.setBodyFormat(f)
+	m.responseTab = respTabBody
+	return m.closeCmdPalette()
+}
@@ -0,0 +1,348 @@
+package ui
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cookieJar is an in-memory http.CookieJar following the RFC 6265 storage model.
+// Unlike net/http/cookiejar it can enumerate, edit and delete its cookies,
+// which the cookies overlay needs. Safe for concurrent use.
+type cookieJar struct {
+	mu      sync.Mutex
+	entries map[string]map[string]cookieEntry // domain → id → entry
+	nextSeq uint64
+	now     func() time.Time
+}
+
+// cookieEntry is one stored cookie (RFC 6265 §5.3).
+type cookieEntry struct {
+	name       string
+	value      string
+	domain     string
+	path       string
+	expires    time.Time // zero for session cookies
+	persistent bool
+	secure     bool
+	httpOnly   bool
+	hostOnly   bool
+	sameSite   string
+	creation   time.Time
+	lastAccess time.Time
+	seq        uint64 // tie-breaker for equal creation times
+}
+
+// id uniquely identifies a cookie within the jar: name, domain and path.
+func (e cookieEntry) id() string {
+	return e.domain + ";" + e.path + ";" + e.name
+}
+
+func (e cookieEntry) expired(now time.Time) bool {
+	return e.persistent && !e.expires.After(now)
+}
+
+func newCookieJar() *cookieJar {
+	return &cookieJar{entries: map[string]map[string]cookieEntry{}, now: time.Now}
+}
+
+// SetCookies implements http.CookieJar.
+func (j *cookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return
+	}
+	host := canonicalHost(u.Host)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := j.now()
+	for _, c := range cookies {
+		e, ok := j.newEntry(c, host, u, now)
+		if !ok {
+			continue
+		}
+		byID := j.entries[e.domain]
+		if e.expired(now) {
+			// An already-expired cookie is how servers delete one (§5.3 step 11).
+			delete(byID, e.id())
+			if len(byID) == 0 {
+				delete(j.entries, e.domain)
+			}
+			continue
+		}
+		if old, ok := byID[e.id()]; ok {
+			e.creation = old.creation
+			e.seq = old.seq
+		} else {
+			j.nextSeq++
+			e.seq = j.nextSeq
+		}
+		if byID == nil {
+			byID = map[string]cookieEntry{}
+			j.entries[e.domain] = byID
+		}
+		byID[e.id()] = e
+	}
+}
+
+// newEntry applies the §5.3 storage algorithm to c received from host.
+// ok is false when the cookie must be ignored.
+func (j *cookieJar) newEntry(c *http.Cookie, host string, u *url.URL, now time.Time) (cookieEntry, bool) {
+	e := cookieEntry{
+		name:       c.Name,
+		value:      c.Value,
+		secure:     c.Secure,
+		httpOnly:   c.HttpOnly,
+		creation:   now,
+		lastAccess: now,
+	}
+	switch c.SameSite {
+	case http.SameSiteLaxMode:
+		e.sameSite = "Lax"
+	case http.SameSiteStrictMode:
+		e.sameSite = "Strict"
+	case http.SameSiteNoneMode:
+		e.sameSite = "None"
+	}
+
+	switch {
+	case c.MaxAge < 0:
+		e.persistent = true
+		e.expires = time.Unix(1, 0)
+	case c.MaxAge > 0:
+		e.persistent = true
+		e.expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+	case !c.Expires.IsZero():
+		e.persistent = true
+		e.expires = c.Expires
+	}
+
+	domain := strings.TrimPrefix(strings.ToLower(c.Domain), ".")
+	switch {
+	case domain == "":
+		e.domain = host
+		e.hostOnly = true
+	case domain == host:
+		e.domain = host
+	case isIP(host):
+		return e, false
+	case !domainMatch(host, domain):
+		return e, false
+	case !strings.Contains(domain, "."):
+		// Without a public suffix list, refuse single-label domains such
+		// as "com" so one site cannot set cookies for a whole TLD.
+		return e, false
+	default:
+		e.domain = domain
+	}
+
+	e.path = c.Path
+	if e.path == "" || e.path[0] != '/' {
+		e.path = defaultCookiePath(u.Path)
+	}
+	return e, true
+}
+
+// Cookies implements http.CookieJar.
+func (j *cookieJar) Cookies(u *url.URL) []*http.Cookie {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil
+	}
+	host := canonicalHost(u.Host)
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	https := u.Scheme == "https"
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := j.now()
+
+	var matched []cookieEntry
+	for domain, byID := range j.entries {
+		if !domainMatch(host, domain) {
+			continue
+		}
+		for id, e := range byID {
+			if e.expired(now) {
+				delete(byID, id)
+				continue
+			}
+			if e.hostOnly && host != e.domain {
+				continue
+			}
+			if e.secure && !https {
+				continue
+			}
+			if !pathMatch(path, e.path) {
+				continue
+			}
+			e.lastAccess = now
+			byID[id] = e
+			matched = append(matched, e)
+		}
+		if len(byID) == 0 {
+			delete(j.entries, domain)
+		}
+	}
+
+	// §5.4 step 2: longer paths first, then earlier creation times.
+	sort.Slice(matched, func(a, b int) bool {
+		if len(matched[a].path) != len(matched[b].path) {
+			return len(matched[a].path) > len(matched[b].path)
+		}
+		if !matched[a].creation.Equal(matched[b].creation) {
+			return matched[a].creation.Before(matched[b].creation)
+		}
+		return matched[a].seq < matched[b].seq
+	})
+
+	out := make([]*http.Cookie, len(matched))
+	for i, e := range matched {
+		out[i] = &http.Cookie{Name: e.name, Value: e.value}
+	}
+	return out
+}
+
+// domains returns every domain holding at least one live cookie, sorted.
+func (j *cookieJar) domains() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.evictLocked()
+	out := make([]string, 0, len(j.entries))
+	for d := range j.entries {
+		out = append(out, d)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// cookies returns the cookies stored for domain, sorted by path then name.
+func (j *cookieJar) cookies(domain string) []cookieEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.evictLocked()
+	out := make([]cookieEntry, 0, len(j.entries[domain]))
+	for _, e := range j.entries[domain] {
+		out = append(out, e)
+	}
+	sortEntries(out)
+	return out
+}
+
+// snapshot copies every live cookie keyed by id, for diffing across a send.
+func (j *cookieJar) snapshot() map[string]cookieEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.evictLocked()
+	out := map[string]cookieEntry{}
+	for _, byID := range j.entries {
+		for id, e := range byID {
+			out[id] = e
+		}
+	}
+	return out
+}
+
+func (j *cookieJar) setValue(domain, id, value string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if e, ok := j.entries[domain][id]; ok {
+		e.value = value
+		j.entries[domain][id] = e
+	}
+}
+
+func (j *cookieJar) remove(domain, id string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	delete(j.entries[domain], id)
+	if len(j.entries[domain]) == 0 {
+		delete(j.entries, domain)
+	}
+}
+
+func (j *cookieJar) removeDomain(domain string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	delete(j.entries, domain)
+}
+
+func (j *cookieJar) evictLocked() {
+	now := j.now()
+	for domain, byID := range j.entries {
+		for id, e := range byID {
+			if e.expired(now) {
+				delete(byID, id)
+			}
+		}
+		if len(byID) == 0 {
+			delete(j.entries, domain)
+		}
+	}
+}
+
+func sortEntries(es []cookieEntry) {
+	sort.Slice(es, func(a, b int) bool {
+		if es[a].domain != es[b].domain {
+			return es[a].domain < es[b].domain
+		}
+		if es[a].path != es[b].path {
+			return es[a].path < es[b].path
+		}
+		return es[a].name < es[b].name
+	})
+}
+
+// canonicalHost strips the port and trailing dot and lower-cases host.
+func canonicalHost(host string) string {
+	if strings.Contains(host, ":") {
+		h, _, err := net.SplitHostPort(host)
+		if err != nil {
+			// IPv6 literal without a port, or a bare host with a stray colon.
+			h = strings.Trim(host, "[]")
+		}
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+func isIP(host string) bool {
+	return net.ParseIP(host) != nil
+}
+
+// domainMatch reports whether host domain-matches domain (RFC 6265 §5.1.3).
+func domainMatch(host, domain string) bool {
+	if host == domain {
+		return true
+	}
+	return !isIP(host) && strings.HasSuffix(host, "."+domain)
+}
+
+// pathMatch reports whether reqPath path-matches cookiePath (RFC 6265 §5.1.4).
+func pathMatch(reqPath, cookiePath string) bool {
+	if reqPath == cookiePath {
+		return true
+	}
+	if !strings.HasPrefix(reqPath, cookiePath) {
+		return false
+	}
+	return strings.HasSuffix(cookiePath, "/") || reqPath[len(cookiePath)] == '/'
+}
+
+// defaultCookiePath computes the default-path of a request URI (RFC 6265 §5.1.4).
+func defaultCookiePath(p string) string {
+	if p == "" || p[0] != '/' {
+		return "/"
+	}
+	i := strings.LastIndex(p, "/")
+	if i == 0 {
+		return "/"
+	}
+	return p[:i]
+}
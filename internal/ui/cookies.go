@@ -0,0 +1,284 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type cookieChangeKind int
+
+const (
+	cookieAdded cookieChangeKind = iota
+	cookieUpdated
+	cookieRemoved
+)
+
+// cookieChange records how one cookie in the jar changed during a send.
+type cookieChange struct {
+	kind  cookieChangeKind
+	entry cookieEntry // new state; old state for removals
+}
+
+// diffCookies compares jar snapshots taken before and after a send.
+func diffCookies(before, after map[string]cookieEntry) []cookieChange {
+	var out []cookieChange
+	for id, e := range after {
+		old, ok := before[id]
+		switch {
+		case !ok:
+			out = append(out, cookieChange{kind: cookieAdded, entry: e})
+		case old.value != e.value || !old.expires.Equal(e.expires):
+			out = append(out, cookieChange{kind: cookieUpdated, entry: e})
+		}
+	}
+	for id, e := range before {
+		if _, ok := after[id]; !ok {
+			out = append(out, cookieChange{kind: cookieRemoved, entry: e})
+		}
+	}
+	sort.Slice(out, func(a, b int) bool {
+		return out[a].entry.id() < out[b].entry.id()
+	})
+	return out
+}
+
+// jar returns the cookie jar of the active environment, creating it on first use.
+func (m Model) jar() *cookieJar {
+	name := m.env().name
+	j, ok := m.jars[name]
+	if !ok {
+		j = newCookieJar()
+		m.jars[name] = j
+	}
+	return j
+}
+
+// ckItem represents one row in the cookies overlay.
+// If id is empty it is a domain row; otherwise it is a cookie row.
+type ckItem struct {
+	domain string
+	id     string
+}
+
+func (m Model) ckFlatItems() []ckItem {
+	jar := m.jar()
+	var items []ckItem
+	for _, d := range jar.domains() {
+		items = append(items, ckItem{domain: d})
+		for _, e := range jar.cookies(d) {
+			items = append(items, ckItem{domain: d, id: e.id()})
+		}
+	}
+	return items
+}
+
+func (m Model) updateCookies(msg tea.KeyMsg) Model {
+	items := m.ckFlatItems()
+
+	// Editing mode: the inline input replaces the selected cookie's value
+	if m.ckEditing {
+		switch msg.String() {
+		case "esc":
+			m.ckEditing = false
+			m.ckEditInput = ""
+		case "enter":
+			if m.ckCursor < len(items) && items[m.ckCursor].id != "" {
+				it := items[m.ckCursor]
+				m.jar().setValue(it.domain, it.id, m.ckEditInput)
+			}
+			m.ckEditing = false
+			m.ckEditInput = ""
+		case "backspace":
+			runes := []rune(m.ckEditInput)
+			if len(runes) > 0 {
+				m.ckEditInput = string(runes[:len(runes)-1])
+			}
+		default:
+			if len([]rune(msg.String())) == 1 {
+				m.ckEditInput += msg.String()
+			}
+		}
+		return m
+	}
+
+	switch msg.String() {
+	case "esc", "q":
+		m.showCookies = false
+	case "j", "down", "ctrl+j":
+		if m.ckCursor < len(items)-1 {
+			m.ckCursor++
+		}
+	case "k", "up", "ctrl+k":
+		if m.ckCursor > 0 {
+			m.ckCursor--
+		}
+	case "e":
+		if m.ckCursor < len(items) && items[m.ckCursor].id != "" {
+			it := items[m.ckCursor]
+			for _, e := range m.jar().cookies(it.domain) {
+				if e.id() == it.id {
+					m.ckEditInput = e.value
+				}
+			}
+			m.ckEditing = true
+		}
+	case "d":
+		if m.ckCursor < len(items) {
+			it := items[m.ckCursor]
+			if it.id == "" {
+				m.jar().removeDomain(it.domain)
+			} else {
+				m.jar().remove(it.domain, it.id)
+			}
+			if n := len(m.ckFlatItems()); m.ckCursor >= n {
+				m.ckCursor = max(0, n-1)
+			}
+		}
+	}
+	return m
+}
+
+// renderCookies renders the floating cookie jar viewer for the active environment.
+func (m Model) renderCookies() string {
+	outerW := m.width - 6
+	if outerW < 60 {
+		outerW = 60
+	}
+	innerW := outerW - 4
+	contentW := innerW - 2 // Width includes the horizontal padding
+
+	dim := m.theme.dim()
+	val := m.theme.textMuted()
+	accent := m.theme.accent()
+
+	kh := func(key, label string) string {
+		return "  " + m.theme.keyHint(key) + dim.Render(label)
+	}
+	header := m.theme.highlight().Bold(true).Render(" Cookies") +
+		dim.Render(" · "+m.env().name) +
+		kh("e", "edit value") + kh("d", "delete") + kh("esc", "close")
+
+	var inputLine string
+	if m.ckEditing {
+		inputLine = dim.Render(" New value: ") +
+			m.theme.text().Render(m.ckEditInput) +
+			accent.Render("█") +
+			"  " + m.theme.keyHint("enter") + dim.Render("save") +
+			"  " + m.theme.keyHint("esc") + dim.Render("cancel")
+	} else {
+		inputLine = dim.Render(" grouped by domain")
+	}
+
+	jar := m.jar()
+	var lines []string
+	for i, it := range m.ckFlatItems() {
+		selected := i == m.ckCursor
+		prefix := dim.Render("  ")
+		if selected {
+			prefix = accent.Bold(true).Render("> ")
+		}
+		if it.id == "" {
+			count := dim.Render(fmt.Sprintf("(%d)", len(jar.cookies(it.domain))))
+			lines = append(lines, prefix+lipgloss.NewStyle().Bold(selected).Render(it.domain)+" "+count)
+			continue
+		}
+		for _, e := range jar.cookies(it.domain) {
+			if e.id() != it.id {
+				continue
+			}
+			name := m.theme.highlight().Render(e.name)
+			if selected {
+				name = m.theme.highlight().Bold(true).Render(e.name)
+			}
+			lines = append(lines, lipgloss.NewStyle().MaxWidth(contentW).Render(
+				prefix+"  "+name+dim.Render("=")+val.Render(m.cookieValue(e.value))+"  "+dim.Render(cookieAttrs(e))))
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, dim.Render("  no cookies stored"))
+	}
+
+	contentH := m.height*6/10 - 5
+	if contentH < 7 {
+		contentH = 7
+	}
+	if len(lines) > contentH {
+		start := min(max(0, m.ckCursor-contentH+1), len(lines)-contentH)
+		lines = lines[start : start+contentH]
+	}
+
+	hdiv := dim.Render(strings.Repeat("─", contentW))
+	list := lipgloss.NewStyle().Width(contentW).Height(contentH).Render(strings.Join(lines, "\n"))
+	content := strings.Join([]string{header, inputLine, hdiv, list}, "\n")
+	return m.theme.overlayStyle().
+		Padding(0, 1).
+		Width(innerW).
+		Render(content)
+}
+
+// cookieValue is v as shown on screen: hidden in presentation mode, as
+// cookies often carry sessions.
+func (m Model) cookieValue(v string) string {
+	if m.presenting && v != "" {
+		return redactedMask
+	}
+	return v
+}
+
+// cookieAttrs summarizes the attributes of a stored cookie on one line.
+func cookieAttrs(e cookieEntry) string {
+	parts := []string{"path=" + e.path}
+	if e.persistent {
+		parts = append(parts, "expires="+e.expires.Local().Format("2006-01-02 15:04"))
+	} else {
+		parts = append(parts, "session")
+	}
+	if e.hostOnly {
+		parts = append(parts, "host-only")
+	}
+	if e.secure {
+		parts = append(parts, "secure")
+	}
+	if e.httpOnly {
+		parts = append(parts, "httponly")
+	}
+	if e.sameSite != "" {
+		parts = append(parts, "samesite="+e.sameSite)
+	}
+	return strings.Join(parts, " ")
+}
+
+// renderCookieChanges renders the Cookies tab of the response pane.
+func (m Model) renderCookieChanges(resp response, w int) string {
+	dim := m.theme.dim()
+	val := m.theme.textMuted()
+
+	if resp.jarOff {
+		return dim.Render("  cookie jar disabled for this request  ") + m.theme.keyHint(":jar on")
+	}
+	if len(resp.cookies) == 0 {
+		return dim.Render("  no cookie changes")
+	}
+
+	var lines []string
+	for _, c := range resp.cookies {
+		var mark string
+		switch c.kind {
+		case cookieAdded:
+			mark = m.theme.successStyle().Render("  + ")
+		case cookieUpdated:
+			mark = m.theme.highlight().Bold(true).Render("  ~ ")
+		case cookieRemoved:
+			mark = m.theme.errStyle().Bold(true).Render("  - ")
+		}
+		e := c.entry
+		line := mark + m.theme.highlight().Render(e.name) + dim.Render("=") + val.Render(m.cookieValue(e.value)) +
+			dim.Render("  "+e.domain+" "+cookieAttrs(e))
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(w).Render(line))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,146 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// resolveEntry pins host:port to a fixed address, like curl's --resolve.
+// port "*" matches any port.
+type resolveEntry struct {
+	host string
+	port string
+	addr string
+}
+
+func (e resolveEntry) String() string {
+	return e.host + ":" + e.port + ":" + e.addr
+}
+
+// parseResolve parses "host:port:addr". addr may be an IPv6 literal in brackets.
+func parseResolve(s string) (resolveEntry, error) {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return resolveEntry{}, fmt.Errorf("expected host:port:addr, got %q", s)
+	}
+	addr := strings.TrimSuffix(strings.TrimPrefix(parts[2], "["), "]")
+	if net.ParseIP(addr) == nil {
+		return resolveEntry{}, fmt.Errorf("not an IP address: %q", parts[2])
+	}
+	return resolveEntry{host: strings.ToLower(parts[0]), port: parts[1], addr: addr}, nil
+}
+
+// splitUnixURL splits "unix:///path/to.sock:/http/path" into the socket path
+// and the HTTP path. The HTTP path defaults to "/".
+func splitUnixURL(raw string) (socket, path string, ok bool) {
+	rest, ok := strings.CutPrefix(raw, "unix://")
+	if !ok {
+		return "", "", false
+	}
+	socket, path, found := strings.Cut(rest, ":")
+	if !found || path == "" {
+		path = "/"
+	}
+	return socket, path, true
+}
+
+// dialContext returns a dialer that connects to socket when set, and
+// otherwise applies the resolve overrides before dialing TCP.
+func dialContext(socket string, resolves []resolveEntry, timeout time.Duration) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	d := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if socket != "" {
+			return d.DialContext(ctx, "unix", socket)
+		}
+		return d.DialContext(ctx, network, applyResolve(addr, resolves))
+	}
+}
+
+// applyResolve rewrites addr ("host:port") using the first matching override.
+func applyResolve(addr string, resolves []resolveEntry) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	host = strings.ToLower(host)
+	for _, e := range resolves {
+		if e.host == host && (e.port == "*" || e.port == port) {
+			return net.JoinHostPort(e.addr, port)
+		}
+	}
+	return addr
+}
+
+// execSocket handles ":socket <path|off>" for the active request.
+func (m Model) execSocket(args []string) Model {
+	if m.activeFolderIdx < 0 {
+		m.cmdError = "no request selected"
+		return m
+	}
+	r := &m.folders[m.activeFolderIdx].requests[m.activeReqIdx]
+	if len(args) == 0 {
+		if r.socket == "" {
+			m.cmdInfo = "socket off"
+		} else {
+			m.cmdInfo = "socket " + r.socket
+		}
+		return m
+	}
+	if len(args) != 1 {
+		m.cmdError = "usage: socket <path|off>"
+		return m
+	}
+	if args[0] == "off" {
+		r.socket = ""
+	} else {
+		r.socket = args[0]
+	}
+	return m.closeCmdPalette()
+}
+
+// execResolve handles ":resolve [host:port:addr|clear]".
+func (m Model) execResolve(args []string) Model {
+	if len(args) == 0 {
+		if len(m.resolves) == 0 {
+			m.cmdInfo = "no resolve overrides"
+			return m
+		}
+		var s []string
+		for _, e := range m.resolves {
+			s = append(s, e.String())
+		}
+		m.cmdInfo = "resolve " + strings.Join(s, " · ")
+		return m
+	}
+	if args[0] == "clear" {
+		m.resolves = nil
+		return m.closeCmdPalette()
+	}
+	var added []resolveEntry
+	for _, a := range args {
+		e, err := parseResolve(a)
+		if err != nil {
+			m.cmdError = err.Error()
+			return m
+		}
+		added = append(added, e)
+	}
+	// Later entries for the same host:port replace earlier ones.
+	next := added
+	for _, old := range m.resolves {
+		replaced := false
+		for _, e := range added {
+			if e.host == old.host && e.port == old.port {
+				replaced = true
+			}
+		}
+		if !replaced {
+			next = append(next, old)
+		}
+	}
+	m.resolves = next
+	return m.closeCmdPalette()
+}
@@ -0,0 +1,609 @@
+package ui
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxLCSCells bounds the table of the longest-common-subsequence match. Past
+// it the differing middle of two sequences is compared position by position.
+const maxLCSCells = 4 << 20
+
+type diffKind int
+
+const (
+	diffSame diffKind = iota
+	diffChanged
+	diffRemoved // only in the baseline, shown on the left
+	diffAdded   // only in the latest response, shown on the right
+)
+
+// diffRow is one aligned row of the side-by-side diff. A side without a
+// counterpart is left blank.
+type diffRow struct {
+	kind        diffKind
+	depth       int
+	left, right string
+}
+
+// responseDiff compares the latest response with a baseline: a pinned
+// example or an earlier response of the same request.
+type responseDiff struct {
+	label  string // names the baseline in its pane title
+	base   snapshot
+	latest snapshot
+	json   bool // bodies were compared structurally
+	rows   []diffRow
+	counts [4]int // rows per kind
+}
+
+// diffSnapshots compares two responses. JSON bodies are compared as values:
+// object keys are matched by name, so key order never shows up as a change,
+// and array elements are aligned by their longest common subsequence. Other
+// bodies are compared line by line as the body viewer formats them.
+func diffSnapshots(label string, base, latest snapshot) *responseDiff {
+	d := &responseDiff{label: label, base: base, latest: latest}
+	if isJSON(base.header, base.body) && isJSON(latest.header, latest.body) {
+		a, errA := jqDecode(base.body)
+		b, errB := jqDecode(latest.body)
+		if errA == nil && errB == nil {
+			d.json = true
+			jd := &jsonDiffer{}
+			jd.value(0, "", a, b)
+			d.rows = jd.rows
+		}
+	}
+	if !d.json {
+		d.rows = diffText(base, latest)
+	}
+	for _, r := range d.rows {
+		d.counts[r.kind]++
+	}
+	return d
+}
+
+// identical reports whether the bodies compare equal.
+func (d *responseDiff) identical() bool {
+	return d.counts[diffChanged]+d.counts[diffAdded]+d.counts[diffRemoved] == 0
+}
+
+type jsonDiffer struct {
+	rows []diffRow
+}
+
+func (d *jsonDiffer) add(kind diffKind, depth int, left, right string) {
+	d.rows = append(d.rows, diffRow{kind, depth, left, right})
+}
+
+// side adds a row of one side only, or of both for unchanged rows.
+func (d *jsonDiffer) side(kind diffKind, depth int, text string) {
+	switch kind {
+	case diffRemoved:
+		d.add(kind, depth, text, "")
+	case diffAdded:
+		d.add(kind, depth, "", text)
+	default:
+		d.add(kind, depth, text, text)
+	}
+}
+
+// value diffs a against b; prefix is the `"key": ` they are printed after.
+func (d *jsonDiffer) value(depth int, prefix string, a, b any) {
+	if canonicalJSON(a) == canonicalJSON(b) {
+		d.whole(diffSame, depth, prefix, a)
+		return
+	}
+	switch at := a.(type) {
+	case *jqObject:
+		if bt, ok := b.(*jqObject); ok {
+			d.add(diffSame, depth, prefix+"{", prefix+"{")
+			for _, k := range unionKeys(at, bt) {
+				av, inA := at.vals[k]
+				bv, inB := bt.vals[k]
+				switch {
+				case inA && inB:
+					d.value(depth+1, jsonKeyPrefix(k), av, bv)
+				case inA:
+					d.whole(diffRemoved, depth+1, jsonKeyPrefix(k), av)
+				default:
+					d.whole(diffAdded, depth+1, jsonKeyPrefix(k), bv)
+				}
+			}
+			d.add(diffSame, depth, "}", "}")
+			return
+		}
+	case []any:
+		if bt, ok := b.([]any); ok {
+			d.array(depth, prefix, at, bt)
+			return
+		}
+	}
+	if isJQScalar(a) && isJQScalar(b) {
+		d.add(diffChanged, depth, prefix+jqScalarText(a), prefix+jqScalarText(b))
+		return
+	}
+	d.whole(diffRemoved, depth, prefix, a)
+	d.whole(diffAdded, depth, prefix, b)
+}
+
+// array aligns equal elements, then diffs the elements between them pairwise
+// so an edited element shows what changed inside it.
+func (d *jsonDiffer) array(depth int, prefix string, a, b []any) {
+	d.add(diffSame, depth, prefix+"[", prefix+"[")
+	ka := make([]string, len(a))
+	for i, v := range a {
+		ka[i] = canonicalJSON(v)
+	}
+	kb := make([]string, len(b))
+	for i, v := range b {
+		kb[i] = canonicalJSON(v)
+	}
+	i, j := 0, 0
+	for _, p := range append(lcsPairs(ka, kb), [2]int{len(a), len(b)}) {
+		ra, rb := a[i:p[0]], b[j:p[1]]
+		n := min(len(ra), len(rb))
+		for k := 0; k < n; k++ {
+			d.value(depth+1, "", ra[k], rb[k])
+		}
+		for _, v := range ra[n:] {
+			d.whole(diffRemoved, depth+1, "", v)
+		}
+		for _, v := range rb[n:] {
+			d.whole(diffAdded, depth+1, "", v)
+		}
+		if p[0] < len(a) {
+			d.whole(diffSame, depth+1, "", a[p[0]])
+		}
+		i, j = p[0]+1, p[1]+1
+	}
+	d.add(diffSame, depth, "]", "]")
+}
+
+// whole prints v on one side, or on both when kind is diffSame. Keys are
+// sorted so both sides of the diff list them in the same order.
+func (d *jsonDiffer) whole(kind diffKind, depth int, prefix string, v any) {
+	switch t := v.(type) {
+	case *jqObject:
+		if len(t.keys) == 0 {
+			d.side(kind, depth, prefix+"{}")
+			return
+		}
+		d.side(kind, depth, prefix+"{")
+		keys := append([]string(nil), t.keys...)
+		sort.Strings(keys)
+		for _, k := range keys {
+			d.whole(kind, depth+1, jsonKeyPrefix(k), t.vals[k])
+		}
+		d.side(kind, depth, "}")
+	case []any:
+		if len(t) == 0 {
+			d.side(kind, depth, prefix+"[]")
+			return
+		}
+		d.side(kind, depth, prefix+"[")
+		for _, e := range t {
+			d.whole(kind, depth+1, "", e)
+		}
+		d.side(kind, depth, "]")
+	default:
+		d.side(kind, depth, prefix+jqScalarText(v))
+	}
+}
+
+func unionKeys(a, b *jqObject) []string {
+	keys := append([]string(nil), a.keys...)
+	for _, k := range b.keys {
+		if _, ok := a.vals[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func jsonKeyPrefix(k string) string {
+	return jqScalarText(k) + ": "
+}
+
+func isJQScalar(v any) bool {
+	switch v.(type) {
+	case *jqObject, []any:
+		return false
+	}
+	return true
+}
+
+func jqScalarText(v any) string {
+	var buf bytes.Buffer
+	jqEncode(&buf, v)
+	return buf.String()
+}
+
+// canonicalJSON encodes v with sorted keys and normalized numbers, so two
+// values encode the same exactly when they are equal as JSON.
+func canonicalJSON(v any) string {
+	var buf bytes.Buffer
+	writeCanonicalJSON(&buf, v)
+	return buf.String()
+}
+
+func writeCanonicalJSON(buf *bytes.Buffer, v any) {
+	switch t := v.(type) {
+	case *jqObject:
+		keys := append([]string(nil), t.keys...)
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			jqEncode(buf, k)
+			buf.WriteByte(':')
+			writeCanonicalJSON(buf, t.vals[k])
+		}
+		buf.WriteByte('}')
+	case []any:
+		buf.WriteByte('[')
+		for i, e := range t {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalJSON(buf, e)
+		}
+		buf.WriteByte(']')
+	case json.Number:
+		// 1.0 equals 1, but integers keep their digits: IDs past 2^53 would
+		// collide as floats.
+		if f, err := t.Float64(); err == nil && strings.ContainsAny(t.String(), ".eE") {
+			buf.WriteString(jqFormatNumber(f))
+			return
+		}
+		buf.WriteString(t.String())
+	default:
+		jqEncode(buf, v)
+	}
+}
+
+// lcsPairs returns the index pairs of a longest common subsequence of a and b.
+func lcsPairs(a, b []string) [][2]int {
+	var pairs [][2]int
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pairs = append(pairs, [2]int{pre, pre})
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	if len(ma) > 0 && len(mb) > 0 && len(ma)*len(mb) <= maxLCSCells {
+		// table[i*w+j] is the LCS length of ma[i:] and mb[j:]
+		w := len(mb) + 1
+		table := make([]int32, (len(ma)+1)*w)
+		for i := len(ma) - 1; i >= 0; i-- {
+			for j := len(mb) - 1; j >= 0; j-- {
+				if ma[i] == mb[j] {
+					table[i*w+j] = table[(i+1)*w+j+1] + 1
+				} else {
+					table[i*w+j] = max(table[(i+1)*w+j], table[i*w+j+1])
+				}
+			}
+		}
+		for i, j := 0, 0; i < len(ma) && j < len(mb); {
+			switch {
+			case ma[i] == mb[j]:
+				pairs = append(pairs, [2]int{pre + i, pre + j})
+				i++
+				j++
+			case table[(i+1)*w+j] >= table[i*w+j+1]:
+				i++
+			default:
+				j++
+			}
+		}
+	}
+	for k := suf; k > 0; k-- {
+		pairs = append(pairs, [2]int{len(a) - k, len(b) - k})
+	}
+	return pairs
+}
+
+// diffText compares bodies line by line, formatted as the body viewer shows
+// them. Binary bodies are only compared as a whole.
+func diffText(base, latest snapshot) []diffRow {
+	la, binA := diffLines(base)
+	lb, binB := diffLines(latest)
+	if binA || binB {
+		kind := diffChanged
+		if bytes.Equal(base.body, latest.body) {
+			kind = diffSame
+		}
+		return []diffRow{{kind: kind, left: binarySummary(base.body), right: binarySummary(latest.body)}}
+	}
+	ka := make([]string, len(la))
+	for i, l := range la {
+		ka[i] = strings.Repeat("  ", l.depth) + synLineText(l)
+	}
+	kb := make([]string, len(lb))
+	for i, l := range lb {
+		kb[i] = strings.Repeat("  ", l.depth) + synLineText(l)
+	}
+	var rows []diffRow
+	i, j := 0, 0
+	for _, p := range append(lcsPairs(ka, kb), [2]int{len(la), len(lb)}) {
+		n := min(p[0]-i, p[1]-j)
+		for k := 0; k < n; k++ {
+			rows = append(rows, diffRow{diffChanged, 0, ka[i+k], kb[j+k]})
+		}
+		for _, t := range ka[i+n : p[0]] {
+			rows = append(rows, diffRow{kind: diffRemoved, left: t})
+		}
+		for _, t := range kb[j+n : p[1]] {
+			rows = append(rows, diffRow{kind: diffAdded, right: t})
+		}
+		if p[0] < len(la) {
+			rows = append(rows, diffRow{diffSame, 0, ka[p[0]], kb[p[1]]})
+		}
+		i, j = p[0]+1, p[1]+1
+	}
+	return rows
+}
+
+// diffLines formats a body for the line diff, or reports it as binary.
+func diffLines(s snapshot) ([]synLine, bool) {
+	f := detectFormat(s.header, s.body)
+	if f == formatImage || f == formatHex {
+		return nil, true
+	}
+	p := formatBody(f, s.body)
+	if p.hex != nil {
+		return nil, true
+	}
+	lines := make([]synLine, p.lineCount())
+	for i := range lines {
+		lines[i] = p.line(i)
+	}
+	return lines, false
+}
+
+func binarySummary(body []byte) string {
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf("binary · %s · sha256 %x", formatBytes(len(body)), sum[:6])
+}
+
+// execDiff handles ":diff [name|#n|off]". It compares the latest response
+// with the example called name, with the response n sends before it, or, by
+// default, with the one just before it.
+func (m Model) execDiff(args []string) Model {
+	arg := strings.Join(args, " ")
+	if arg == "off" {
+		m.diff = nil
+		return m.closeCmdPalette()
+	}
+	if m.activeFolderIdx < 0 || m.resp == nil || m.resp.statusCode == 0 {
+		m.cmdError = "no response to compare"
+		return m
+	}
+	r := m.folders[m.activeFolderIdx].requests[m.activeReqIdx]
+	var label string
+	var base snapshot
+	switch {
+	case arg == "" || strings.HasPrefix(arg, "#"):
+		n := 1
+		if arg != "" {
+			v, err := strconv.Atoi(arg[1:])
+			if err != nil || v < 1 {
+				m.cmdError = "usage: diff [example|#n|off]"
+				return m
+			}
+			n = v
+		}
+		if n >= len(r.history) {
+			m.cmdError = "no response #" + strconv.Itoa(n) + " in history (:history lists them)"
+			return m
+		}
+		base = r.history[len(r.history)-1-n]
+		label = "#" + strconv.Itoa(n) + " · " + base.at.Format("15:04:05")
+	default:
+		i := r.exampleIndex(arg)
+		if i < 0 {
+			m.cmdError = "no example " + strconv.Quote(arg)
+			return m
+		}
+		base = r.examples[i].snapshot
+		label = "example " + strconv.Quote(arg)
+	}
+	m.diff = diffSnapshots(label, base, m.snapshot(*m.resp))
+	m.diffScroll = 0
+	return m.closeCmdPalette()
+}
+
+// refreshDiff compares a new response with the baseline on screen.
+func (m Model) refreshDiff() Model {
+	if m.diff != nil && m.resp != nil && m.resp.statusCode != 0 {
+		m.diff = diffSnapshots(m.diff.label, m.diff.base, m.snapshot(*m.resp))
+		m.diffScroll = 0
+	}
+	return m
+}
+
+// diffBodyHeight is the number of rows each diff pane shows.
+func (m Model) diffBodyHeight() int {
+	mainH := m.height - 1
+	innerH := mainH - 2
+	if !m.splitVertical {
+		innerH = mainH/2 - 2
+	}
+	return innerH - 3
+}
+
+// updateDiff handles keys while the diff is on screen. ok is false for keys
+// it leaves to the rest of the UI, such as s to send again.
+func (m Model) updateDiff(msg tea.KeyMsg) (Model, bool) {
+	page := max(1, m.diffBodyHeight())
+	last := max(0, len(m.diff.rows)-page)
+	switch msg.String() {
+	case "esc":
+		m.diff = nil
+	case "j", "down":
+		m.diffScroll = min(m.diffScroll+1, last)
+	case "k", "up":
+		m.diffScroll = max(m.diffScroll-1, 0)
+	case "ctrl+d":
+		m.diffScroll = min(m.diffScroll+page/2, last)
+	case "ctrl+u":
+		m.diffScroll = max(m.diffScroll-page/2, 0)
+	case "g":
+		m.diffScroll = 0
+	case "G":
+		m.diffScroll = last
+	case "n", "N":
+		if row, ok := m.diff.nextChange(m.diffScroll, msg.String() == "N"); ok {
+			m.diffScroll = min(row, last)
+		}
+	default:
+		return m, false
+	}
+	return m, true
+}
+
+// nextChange returns the first row of the next (or previous) run of changed
+// rows after from, wrapping around.
+func (d *responseDiff) nextChange(from int, back bool) (int, bool) {
+	var starts []int
+	for i, r := range d.rows {
+		if r.kind != diffSame && (i == 0 || d.rows[i-1].kind == diffSame) {
+			starts = append(starts, i)
+		}
+	}
+	if len(starts) == 0 {
+		return 0, false
+	}
+	if back {
+		for i := len(starts) - 1; i >= 0; i-- {
+			if starts[i] < from {
+				return starts[i], true
+			}
+		}
+		return starts[len(starts)-1], true
+	}
+	for _, s := range starts {
+		if s > from {
+			return s, true
+		}
+	}
+	return starts[0], true
+}
+
+// renderDiff lays the baseline and the latest response out like the request
+// and response panes: side by side, or stacked when the split is horizontal.
+// Both panes scroll together, so each row faces its counterpart.
+func (m Model) renderDiff(mainH int) string {
+	if m.splitVertical {
+		leftOuterW := m.width / 2
+		leftInnerW := leftOuterW - 2
+		rightInnerW := m.width - leftOuterW - 2
+		innerH := mainH - 2
+		left := m.theme.paneStyle(false).Width(leftInnerW).Height(innerH).
+			Render(m.renderDiffPane(false, leftInnerW, innerH))
+		right := m.theme.paneStyle(true).Width(rightInnerW).Height(innerH).
+			Render(m.renderDiffPane(true, rightInnerW, innerH))
+		return lipgloss.JoinHorizontal(lipgloss.Top, left, right)
+	}
+	innerW := m.width - 2
+	topOuterH := mainH / 2
+	top := m.theme.paneStyle(false).Width(innerW).Height(topOuterH - 2).
+		Render(m.renderDiffPane(false, innerW, topOuterH-2))
+	bottom := m.theme.paneStyle(true).Width(innerW).Height(mainH - topOuterH - 2).
+		Render(m.renderDiffPane(true, innerW, mainH-topOuterH-2))
+	return lipgloss.JoinVertical(lipgloss.Left, top, bottom)
+}
+
+func (m Model) renderDiffPane(latest bool, w, h int) string {
+	d := m.diff
+	dim := m.theme.dim()
+	s, title := d.base, " "+d.label+" "
+	if latest {
+		s, title = d.latest, " Latest "
+	}
+	status := m.statusStyle(s.statusCode).Render(s.status)
+	if d.base.statusCode != d.latest.statusCode {
+		status += m.theme.diffStyle(diffChanged).Render(" ~")
+	}
+	head := m.theme.paneTitle(title, latest) + " " + status + dim.Render(
+		" · "+s.duration.Round(time.Millisecond).String()+" · "+formatBytes(len(s.body)))
+
+	var info string
+	if latest {
+		info = m.diffSummary() + "  " + m.theme.keyHint("n/N") + dim.Render("change  ") +
+			m.theme.keyHint("esc") + dim.Render("close")
+	} else {
+		mode := "line diff"
+		if d.json {
+			mode = "JSON diff · key order ignored"
+		}
+		info = dim.Render("  " + mode)
+	}
+	lines := []string{
+		lipgloss.NewStyle().MaxWidth(w).Render(head),
+		lipgloss.NewStyle().MaxWidth(w).Render(info),
+		dim.Render(strings.Repeat("─", w)),
+	}
+	end := min(m.diffScroll+max(0, h-3), len(d.rows))
+	for _, r := range d.rows[m.diffScroll:end] {
+		lines = append(lines, m.renderDiffRow(r, latest, w))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m Model) renderDiffRow(r diffRow, latest bool, w int) string {
+	text := r.left
+	if latest {
+		text = r.right
+	}
+	if (r.kind == diffAdded && !latest) || (r.kind == diffRemoved && latest) {
+		return ""
+	}
+	marker := "  "
+	switch r.kind {
+	case diffChanged:
+		marker = "~ "
+	case diffRemoved:
+		marker = "- "
+	case diffAdded:
+		marker = "+ "
+	}
+	style := m.theme.textMuted()
+	if r.kind != diffSame {
+		style = m.theme.diffStyle(r.kind)
+	}
+	line := style.Render(marker + strings.Repeat("  ", r.depth) + printable(text))
+	return lipgloss.NewStyle().MaxWidth(w).Render(line)
+}
+
+func (m Model) diffSummary() string {
+	d := m.diff
+	if d.identical() {
+		return m.theme.successStyle().Render("  identical")
+	}
+	var parts []string
+	for _, k := range []struct {
+		kind diffKind
+		name string
+	}{{diffChanged, "changed"}, {diffAdded, "added"}, {diffRemoved, "removed"}} {
+		if n := d.counts[k.kind]; n > 0 {
+			parts = append(parts, m.theme.diffStyle(k.kind).Render(fmt.Sprintf("%d %s", n, k.name)))
+		}
+	}
+	return "  " + strings.Join(parts, m.theme.dim().Render(" · "))
+}
@@ -0,0 +1,60 @@
+package ui
+
+// environment is a named set of variables substituted into requests as {{name}}.
+// Each environment also owns its own cookie jar (see Model.jar).
+type environment struct {
+	name    string
+	vars    []variable
+	secrets *vault // resolves {{$secret name}}; set by Model.env
+}
+
+type variable struct {
+	key   string
+	value string
+}
+
+// lookup returns the value of the named variable.
+func (e environment) lookup(key string) (string, bool) {
+	for _, v := range e.vars {
+		if v.key == key {
+			return v.value, true
+		}
+	}
+	return "", false
+}
+
+// env returns the active environment with the runtime variables laid over it,
+// as sends resolve them, along with the answers to the prompts of the send
+// being started.
+func (m Model) env() environment {
+	e := m.envs[m.activeEnv]
+	e.secrets = m.vault
+	for _, v := range m.runtimeVars {
+		e = e.with(v.key, v.value)
+	}
+	for label, answer := range m.promptAnswers {
+		e = e.with(promptKey(label), answer)
+	}
+	return e
+}
+
+var mockEnvironments = []environment{
+	{
+		name: "development",
+		vars: []variable{
+			{key: "githubUser", value: "octocat"},
+		},
+	},
+	{
+		name: "staging",
+		vars: []variable{
+			{key: "githubUser", value: "octocat"},
+		},
+	},
+	{
+		name: "production",
+		vars: []variable{
+			{key: "githubUser", value: "octocat"},
+		},
+	},
+}
@@ -0,0 +1,307 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Variable scopes an extractor can write to. Environment variables belong to
+// the active environment; runtime variables last for the session, apply in
+// every environment and take precedence over environment variables.
+const (
+	scopeEnv     = "env"
+	scopeRuntime = "runtime"
+)
+
+// extractor is a parsed entry of a request's extractor list, stored as the
+// text the user typed, e.g.
+//
+//	access_token json .access_token
+//	runtime etag header ETag
+//	order_id regex "id":\s*(\d+)
+//	session cookie SID
+type extractor struct {
+	scope  string // scopeEnv or scopeRuntime
+	name   string // variable to set
+	source string // json, header, regex or cookie
+	arg    string
+	path   jqNode
+	re     *regexp.Regexp
+}
+
+// extractResult is the outcome of one extractor after a send.
+type extractResult struct {
+	spec  string
+	scope string
+	name  string
+	value string
+	err   string // why nothing was captured, if so
+}
+
+// parseExtractor parses the text form of an extractor.
+func parseExtractor(spec string) (extractor, error) {
+	const usage = "usage: [runtime] <var> json <path>|header <name>|regex <re>|cookie <name>"
+	x := extractor{scope: scopeEnv}
+	word, rest := cutWord(spec)
+	if word == scopeEnv || word == scopeRuntime {
+		x.scope = word
+		word, rest = cutWord(rest)
+	}
+	x.name = word
+	x.source, x.arg = cutWord(rest)
+	if x.name == "" || x.arg == "" || strings.ContainsAny(x.name, "{} ") {
+		return x, errors.New(usage)
+	}
+	switch x.source {
+	case "json":
+		q, err := parseJSONPath(x.arg)
+		if err != nil {
+			return x, err
+		}
+		x.path = q
+	case "regex":
+		re, err := regexp.Compile(x.arg)
+		if err != nil {
+			return x, fmt.Errorf("bad regex: %w", err)
+		}
+		x.re = re
+	case "header", "cookie":
+	default:
+		return x, errors.New(usage)
+	}
+	return x, nil
+}
+
+// runExtractors captures values from resp. The regex source takes the first
+// capture group, or the whole match when there is none.
+func runExtractors(specs []string, resp response) []extractResult {
+	if len(specs) == 0 || resp.statusCode == 0 {
+		return nil
+	}
+	ev := &assertEnv{resp: resp}
+	results := make([]extractResult, 0, len(specs))
+	for _, spec := range specs {
+		res := extractResult{spec: spec}
+		x, err := parseExtractor(spec)
+		if err != nil {
+			res.err = err.Error()
+			results = append(results, res)
+			continue
+		}
+		res.scope, res.name = x.scope, x.name
+		res.value, err = ev.extract(x)
+		if err != nil {
+			res.err = err.Error()
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+func (e *assertEnv) extract(x extractor) (string, error) {
+	resp := e.resp
+	switch x.source {
+	case "json":
+		doc, err := e.json()
+		if err != nil {
+			return "", err
+		}
+		out, err := x.path.eval(doc)
+		if err != nil {
+			return "", err
+		}
+		if len(out) == 0 || out[0] == nil {
+			return "", errors.New("missing")
+		}
+		if s, ok := out[0].(string); ok {
+			return s, nil
+		}
+		return jqScalarText(out[0]), nil
+	case "header":
+		vals, ok := resp.header[http.CanonicalHeaderKey(x.arg)]
+		if !ok {
+			return "", errors.New("missing")
+		}
+		return strings.Join(vals, ", "), nil
+	case "regex":
+		m := x.re.FindSubmatch(resp.body)
+		if m == nil {
+			return "", errors.New("no match")
+		}
+		if len(m) > 1 {
+			return string(m[1]), nil
+		}
+		return string(m[0]), nil
+	case "cookie":
+		// Set-Cookie of the final response first, then cookies the jar
+		// picked up on the way, such as from a redirect.
+		for _, c := range (&http.Response{Header: resp.header}).Cookies() {
+			if c.Name == x.arg {
+				return c.Value, nil
+			}
+		}
+		for _, c := range resp.cookies {
+			if c.kind != cookieRemoved && c.entry.name == x.arg {
+				return c.entry.value, nil
+			}
+		}
+		return "", errors.New("not set by this response")
+	}
+	return "", errors.New("unknown source")
+}
+
+// extractedChanges splits the captured values by scope.
+func extractedChanges(results []extractResult) (env, runtime []envChange) {
+	for _, r := range results {
+		if r.err != "" {
+			continue
+		}
+		c := envChange{key: r.name, value: r.value}
+		if r.scope == scopeRuntime {
+			runtime = append(runtime, c)
+		} else {
+			env = append(env, c)
+		}
+	}
+	return env, runtime
+}
+
+// applyRuntimeChanges records variables captured into the runtime scope.
+func (m Model) applyRuntimeChanges(changes []envChange) Model {
+	if len(changes) > 0 {
+		m.runtimeVars = environment{vars: m.runtimeVars}.apply(changes).vars
+	}
+	return m
+}
+
+// execExtract handles ":extract [spec]": with a spec it adds an extractor to
+// the active request; alone it lists them.
+func (m Model) execExtract(spec string) Model {
+	if m.activeFolderIdx < 0 {
+		m.cmdError = "no request selected"
+		return m
+	}
+	r := &m.folders[m.activeFolderIdx].requests[m.activeReqIdx]
+	if spec == "" {
+		if len(r.extractors) == 0 {
+			m.cmdInfo = "no extractors"
+			return m
+		}
+		parts := make([]string, len(r.extractors))
+		for i, x := range r.extractors {
+			parts[i] = strconv.Itoa(i+1) + ": " + x
+		}
+		m.cmdInfo = strings.Join(parts, " · ")
+		return m
+	}
+	x, err := parseExtractor(spec)
+	if err != nil {
+		m.cmdError = err.Error()
+		return m
+	}
+	r.extractors = append(r.extractors, spec)
+	m.cmdInfo = fmt.Sprintf("added extractor %d: %s into %s {{%s}}", len(r.extractors), x.source, x.scope, x.name)
+	return m
+}
+
+// execUnextract handles ":unextract <n>".
+func (m Model) execUnextract(args []string) Model {
+	if m.activeFolderIdx < 0 {
+		m.cmdError = "no request selected"
+		return m
+	}
+	r := &m.folders[m.activeFolderIdx].requests[m.activeReqIdx]
+	n, err := 0, error(nil)
+	if len(args) == 1 {
+		n, err = strconv.Atoi(args[0])
+	}
+	if len(args) != 1 || err != nil || n < 1 || n > len(r.extractors) {
+		m.cmdError = fmt.Sprintf("usage: unextract <1-%d>", len(r.extractors))
+		return m
+	}
+	r.extractors = append(r.extractors[:n-1:n-1], r.extractors[n:]...)
+	m.cmdInfo = "removed extractor " + strconv.Itoa(n)
+	return m
+}
+
+// execVars handles ":vars clear", dropping the runtime variables.
+func (m Model) execVars(args []string) Model {
+	if len(args) != 1 || args[0] != "clear" {
+		m.cmdError = "usage: vars clear"
+		return m
+	}
+	n := len(m.runtimeVars)
+	m.runtimeVars = nil
+	m.cmdInfo = "cleared " + plural(n, "runtime variable")
+	return m
+}
+
+// renderVars lists what the last send extracted, then the variables the next
+// send resolves: runtime ones first, as they take precedence.
+func (m Model) renderVars(resp response, w int) string {
+	dim := m.theme.dim()
+	label := m.theme.highlight().Bold(true)
+	lines := []string{label.Render("  Extracted")}
+	if len(resp.extracted) == 0 {
+		lines = append(lines, dim.Render("  none — add one with ")+m.theme.keyHint(":extract token json .token"))
+	}
+	for _, x := range resp.extracted {
+		var line string
+		if x.err != "" {
+			line = m.theme.errStyle().Bold(true).Render("  ✗ ") + m.theme.text().Render(x.spec) +
+				m.theme.errStyle().Render("  "+x.err)
+		} else {
+			line = m.theme.successStyle().Render("  ✓ ") + m.theme.text().Render("{{"+x.name+"}}") +
+				dim.Render(" "+x.scope+" = ") + m.theme.textMuted().Render(printable(clip(x.value)))
+		}
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(w).Render(line))
+	}
+
+	lines = append(lines, "", label.Render("  Variables"))
+	env := m.envs[m.activeEnv]
+	shadowed := map[string]bool{}
+	var pairs [][2]string
+	for _, v := range sortedVars(m.runtimeVars) {
+		shadowed[v.key] = true
+		pairs = append(pairs, [2]string{v.key, v.value})
+	}
+	nRuntime := len(pairs)
+	for _, v := range sortedVars(env.vars) {
+		pairs = append(pairs, [2]string{v.key, v.value})
+	}
+	if len(pairs) == 0 {
+		return strings.Join(append(lines, dim.Render("  none")), "\n")
+	}
+	keyW := 0
+	for _, p := range pairs {
+		keyW = max(keyW, len([]rune(p[0])))
+	}
+	keyW = min(keyW, 30)
+	for i, p := range pairs {
+		scope := scopeRuntime
+		if i >= nRuntime {
+			scope = env.name
+		}
+		value := m.theme.textMuted().Render(printable(p[1]))
+		if i >= nRuntime && shadowed[p[0]] {
+			value = dim.Strikethrough(true).Render(printable(p[1])) + dim.Render("  overridden")
+		}
+		line := "  " + m.theme.text().Render(fmt.Sprintf("%-*s", keyW, clipRunes(p[0], keyW))) +
+			dim.Render(fmt.Sprintf("  %-11s ", clipRunes(scope, 11))) + value
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(w).Render(line))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func sortedVars(vars []variable) []variable {
+	out := append([]variable(nil), vars...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].key < out[j].key })
+	return out
+}
@@ -0,0 +1,731 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// introspectionQuery asks an endpoint for its types, their fields and
+// arguments, which is what completion and the schema browser need.
+const introspectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      kind name description
+      fields(includeDeprecated: true) {
+        name description isDeprecated
+        args { name description type { ...TypeRef } defaultValue }
+        type { ...TypeRef }
+      }
+      inputFields { name description type { ...TypeRef } defaultValue }
+      interfaces { name }
+      enumValues(includeDeprecated: true) { name description }
+      possibleTypes { name }
+    }
+  }
+}
+
+fragment TypeRef on __Type {
+  kind name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } } }
+}`
+
+// gqlSchema is the introspected schema of one endpoint.
+type gqlSchema struct {
+	url              string
+	queryType        string
+	mutationType     string
+	subscriptionType string
+	types            map[string]*gqlType
+	fetched          time.Time
+}
+
+type gqlType struct {
+	Kind          string       `json:"kind"`
+	Name          string       `json:"name"`
+	Description   string       `json:"description"`
+	Fields        []gqlField   `json:"fields"`
+	InputFields   []gqlInput   `json:"inputFields"`
+	Interfaces    []gqlTypeRef `json:"interfaces"`
+	EnumValues    []gqlField   `json:"enumValues"`
+	PossibleTypes []gqlTypeRef `json:"possibleTypes"`
+}
+
+type gqlField struct {
+	Name         string     `json:"name"`
+	Description  string     `json:"description"`
+	IsDeprecated bool       `json:"isDeprecated"`
+	Args         []gqlInput `json:"args"`
+	Type         gqlTypeRef `json:"type"`
+}
+
+type gqlInput struct {
+	Name         string     `json:"name"`
+	Description  string     `json:"description"`
+	Type         gqlTypeRef `json:"type"`
+	DefaultValue *string    `json:"defaultValue"`
+}
+
+type gqlTypeRef struct {
+	Kind   string      `json:"kind"`
+	Name   string      `json:"name"`
+	OfType *gqlTypeRef `json:"ofType"`
+}
+
+// String writes the reference as in SDL, e.g. [User!]!.
+func (t gqlTypeRef) String() string {
+	switch {
+	case t.OfType == nil:
+		return t.Name
+	case t.Kind == "NON_NULL":
+		return t.OfType.String() + "!"
+	case t.Kind == "LIST":
+		return "[" + t.OfType.String() + "]"
+	}
+	return t.Name
+}
+
+// named is the type under the list and non-null wrappers.
+func (t gqlTypeRef) named() string {
+	for t.OfType != nil {
+		t = *t.OfType
+	}
+	return t.Name
+}
+
+// parseIntrospection reads the response to introspectionQuery.
+func parseIntrospection(body []byte) (*gqlSchema, error) {
+	var doc struct {
+		Data *struct {
+			Schema *struct {
+				QueryType        *gqlTypeRef `json:"queryType"`
+				MutationType     *gqlTypeRef `json:"mutationType"`
+				SubscriptionType *gqlTypeRef `json:"subscriptionType"`
+				Types            []gqlType   `json:"types"`
+			} `json:"__schema"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("not a GraphQL response: %w", err)
+	}
+	if doc.Data == nil || doc.Data.Schema == nil {
+		if len(doc.Errors) > 0 {
+			return nil, errors.New(doc.Errors[0].Message)
+		}
+		return nil, errors.New("the response has no __schema")
+	}
+	sc := doc.Data.Schema
+	s := &gqlSchema{types: map[string]*gqlType{}, fetched: time.Now()}
+	for i := range sc.Types {
+		s.types[sc.Types[i].Name] = &sc.Types[i]
+	}
+	if sc.QueryType != nil {
+		s.queryType = sc.QueryType.Name
+	}
+	if sc.MutationType != nil {
+		s.mutationType = sc.MutationType.Name
+	}
+	if sc.SubscriptionType != nil {
+		s.subscriptionType = sc.SubscriptionType.Name
+	}
+	return s, nil
+}
+
+// typeNames lists the types for the browser: the root operation types
+// first, then the others by name. Introspection types are left out.
+func (s *gqlSchema) typeNames() []string {
+	var roots, rest []string
+	for _, n := range []string{s.queryType, s.mutationType, s.subscriptionType} {
+		if n != "" && s.types[n] != nil {
+			roots = append(roots, n)
+		}
+	}
+	for n := range s.types {
+		if !strings.HasPrefix(n, "__") && n != s.queryType && n != s.mutationType && n != s.subscriptionType {
+			rest = append(rest, n)
+		}
+	}
+	sort.Strings(rest)
+	return append(roots, rest...)
+}
+
+// fields lists what can be selected or set on type name: fields of objects
+// and interfaces, input fields of input objects.
+func (s *gqlSchema) fields(name string) []gqlField {
+	t := s.types[name]
+	if t == nil {
+		return nil
+	}
+	if t.Kind == "INPUT_OBJECT" {
+		out := make([]gqlField, len(t.InputFields))
+		for i, f := range t.InputFields {
+			out[i] = gqlField{Name: f.Name, Description: f.Description, Type: f.Type}
+		}
+		return out
+	}
+	return t.Fields
+}
+
+func (s *gqlSchema) field(typeName, name string) (gqlField, bool) {
+	for _, f := range s.fields(typeName) {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return gqlField{}, false
+}
+
+// schemaLoad is an introspection in flight.
+type schemaLoad struct {
+	url    string
+	cancel context.CancelFunc
+	cmd    tea.Cmd // performs the introspection
+}
+
+// schemaMsg is delivered to Update when an introspection finishes.
+type schemaMsg struct {
+	url    string
+	schema *gqlSchema
+	err    error
+}
+
+// gqlURL is the resolved URL of the active request, which schemas are
+// cached under.
+func (m Model) gqlURL() string {
+	if m.activeFolderIdx < 0 {
+		return ""
+	}
+	r := m.folders[m.activeFolderIdx].requests[m.activeReqIdx]
+	return strings.TrimSpace(resolveVars(r.url, m.env()))
+}
+
+// schema is the cached schema of the active request's endpoint, if any.
+func (m Model) schema() *gqlSchema {
+	return m.schemas[m.gqlURL()]
+}
+
+// introspect prepares fetching the schema of the active request's endpoint,
+// with its headers and auth but none of its scripts or checks. The caller
+// runs m.schemaLoad.cmd.
+func (m Model) introspect() Model {
+	f := m.folders[m.activeFolderIdx]
+	r := f.requests[m.activeReqIdx]
+	if m.schemaLoad != nil {
+		m.schemaLoad.cancel()
+	}
+	f.preScript, f.postScript = "", ""
+	r.preScript, r.postScript = "", ""
+	r.assertions, r.extractors = nil, nil
+	r.graphql = &graphQL{query: introspectionQuery}
+	env := m.env()
+	jar := m.jar()
+	ts := m.transportSettings()
+	opts, optsErr := resolveOptions(f, r)
+	ctx, cancel := context.WithCancel(context.Background())
+	load := &schemaLoad{url: m.gqlURL(), cancel: cancel}
+	load.cmd = func() tea.Msg {
+		defer cancel()
+		if optsErr != nil {
+			return schemaMsg{url: load.url, err: optsErr}
+		}
+		resp := execute(ctx, f, r, env, jar, ts, opts)
+		if resp.err != nil {
+			return schemaMsg{url: load.url, err: resp.err}
+		}
+		if resp.statusCode >= 400 {
+			return schemaMsg{url: load.url, err: errors.New("introspection failed: " + resp.status)}
+		}
+		s, err := parseIntrospection(resp.body)
+		if s != nil {
+			s.url = load.url
+		}
+		return schemaMsg{url: load.url, schema: s, err: err}
+	}
+	m.schemaLoad = load
+	m.notice = "introspecting " + load.url + "…"
+	return m
+}
+
+func (m Model) handleSchemaMsg(msg schemaMsg) Model {
+	if m.schemaLoad != nil && m.schemaLoad.url == msg.url {
+		m.schemaLoad = nil
+	}
+	if msg.err != nil {
+		m.notice = "schema: " + msg.err.Error()
+		m.showSchema = false
+		return m
+	}
+	m.schemas[msg.url] = msg.schema
+	m.notice = fmt.Sprintf("schema of %s: %d types", msg.url, len(msg.schema.typeNames()))
+	return m
+}
+
+// gqlCompletion is one suggestion while typing a query.
+type gqlCompletion struct {
+	name   string
+	detail string // the type, or the argument type
+	insert string // what replaces the word being typed
+}
+
+// complete suggests what may be typed at pos of query: fields of the
+// enclosing selection set, arguments inside a field's parentheses, or the
+// operation keywords at the top level. word is the identifier being typed.
+func (s *gqlSchema) complete(query string, pos int, word string) []gqlCompletion {
+	var (
+		stack    []string // type of each open selection set
+		pending  string   // type the next { selects on
+		field    *gqlField
+		argOf    *gqlField // field whose arguments are open
+		parens   int
+		braces   int // { } inside argument values
+		prev     string
+		expectOn bool // the next name is a type condition
+	)
+	lex := gqlLexer{src: query[:pos-len(word)]}
+	for tok, ok := lex.next(); ok; tok, ok = lex.next() {
+		switch {
+		case tok == "{" && parens > 0:
+			braces++
+		case tok == "}" && parens > 0:
+			braces--
+		case tok == "{":
+			if pending == "" && len(stack) == 0 {
+				pending = s.queryType
+			}
+			stack = append(stack, pending)
+			pending, field = "", nil
+		case tok == "}":
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			pending, field = "", nil
+		case tok == "(":
+			if parens == 0 {
+				argOf = field
+			}
+			parens++
+		case tok == ")":
+			parens--
+			if parens == 0 {
+				argOf = nil
+			}
+		case parens > 0 || !isGQLName(tok):
+		case expectOn:
+			pending, expectOn = tok, false
+		case tok == "on":
+			expectOn = true
+		case len(stack) == 0:
+			switch tok {
+			case "query":
+				pending = s.queryType
+			case "mutation":
+				pending = s.mutationType
+			case "subscription":
+				pending = s.subscriptionType
+			}
+		case prev == "..." || prev == "@":
+			// a fragment spread or a directive
+		default:
+			field = nil
+			pending = ""
+			if f, ok := s.field(stack[len(stack)-1], tok); ok {
+				field = &f
+				pending = f.Type.named()
+			}
+		}
+		prev = tok
+	}
+
+	var out []gqlCompletion
+	add := func(name, detail, insert string) {
+		if strings.HasPrefix(strings.ToLower(name), strings.ToLower(word)) && name != word {
+			out = append(out, gqlCompletion{name: name, detail: detail, insert: insert})
+		}
+	}
+	switch {
+	case prev == "$":
+		return nil
+	case parens > 0:
+		if argOf == nil || braces > 0 || prev == ":" {
+			return nil
+		}
+		for _, a := range argOf.Args {
+			add(a.Name, a.Type.String(), a.Name+": ")
+		}
+	case len(stack) == 0:
+		for _, kw := range []string{"query", "mutation", "subscription", "fragment"} {
+			add(kw, "keyword", kw+" ")
+		}
+	case expectOn:
+		for _, n := range s.typeNames() {
+			if k := s.types[n].Kind; k == "OBJECT" || k == "INTERFACE" || k == "UNION" {
+				add(n, strings.ToLower(k), n+" ")
+			}
+		}
+	case prev == "...":
+		add("on", "keyword", "on ")
+	default:
+		for _, f := range s.fields(stack[len(stack)-1]) {
+			add(f.Name, f.Type.String(), f.Name)
+		}
+		add("__typename", "String!", "__typename")
+	}
+	return out
+}
+
+// gqlLexer splits a GraphQL document into names, punctuators and values,
+// skipping whitespace, commas and comments.
+type gqlLexer struct {
+	src string
+	pos int
+}
+
+func (l *gqlLexer) next() (string, bool) {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.pos++
+		case c == '#':
+			if i := strings.IndexByte(l.src[l.pos:], '\n'); i >= 0 {
+				l.pos += i
+			} else {
+				l.pos = len(l.src)
+			}
+		case strings.HasPrefix(l.src[l.pos:], `"""`):
+			end := strings.Index(l.src[l.pos+3:], `"""`)
+			start := l.pos
+			if end < 0 {
+				l.pos = len(l.src)
+			} else {
+				l.pos += end + 6
+			}
+			return l.src[start:l.pos], true
+		case c == '"':
+			start := l.pos
+			for l.pos++; l.pos < len(l.src) && l.src[l.pos] != '"' && l.src[l.pos] != '\n'; l.pos++ {
+				if l.src[l.pos] == '\\' {
+					l.pos++
+				}
+			}
+			l.pos = min(l.pos+1, len(l.src))
+			return l.src[start:l.pos], true
+		case strings.HasPrefix(l.src[l.pos:], "..."):
+			l.pos += 3
+			return "...", true
+		case isGQLNameByte(c) || c == '-' || c == '$':
+			start := l.pos
+			for l.pos++; l.pos < len(l.src) && (isGQLNameByte(l.src[l.pos]) || l.src[l.pos] == '.'); l.pos++ {
+			}
+			return l.src[start:l.pos], true
+		default:
+			l.pos++
+			return string(c), true
+		}
+	}
+	return "", false
+}
+
+func isGQLNameByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// isGQLName reports whether tok is a name rather than a number or variable.
+func isGQLName(tok string) bool {
+	return tok != "" && (tok[0] == '_' || tok[0] >= 'a' && tok[0] <= 'z' || tok[0] >= 'A' && tok[0] <= 'Z')
+}
+
+// sbItem is one row of the schema browser: a type, or one of its fields
+// when field is set.
+type sbItem struct {
+	typeName string
+	field    string
+}
+
+// sbFlatItems lists the rows of the schema browser: the types, with the
+// fields of the expanded ones, or everything matching the filter.
+func (m Model) sbFlatItems() []sbItem {
+	s := m.schema()
+	if s == nil {
+		return nil
+	}
+	q := strings.ToLower(m.sbQuery)
+	var items []sbItem
+	for _, n := range s.typeNames() {
+		if q == "" {
+			items = append(items, sbItem{typeName: n})
+			if !m.sbExpanded[n] {
+				continue
+			}
+		} else if strings.Contains(strings.ToLower(n), q) {
+			items = append(items, sbItem{typeName: n})
+		}
+		for _, f := range s.fields(n) {
+			if q == "" || strings.Contains(strings.ToLower(f.Name), q) {
+				items = append(items, sbItem{typeName: n, field: f.Name})
+			}
+		}
+	}
+	return items
+}
+
+func (m Model) updateSchemaBrowser(msg tea.KeyMsg) (Model, tea.Cmd) {
+	if m.sbInsert {
+		switch msg.String() {
+		case "esc", "enter":
+			m.sbInsert = false
+		case "backspace":
+			if runes := []rune(m.sbQuery); len(runes) > 0 {
+				m.sbQuery = string(runes[:len(runes)-1])
+				m.sbCursor = 0
+			}
+		default:
+			if len([]rune(msg.String())) == 1 {
+				m.sbQuery += msg.String()
+				m.sbCursor = 0
+			}
+		}
+		return m, nil
+	}
+
+	items := m.sbFlatItems()
+	switch msg.String() {
+	case "esc":
+		if m.sbQuery != "" {
+			m.sbQuery = ""
+			m.sbCursor = 0
+		} else {
+			m.showSchema = false
+		}
+	case "i":
+		m.sbInsert = true
+	case "j", "down", "ctrl+j":
+		if m.sbCursor < len(items)-1 {
+			m.sbCursor++
+		}
+	case "k", "up", "ctrl+k":
+		if m.sbCursor > 0 {
+			m.sbCursor--
+		}
+	case "/":
+		if len(m.sbExpanded) > 0 {
+			m.sbExpanded = map[string]bool{}
+		} else if s := m.schema(); s != nil {
+			for _, n := range s.typeNames() {
+				m.sbExpanded[n] = true
+			}
+		}
+	case "R":
+		m = m.introspect()
+		return m, m.schemaLoad.cmd
+	case "enter":
+		if m.sbCursor >= len(items) {
+			break
+		}
+		it := items[m.sbCursor]
+		target := it.typeName
+		if it.field == "" && m.sbQuery == "" {
+			if m.sbExpanded[target] {
+				delete(m.sbExpanded, target)
+			} else {
+				m.sbExpanded[target] = true
+			}
+			break
+		}
+		if it.field != "" {
+			f, _ := m.schema().field(it.typeName, it.field)
+			target = f.Type.named()
+		}
+		// Jump to the type, expanded.
+		m.sbQuery = ""
+		m.sbExpanded[target] = true
+		for i, row := range m.sbFlatItems() {
+			if row.typeName == target && row.field == "" {
+				m.sbCursor = i
+			}
+		}
+	}
+	return m, nil
+}
+
+// renderSchemaBrowser renders the schema of the active request's endpoint
+// as a floating two-pane overlay in the style of the folder picker.
+func (m Model) renderSchemaBrowser() string {
+	outerW := max(60, m.width-6)
+	innerW := outerW - 4
+	contentW := innerW - 2 // Width includes the horizontal padding
+	pickerH := max(12, m.height*6/10)
+	contentH := pickerH - 5
+	listW := contentW * 2 / 5
+	previewW := contentW - listW - 1
+
+	dim := m.theme.dim()
+	yellow := m.theme.highlight().Bold(true)
+	orange := m.theme.accent()
+	kh := func(key, label string) string {
+		return "  " + m.theme.keyHint(key) + dim.Render(label)
+	}
+
+	s := m.schema()
+	items := m.sbFlatItems()
+	enterHint := "expand"
+	if m.sbCursor < len(items) && (items[m.sbCursor].field != "" || m.sbQuery != "") {
+		enterHint = "go to type"
+	}
+	headerText := yellow.Render(" Schema") + dim.Render(" · "+m.gqlURL()) +
+		kh("i", "filter") + kh("enter", enterHint) + kh("/", "expand all") + kh("R", "refresh") + kh("esc", "close")
+
+	var queryLine string
+	switch {
+	case m.sbInsert:
+		queryLine = dim.Render(" -- FILTER --  > ") + m.theme.text().Render(m.sbQuery) + orange.Render("█") +
+			"  " + m.theme.keyHint("esc") + dim.Render("normal")
+	case m.sbQuery != "":
+		queryLine = dim.Render(" -- FILTER --  > ") + m.theme.textMuted().Render(m.sbQuery) +
+			"  " + m.theme.keyHint("esc") + dim.Render("clear")
+	case s != nil:
+		queryLine = dim.Render(fmt.Sprintf(" %d types · introspected %s", len(s.typeNames()), s.fetched.Format("15:04:05")))
+	default:
+		queryLine = dim.Render(" -- NORMAL --")
+	}
+	hdiv := dim.Render(strings.Repeat("─", contentW))
+
+	var itemLines []string
+	for i, it := range items {
+		selected := i == m.sbCursor
+		prefix := dim.Render("  ")
+		if selected {
+			prefix = orange.Bold(true).Render("> ")
+		}
+		var text string
+		if it.field == "" {
+			chevron := dim.Render("▸ ")
+			switch {
+			case len(s.fields(it.typeName)) == 0:
+				chevron = "  "
+			case m.sbExpanded[it.typeName] && m.sbQuery == "":
+				chevron = orange.Render("▾ ")
+			}
+			text = chevron + lipgloss.NewStyle().Bold(selected).Render(it.typeName) + " " + dim.Render(strings.ToLower(s.types[it.typeName].Kind))
+		} else {
+			f, _ := s.field(it.typeName, it.field)
+			name := it.field
+			if m.sbQuery != "" {
+				name = it.typeName + "." + it.field
+			}
+			text = "  " + lipgloss.NewStyle().Bold(selected).Render(name) + dim.Render(": "+f.Type.String())
+		}
+		itemLines = append(itemLines, lipgloss.NewStyle().MaxWidth(listW).Render(prefix+text))
+	}
+	switch {
+	case s == nil && m.schemaLoad != nil:
+		itemLines = append(itemLines, dim.Render("  introspecting…"))
+	case len(items) == 0:
+		itemLines = append(itemLines, dim.Render("  no results"))
+	}
+	if len(itemLines) > contentH {
+		start := min(max(0, m.sbCursor-contentH+1), len(itemLines)-contentH)
+		itemLines = itemLines[start : start+contentH]
+	}
+	listPane := lipgloss.NewStyle().Width(listW).Height(contentH).Render(strings.Join(itemLines, "\n"))
+
+	previewContent := dim.Render("  nothing selected")
+	if m.sbCursor < len(items) {
+		previewContent = m.renderSchemaPreview(s, items[m.sbCursor], previewW)
+	}
+	previewPane := lipgloss.NewStyle().Width(previewW).Height(contentH).
+		Render(scrollLines(previewContent, 0, contentH))
+
+	vdiv := dim.Render(strings.Repeat("│\n", contentH-1) + "│")
+	contentArea := lipgloss.JoinHorizontal(lipgloss.Top, listPane, vdiv, previewPane)
+	content := strings.Join([]string{headerText, queryLine, hdiv, contentArea}, "\n")
+	return m.theme.overlayStyle().
+		Padding(0, 1).
+		Width(innerW).
+		Render(content)
+}
+
+// renderSchemaPreview describes the selected type or field.
+func (m Model) renderSchemaPreview(s *gqlSchema, it sbItem, width int) string {
+	label := m.theme.highlight().Bold(true)
+	dim := m.theme.dim()
+	val := m.theme.textMuted()
+	wrap := lipgloss.NewStyle().Width(width - 2)
+
+	var lines []string
+	input := func(a gqlInput) string {
+		l := "  " + val.Render(a.Name) + dim.Render(": "+a.Type.String())
+		if a.DefaultValue != nil {
+			l += dim.Render(" = " + *a.DefaultValue)
+		}
+		return l
+	}
+	if it.field != "" {
+		f, _ := s.field(it.typeName, it.field)
+		lines = append(lines, label.Render(it.typeName+"."+f.Name), dim.Render(f.Type.String()))
+		if f.IsDeprecated {
+			lines = append(lines, m.theme.errStyle().Render("deprecated"))
+		}
+		lines = append(lines, dim.Render(strings.Repeat("─", width-2)))
+		if f.Description != "" {
+			lines = append(lines, wrap.Render(val.Render(f.Description)), "")
+		}
+		if len(f.Args) > 0 {
+			lines = append(lines, label.Render("Arguments"))
+			for _, a := range f.Args {
+				lines = append(lines, input(a))
+			}
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	t := s.types[it.typeName]
+	lines = append(lines, label.Render(t.Name), dim.Render(strings.ToLower(t.Kind)))
+	lines = append(lines, dim.Render(strings.Repeat("─", width-2)))
+	if t.Description != "" {
+		lines = append(lines, wrap.Render(val.Render(t.Description)), "")
+	}
+	switch t.Kind {
+	case "ENUM":
+		for _, v := range t.EnumValues {
+			lines = append(lines, "  "+val.Render(v.Name))
+		}
+	case "UNION":
+		for _, p := range t.PossibleTypes {
+			lines = append(lines, "  "+val.Render(p.Name))
+		}
+	case "INPUT_OBJECT":
+		for _, f := range t.InputFields {
+			lines = append(lines, input(f))
+		}
+	default:
+		for _, f := range t.Fields {
+			var args []string
+			for _, a := range f.Args {
+				args = append(args, a.Name+": "+a.Type.String())
+			}
+			sig := f.Name
+			if len(args) > 0 {
+				sig += "(" + strings.Join(args, ", ") + ")"
+			}
+			lines = append(lines, lipgloss.NewStyle().MaxWidth(width-1).Render("  "+val.Render(sig)+dim.Render(": "+f.Type.String())))
+		}
+	}
+	if t.Kind == "SCALAR" {
+		lines = append(lines, dim.Render("  (scalar)"))
+	}
+	return strings.Join(lines, "\n")
+}
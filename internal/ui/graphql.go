@@ -0,0 +1,460 @@
+package ui
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// graphQL is the body of a request in GraphQL mode. It is sent as a POST
+// of the JSON envelope {"query", "variables", "operationName"}.
+type graphQL struct {
+	query     string
+	variables string // a JSON object; may hold {{templates}}
+	operation string // operationName; empty lets a single operation run
+}
+
+// Editors of the GraphQL Body tab.
+const (
+	gqlQueryPane = iota
+	gqlVarsPane
+)
+
+// maxCompletions bounds the suggestions shown under the cursor.
+const maxCompletions = 6
+
+// envelopeJSON writes the POST body of a GraphQL request. The variables are
+// copied as written, so they keep their {{templates}} until the send.
+func envelopeJSON(query, variables, operation string) string {
+	quote := func(s string) string {
+		b, _ := json.Marshal(s)
+		return string(b)
+	}
+	parts := []string{`"query": ` + quote(query)}
+	if v := strings.TrimSpace(variables); v != "" {
+		parts = append(parts, `"variables": `+strings.ReplaceAll(v, "\n", "\n  "))
+	}
+	if operation != "" {
+		parts = append(parts, `"operationName": `+quote(operation))
+	}
+	return "{\n  " + strings.Join(parts, ",\n  ") + "\n}"
+}
+
+// envelope resolves templates in g and builds the body of the send.
+func (g graphQL) envelope(rv *resolver) (string, error) {
+	vars := strings.TrimSpace(rv.resolve(g.variables))
+	if vars != "" && !json.Valid([]byte(vars)) {
+		return "", errors.New("the GraphQL variables are not valid JSON")
+	}
+	if g.operation == "" {
+		if ops := gqlOperations(g.query); len(ops) > 1 {
+			return "", fmt.Errorf("the query has %d operations; pick one with :operation", len(ops))
+		}
+	}
+	return envelopeJSON(rv.resolve(g.query), vars, g.operation), nil
+}
+
+// gqlOperations lists the named operations of a query, in order.
+func gqlOperations(query string) []string {
+	var ops []string
+	lex := gqlLexer{src: query}
+	depth, prev := 0, ""
+	for tok, ok := lex.next(); ok; tok, ok = lex.next() {
+		switch tok {
+		case "{", "(":
+			depth++
+		case "}", ")":
+			depth--
+		default:
+			if depth == 0 && isGQLName(tok) && (prev == "query" || prev == "mutation" || prev == "subscription") {
+				ops = append(ops, tok)
+			}
+		}
+		prev = tok
+	}
+	return ops
+}
+
+// parseEnvelope reads a body that already is a GraphQL envelope, as when a
+// request switches to GraphQL mode.
+func parseEnvelope(body string) (graphQL, bool) {
+	var env struct {
+		Query         *string         `json:"query"`
+		Variables     json.RawMessage `json:"variables"`
+		OperationName string          `json:"operationName"`
+	}
+	if json.Unmarshal([]byte(body), &env) != nil || env.Query == nil {
+		return graphQL{}, false
+	}
+	g := graphQL{query: *env.Query, operation: env.OperationName}
+	if len(env.Variables) > 0 && string(env.Variables) != "null" {
+		g.variables = string(env.Variables)
+		if v, err := reindentJSON(env.Variables); err == nil {
+			g.variables = v
+		}
+	}
+	return g, true
+}
+
+func reindentJSON(data []byte) (string, error) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return "", err
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	return string(b), err
+}
+
+// activeGraphQL is the GraphQL body of the active request, or nil.
+func (m Model) activeGraphQL() *graphQL {
+	if m.activeFolderIdx < 0 {
+		return nil
+	}
+	return m.folders[m.activeFolderIdx].requests[m.activeReqIdx].graphql
+}
+
+// setGraphQL replaces the GraphQL body of the active request.
+func (m Model) setGraphQL(g graphQL) Model {
+	r := &m.folders[m.activeFolderIdx].requests[m.activeReqIdx]
+	r.graphql = &g
+	r.searchable = r.searchText()
+	return m
+}
+
+// execGraphQL handles ":graphql [on|off]", switching the body of the
+// request between raw text and the GraphQL editors.
+func (m Model) execGraphQL(args []string) Model {
+	if m.activeFolderIdx < 0 {
+		m.cmdError = "no request selected"
+		return m
+	}
+	r := &m.folders[m.activeFolderIdx].requests[m.activeReqIdx]
+	on := r.graphql == nil
+	switch {
+	case len(args) == 1 && (args[0] == "on" || args[0] == "off"):
+		on = args[0] == "on"
+	case len(args) != 0:
+		m.cmdError = "usage: graphql [on|off]"
+		return m
+	}
+	switch {
+	case on && r.graphql != nil, !on && r.graphql == nil:
+	case on:
+		g, ok := parseEnvelope(r.body)
+		if !ok {
+			g = graphQL{query: r.body}
+		}
+		r.graphql = &g
+		r.body = ""
+		r.method = "POST"
+		m.methodInput = r.method
+	default:
+		r.body = envelopeJSON(r.graphql.query, r.graphql.variables, r.graphql.operation)
+		r.graphql = nil
+	}
+	r.searchable = r.searchText()
+	m.requestTab = 3
+	m.notice = "GraphQL body off · the envelope is the raw body"
+	if on {
+		m.notice = "GraphQL body · i edits the query, v the variables, g browses the schema"
+	}
+	return m.closeCmdPalette()
+}
+
+// execOperation handles ":operation [name|auto]", choosing the operation of
+// the query to run; alone, it lists them.
+func (m Model) execOperation(args []string) Model {
+	g := m.activeGraphQL()
+	if g == nil {
+		m.cmdError = "not a GraphQL request (:graphql on)"
+		return m
+	}
+	ops := gqlOperations(g.query)
+	switch {
+	case len(args) == 0:
+		if len(ops) == 0 {
+			m.cmdInfo = "the query has no named operations"
+			return m
+		}
+		m.cmdInfo = "operations: " + strings.Join(ops, " ") + " · sending " + cmp.Or(g.operation, "auto")
+		return m
+	case len(args) > 1:
+		m.cmdError = "usage: operation [name|auto]"
+		return m
+	case args[0] == "auto":
+		g2 := *g
+		g2.operation = ""
+		m = m.setGraphQL(g2)
+	case !slices.Contains(ops, args[0]):
+		m.cmdError = "no operation " + args[0] + " in the query"
+		return m
+	default:
+		g2 := *g
+		g2.operation = args[0]
+		m = m.setGraphQL(g2)
+	}
+	return m.closeCmdPalette()
+}
+
+// cycleOperation moves to the next operation of the query, then back to
+// auto after the last.
+func (m Model) cycleOperation() Model {
+	g := *m.activeGraphQL()
+	ops := gqlOperations(g.query)
+	if len(ops) == 0 {
+		m.notice = "the query has no named operations"
+		return m
+	}
+	i := slices.Index(ops, g.operation)
+	switch {
+	case i == len(ops)-1:
+		g.operation = ""
+	default:
+		g.operation = ops[i+1]
+	}
+	m.notice = "operation: " + cmp.Or(g.operation, "auto")
+	return m.setGraphQL(g)
+}
+
+// execSchema handles ":schema [refresh]", opening the schema browser and
+// introspecting the endpoint unless its schema is cached.
+func (m Model) execSchema(args []string) Model {
+	switch {
+	case m.activeFolderIdx < 0:
+		m.cmdError = "no request selected"
+		return m
+	case len(args) > 1 || len(args) == 1 && args[0] != "refresh":
+		m.cmdError = "usage: schema [refresh]"
+		return m
+	}
+	m = m.closeCmdPalette()
+	return m.openSchema(len(args) == 1)
+}
+
+// openSchema opens the schema browser, introspecting when the schema is
+// not cached or refresh is set. The caller runs m.schemaLoad.cmd.
+func (m Model) openSchema(refresh bool) Model {
+	m.showSchema = true
+	m.sbCursor = 0
+	m.sbQuery = ""
+	m.sbInsert = false
+	if refresh || m.schema() == nil {
+		m = m.introspect()
+	}
+	return m
+}
+
+// updateGraphQLKeys handles the keys of the Body tab in GraphQL mode.
+func (m Model) updateGraphQLKeys(msg tea.KeyMsg) (Model, tea.Cmd, bool) {
+	g := m.activeGraphQL()
+	switch msg.String() {
+	case "i", "v":
+		m.gqlPane = gqlQueryPane
+		text := g.query
+		if msg.String() == "v" {
+			m.gqlPane = gqlVarsPane
+			text = g.variables
+		}
+		m.gqlEditing = true
+		m.gqlEdit = newTextArea(text)
+		m.gqlComplete = 0
+		m.gqlForce = false
+	case "o":
+		m = m.cycleOperation()
+	case "g":
+		prev := m.schemaLoad
+		m = m.openSchema(false)
+		if m.schemaLoad != prev {
+			return m, m.schemaLoad.cmd, true
+		}
+	default:
+		return m, nil, false
+	}
+	return m, nil, true
+}
+
+// updateGraphQLEditor handles typing into the Query or Variables editor.
+// Every edit is written back to the request.
+func (m Model) updateGraphQLEditor(msg tea.KeyMsg) (Model, tea.Cmd) {
+	key := msg.String()
+	force := false
+	t := m.gqlEdit
+	switch {
+	case key == "esc":
+		m.gqlEditing = false
+		return m, nil
+	case msg.Paste:
+		t = t.insert(string(msg.Runes))
+	case key == "tab":
+		if items := m.gqlCompletions(); len(items) > 0 {
+			c := items[min(m.gqlComplete, len(items)-1)]
+			t = t.insert(strings.TrimPrefix(c.insert, t.word()))
+		} else {
+			t = t.insert("  ")
+		}
+	case key == "ctrl+n" || key == "ctrl+p":
+		if n := len(m.gqlCompletions()); n > 0 {
+			step := 1
+			if key == "ctrl+p" {
+				step = n - 1
+			}
+			m.gqlComplete = (m.gqlComplete + step) % n
+		}
+		return m, nil
+	case key == "ctrl+@" || key == "ctrl+ ":
+		force = true
+	default:
+		t, _ = t.edit(key)
+	}
+	if t.text != m.gqlEdit.text {
+		m.gqlComplete = 0
+	}
+	m.gqlEdit = t
+	m.gqlForce = force
+	g := *m.activeGraphQL()
+	if m.gqlPane == gqlQueryPane {
+		g.query = t.text
+	} else {
+		g.variables = t.text
+	}
+	return m.setGraphQL(g), nil
+}
+
+// gqlCompletions suggests what fits at the cursor of the Query editor, from
+// the cached schema, once a word is being typed or ctrl+space asked.
+func (m Model) gqlCompletions() []gqlCompletion {
+	s := m.schema()
+	if !m.gqlEditing || m.gqlPane != gqlQueryPane || s == nil {
+		return nil
+	}
+	t := m.gqlEdit
+	word := t.word()
+	if word == "" && !m.gqlForce {
+		return nil
+	}
+	return s.complete(t.text, t.pos, word)
+}
+
+// renderGraphQLBody renders the Body tab of a GraphQL request: the Query
+// editor above the Variables editor.
+func (m Model) renderGraphQLBody(g graphQL, w, h int) string {
+	dim := m.theme.dim()
+	label := m.theme.highlight().Bold(true)
+
+	status := dim.Render("no schema ") + m.theme.keyHint("g")
+	switch s := m.schema(); {
+	case m.schemaLoad != nil && m.schemaLoad.url == m.gqlURL():
+		status = dim.Render("introspecting…")
+	case s != nil:
+		status = dim.Render(fmt.Sprintf("schema %d types ", len(s.typeNames()))) + m.theme.keyHint("g")
+	}
+	op := dim.Render("operation ") + m.theme.textMuted().Render(cmp.Or(g.operation, "auto"))
+	if len(gqlOperations(g.query)) > 1 {
+		op += " " + m.theme.keyHint("o")
+	}
+	editHint := func(key string, pane int) string {
+		if m.gqlEditing && m.gqlPane == pane {
+			return m.theme.keyHint("esc") + dim.Render("done")
+		}
+		return m.theme.keyHint(key) + dim.Render("edit")
+	}
+	header := func(title, key string, pane int, right string) string {
+		left := label.Render("  "+title) + "  " + editHint(key, pane)
+		gap := max(1, w-lipgloss.Width(left)-lipgloss.Width(right)-1)
+		return left + strings.Repeat(" ", gap) + right
+	}
+
+	queryH := max(1, (h-2)*2/3)
+	varsH := max(1, h-2-queryH)
+	query := m.renderEditor(g.query, m.gqlEditor(gqlQueryPane), w, queryH)
+	if items := m.gqlCompletions(); len(items) > 0 {
+		line, col := m.gqlEdit.cursor()
+		row := line - max(0, line-queryH+1)
+		popup := m.renderCompletions(items)
+		y := row + 1
+		if popupH := lipgloss.Height(popup); y+popupH > queryH {
+			y = max(0, row-popupH)
+		}
+		query = placeOverlayAt(query, popup, min(2+col, max(0, w-lipgloss.Width(popup))), y)
+	}
+	vars := m.renderEditor(g.variables, m.gqlEditor(gqlVarsPane), w, varsH)
+	return strings.Join([]string{
+		header("Query", "i", gqlQueryPane, op+"  "+status),
+		query,
+		header("Variables", "v", gqlVarsPane, ""),
+		vars,
+	}, "\n")
+}
+
+// gqlEditor is the text area of pane while it is being edited, or nil.
+func (m Model) gqlEditor(pane int) *textArea {
+	if m.gqlEditing && m.gqlPane == pane {
+		return &m.gqlEdit
+	}
+	return nil
+}
+
+// renderEditor renders h lines of text, scrolled to the cursor of ed while
+// it is being edited.
+func (m Model) renderEditor(text string, ed *textArea, w, h int) string {
+	style := m.theme.textMuted()
+	if ed != nil {
+		style = m.theme.text()
+	}
+	if text == "" && ed == nil {
+		return lipgloss.NewStyle().Height(h).Render(m.theme.dim().Render("  (empty)"))
+	}
+	if ed == nil {
+		// The editor keeps its own text, since hiding values would move the
+		// cursor.
+		text = m.presentBody(text)
+	}
+	lines := strings.Split(text, "\n")
+	cursorLine, cursorCol, offset := -1, 0, 0
+	if ed != nil {
+		cursorLine, cursorCol = ed.cursor()
+		offset = max(0, cursorLine-h+1)
+	}
+	var out []string
+	for i := offset; i < len(lines) && len(out) < h; i++ {
+		l := style.Render(lines[i])
+		if i == cursorLine {
+			runes := []rune(lines[i])
+			under, rest := " ", ""
+			if cursorCol < len(runes) {
+				under, rest = string(runes[cursorCol]), string(runes[cursorCol+1:])
+			}
+			l = style.Render(string(runes[:cursorCol])) +
+				m.theme.accent().Reverse(true).Render(under) + style.Render(rest)
+		}
+		out = append(out, lipgloss.NewStyle().MaxWidth(w).Render("  "+l))
+	}
+	return lipgloss.NewStyle().Height(h).Render(strings.Join(out, "\n"))
+}
+
+// renderCompletions renders the suggestions box shown under the cursor.
+func (m Model) renderCompletions(items []gqlCompletion) string {
+	sel := min(m.gqlComplete, len(items)-1)
+	start := max(0, sel-maxCompletions+1)
+	items = items[start:min(len(items), start+maxCompletions)]
+	nameW := 0
+	for _, c := range items {
+		nameW = max(nameW, len(c.name))
+	}
+	var lines []string
+	for i, c := range items {
+		name := fmt.Sprintf("%-*s", nameW, c.name)
+		line := m.theme.text().Render(name) + "  " + m.theme.dim().Render(c.detail)
+		if start+i == sel {
+			line = m.theme.accent().Bold(true).Render(name) + "  " + m.theme.textMuted().Render(c.detail)
+		}
+		lines = append(lines, line)
+	}
+	return m.theme.overlayStyle().Padding(0, 1).Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,362 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Exit codes of the headless commands.
+const (
+	exitOK     = 0 // every request got a response and every assertion held
+	exitFailed = 1 // a send failed or an assertion did not hold
+	exitUsage  = 2 // bad arguments, or no such request, folder or environment
+)
+
+const headlessUsage = `usage:
+  tuiman                                 start the TUI
+  tuiman send <folder/request> [flags]   send one request and print the response
+  tuiman run <folder> [flags]            send every request of a folder
+
+flags:
+  --env <name>      environment to resolve {{variables}} from
+  --import <file>   add a Postman or OpenAPI collection first (repeatable)
+  -i, --include     send: print the status line and headers before the body
+  -c <n>            run: send n requests at a time (default 1)
+  --bail            run: stop at the first failure
+  --junit <file>    run: write a JUnit XML report (- for stdout)
+  --json <file>     run: write a JSON report (- for stdout)
+
+Answers to {{$prompt}} templates are read from stdin, one per line, and
+$TUIMAN_VAULT_PASSPHRASE unlocks the vault for {{$secret}} templates; a
+new vault is only created when $TUIMAN_VAULT_PASSPHRASE_CONFIRM repeats it.
+
+Exit status is 0 when every assertion holds, 1 when a send or an assertion
+fails, and 2 for usage errors.
+`
+
+// RunHeadless runs a command line such as `send "GitHub/Get User" --env
+// staging` or `run GitHub` against the same collections as the TUI, without
+// a terminal UI, and returns the process exit code. Responses and results go
+// to stdout; assertion results of a single send and errors go to stderr.
+func RunHeadless(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(stderr, headlessUsage)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+	cmd := args[0]
+	if cmd != "send" && cmd != "run" {
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", cmd, headlessUsage)
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var imports stringList
+	env := fs.String("env", "", "")
+	fs.Var(&imports, "import", "")
+	include := fs.Bool("include", false, "")
+	fs.BoolVar(include, "i", false, "")
+	concurrency := fs.Int("c", 1, "")
+	bail := fs.Bool("bail", false, "")
+	junit := fs.String("junit", "", "")
+	jsonOut := fs.String("json", "", "")
+	pos, err := parseInterspersed(fs, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprint(stderr, headlessUsage)
+		return exitOK
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n\n%s", cmd, err, headlessUsage)
+		return exitUsage
+	}
+	if len(pos) != 1 {
+		fmt.Fprintf(stderr, "%s: expected one %s\n\n%s", cmd, map[string]string{
+			"send": "folder/request", "run": "folder"}[cmd], headlessUsage)
+		return exitUsage
+	}
+	// Flags of the other command are refused rather than ignored.
+	only := map[string]string{"i": "send", "include": "send", "c": "run", "bail": "run", "junit": "run", "json": "run"}
+	var misplaced []string
+	fs.Visit(func(f *flag.Flag) {
+		if c, ok := only[f.Name]; ok && c != cmd {
+			dash := "--"
+			if len(f.Name) == 1 {
+				dash = "-"
+			}
+			misplaced = append(misplaced, dash+f.Name)
+		}
+	})
+	if len(misplaced) > 0 {
+		other := map[string]string{"send": "run", "run": "send"}[cmd]
+		fmt.Fprintf(stderr, "%s: %s only applies to %s\n\n%s", cmd, strings.Join(misplaced, ", "), other, headlessUsage)
+		return exitUsage
+	}
+	if *concurrency < 1 {
+		fmt.Fprintf(stderr, "run: -c must be at least 1\n")
+		return exitUsage
+	}
+
+	m := New()
+	for _, file := range imports {
+		data, err := os.ReadFile(file)
+		if err == nil {
+			var folders []folder
+			if folders, err = importCollection(data); err == nil {
+				m.folders = append(m.folders, folders...)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "import %s: %v\n", file, err)
+			return exitUsage
+		}
+	}
+	if *env != "" {
+		i := slices.IndexFunc(m.envs, func(e environment) bool { return e.name == *env })
+		if i < 0 {
+			fmt.Fprintf(stderr, "unknown environment %q\n", *env)
+			return exitUsage
+		}
+		m.activeEnv = i
+	}
+
+	if pass, ok := os.LookupEnv(vaultPassphraseEnv); ok {
+		err := m.vault.unlock(pass)
+		// Only a repeated passphrase starts a new vault, so that a typo in
+		// either does not go unnoticed.
+		if confirm, ok := os.LookupEnv(vaultConfirmEnv); ok && !m.vault.exists() {
+			err = m.vault.create(pass, confirm)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "vault: %v\n", err)
+			return exitUsage
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if cmd == "send" {
+		fi, ri := m.requestIndex(pos[0])
+		if fi < 0 {
+			fmt.Fprintf(stderr, "no request %q (use folder/request)\n", pos[0])
+			return exitUsage
+		}
+		if m.promptAnswers, err = readPrompts(requestPrompts(m.folders[fi].requests[ri]), os.Stdin, stderr); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return exitUsage
+		}
+		return m.headlessSend(ctx, fi, ri, *include, stdout, stderr)
+	}
+	fi := m.folderIndex(pos[0])
+	if fi < 0 {
+		fmt.Fprintf(stderr, "no folder %q\n", pos[0])
+		return exitUsage
+	}
+	if m.promptAnswers, err = readPrompts(m.folderPrompts(fi), os.Stdin, stderr); err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exitUsage
+	}
+	reports := map[string]string{}
+	if *junit != "" {
+		reports[reportJUnit] = *junit
+	}
+	if *jsonOut != "" {
+		reports[reportJSON] = *jsonOut
+	}
+	if *junit == "-" && *jsonOut == "-" {
+		fmt.Fprintf(stderr, "run: only one report can go to stdout\n")
+		return exitUsage
+	}
+	return m.headlessRun(ctx, fi, *concurrency, *bail, reports, stdout, stderr)
+}
+
+// requestIndex finds a request by "folder/request", ignoring case. Folder
+// names may themselves contain slashes.
+func (m Model) requestIndex(path string) (int, int) {
+	for fi, f := range m.folders {
+		for ri, r := range f.requests {
+			if strings.EqualFold(f.name+"/"+r.name, path) {
+				return fi, ri
+			}
+		}
+	}
+	return -1, -1
+}
+
+// headlessSend sends one request, printing the body (after the status line
+// and headers with include) to stdout and the assertion results to stderr.
+func (m Model) headlessSend(ctx context.Context, fi, ri int, include bool, stdout, stderr io.Writer) int {
+	f := m.folders[fi]
+	r := f.requests[ri]
+	opts, err := resolveOptions(f, r)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return exitUsage
+	}
+	resp := execute(ctx, f, r, m.env(), m.jar(), m.transportSettingsFor(f, r), opts)
+	for _, l := range resp.console {
+		fmt.Fprintf(stderr, "console.%s: %s\n", l.level, m.maskValues(l.text))
+	}
+	if resp.err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", resp.err)
+		return exitFailed
+	}
+	if include {
+		fmt.Fprintf(stdout, "%s %s\n", resp.proto, resp.status)
+		keys := make([]string, 0, len(resp.header))
+		for k := range resp.header {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			for _, v := range resp.header[k] {
+				fmt.Fprintf(stdout, "%s: %s\n", k, v)
+			}
+		}
+		fmt.Fprintln(stdout)
+	}
+	stdout.Write(resp.body)
+
+	for _, t := range resp.tests {
+		mark := "✓"
+		if !t.pass {
+			mark = "✗"
+		}
+		fmt.Fprintf(stderr, "%s %s  %s\n", mark, t.spec, t.detail)
+	}
+	if !runPassedResp(resp) {
+		fmt.Fprintf(stderr, "%d/%d assertions passed\n", testsPassed(resp.tests), len(resp.tests))
+		return exitFailed
+	}
+	return exitOK
+}
+
+// headlessRun runs folder fi, printing a line per request as it finishes and
+// a summary at the end, then writes the reports, keyed by format. A report
+// written to stdout moves the progress lines to stderr.
+func (m Model) headlessRun(ctx context.Context, fi, concurrency int, bail bool, reports map[string]string, stdout, stderr io.Writer) int {
+	out := stdout
+	for _, path := range reports {
+		if path == "-" {
+			out = stderr
+		}
+	}
+	run, runCtx := m.newRun(ctx, fi, concurrency, bail)
+	nameW := 0
+	for _, r := range run.results {
+		nameW = max(nameW, len([]rune(r.name)))
+	}
+	var mu sync.Mutex
+	m.sendAll(runCtx, run, func(ev runEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		if ctx.Err() != nil && run.stopped == "" {
+			run.stopped = "interrupted"
+		}
+		if st := run.apply(ev); st == runPassed || st == runFailed {
+			fmt.Fprintln(out, runLine(run.results[ev.idx], nameW))
+		}
+	})
+	run.finish()
+	for _, r := range run.results {
+		if r.state == runSkipped {
+			fmt.Fprintln(out, runLine(r, nameW))
+		}
+	}
+
+	summary := fmt.Sprintf("%s · %d passed · %d failed · %d skipped · %s",
+		plural(len(run.results), "request"), run.count(runPassed), run.count(runFailed),
+		run.count(runSkipped), run.elapsed.Round(time.Millisecond))
+	if run.stopped != "" {
+		summary += " · " + run.stopped
+	}
+	fmt.Fprintln(out, summary)
+
+	for _, format := range []string{reportJUnit, reportJSON} {
+		path, ok := reports[format]
+		if !ok {
+			continue
+		}
+		var err error
+		if path == "-" {
+			err = writeReport(stdout, format, run)
+		} else {
+			err = saveReport(path, format, run)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "%s report: %v\n", format, err)
+			return exitUsage
+		}
+	}
+	if run.count(runPassed) != len(run.results) {
+		return exitFailed
+	}
+	return exitOK
+}
+
+// runLine is the plain-text row of a request in a headless run, with the
+// name padded to nameW runes.
+func runLine(r runResult, nameW int) string {
+	name := r.name + strings.Repeat(" ", max(0, nameW-len([]rune(r.name))))
+	switch r.state {
+	case runSkipped:
+		return fmt.Sprintf("– %-7s %s  skipped", r.method, name)
+	case runPassed, runFailed:
+	default:
+		return ""
+	}
+	mark := "✓"
+	if r.state == runFailed {
+		mark = "✗"
+	}
+	status := r.resp.status
+	if r.resp.err != nil {
+		status = "error"
+	}
+	line := fmt.Sprintf("%s %-7s %s  %-24s %8s", mark, r.method, name, status,
+		r.resp.duration.Round(time.Millisecond))
+	if len(r.resp.tests) > 0 {
+		line += "  " + strconv.Itoa(testsPassed(r.resp.tests)) + "/" + strconv.Itoa(len(r.resp.tests))
+	}
+	if r.state == runFailed {
+		line += "\n    " + runFailure(r.resp)
+	}
+	return line
+}
+
+// parseInterspersed parses flags that may come before or after the positional
+// arguments, as in `send "GitHub/Get User" --env staging`, and returns the
+// positional ones.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var pos []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return pos, nil
+		}
+		pos = append(pos, args[0])
+		args = args[1:]
+	}
+}
+
+// stringList is a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
@@ -0,0 +1,29 @@
+package ui
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestHeadlessRefusesFlagsOfTheOtherCommand(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"send", "F/r", "-c", "2"}, "send: -c only applies to run"},
+		{[]string{"send", "--bail", "F/r"}, "send: --bail only applies to run"},
+		{[]string{"send", "F/r", "--junit", "out.xml", "--json", "-"}, "send: --json, --junit only applies to run"},
+		{[]string{"run", "F", "-i"}, "run: -i only applies to send"},
+		{[]string{"run", "--include", "F"}, "run: --include only applies to send"},
+	}
+	for _, tt := range tests {
+		var stderr strings.Builder
+		if code := RunHeadless(tt.args, io.Discard, &stderr); code != exitUsage {
+			t.Errorf("%q: exit %d, want %d", tt.args, code, exitUsage)
+		}
+		if !strings.HasPrefix(stderr.String(), tt.want+"\n") {
+			t.Errorf("%q: %q, want %q", tt.args, stderr.String(), tt.want)
+		}
+	}
+}
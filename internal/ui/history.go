@@ -0,0 +1,216 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxHistory is how many responses are kept per request.
+const maxHistory = 20
+
+// snapshot is a response kept for later comparison: an entry of a request's
+// history or a pinned example.
+type snapshot struct {
+	status     string
+	statusCode int
+	header     http.Header
+	body       []byte
+	duration   time.Duration
+	at         time.Time
+}
+
+// example is a response pinned on a request under a name.
+type example struct {
+	name string
+	snapshot
+}
+
+func snapshotOf(resp response) snapshot {
+	return snapshot{
+		status:     resp.status,
+		statusCode: resp.statusCode,
+		header:     resp.header,
+		body:       resp.body,
+		duration:   resp.duration,
+		at:         time.Now(),
+	}
+}
+
+// recordHistory appends a completed response to the active request's history,
+// dropping the oldest entries past maxHistory. History lives in memory only.
+func (m Model) recordHistory(resp response) Model {
+	if m.activeFolderIdx < 0 {
+		return m
+	}
+	return m.recordHistoryAt(m.activeFolderIdx, m.activeReqIdx, resp)
+}
+
+// recordHistoryAt is recordHistory for any request, such as one of a run.
+func (m Model) recordHistoryAt(fi, ri int, resp response) Model {
+	if resp.statusCode == 0 {
+		return m
+	}
+	r := &m.folders[fi].requests[ri]
+	r.history = append(r.history, m.snapshot(resp))
+	if over := len(r.history) - maxHistory; over > 0 {
+		r.history = append([]snapshot(nil), r.history[over:]...)
+	}
+	return m
+}
+
+func (r request) exampleIndex(name string) int {
+	for i, e := range r.examples {
+		if e.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// execPin handles ":pin [name]": with a name it pins the last response as an
+// example of the active request, replacing one of the same name; alone it
+// lists the examples.
+func (m Model) execPin(args []string) Model {
+	if m.activeFolderIdx < 0 {
+		m.cmdError = "no active request"
+		return m
+	}
+	r := &m.folders[m.activeFolderIdx].requests[m.activeReqIdx]
+	name := strings.Join(args, " ")
+	if name == "" {
+		if len(r.examples) == 0 {
+			m.cmdInfo = "no examples pinned"
+			return m
+		}
+		names := make([]string, len(r.examples))
+		for i, e := range r.examples {
+			names[i] = e.name
+		}
+		m.cmdInfo = "examples: " + strings.Join(names, ", ")
+		return m
+	}
+	if m.resp == nil || m.resp.statusCode == 0 {
+		m.cmdError = "no response to pin"
+		return m
+	}
+	if strings.HasPrefix(name, "#") || name == "off" {
+		m.cmdError = `example names cannot be "off" or start with #`
+		return m
+	}
+	e := example{name: name, snapshot: m.snapshot(*m.resp)}
+	if i := r.exampleIndex(name); i >= 0 {
+		r.examples[i] = e
+		m.cmdInfo = "replaced example " + strconv.Quote(name)
+		return m
+	}
+	r.examples = append(r.examples, e)
+	m.cmdInfo = "pinned example " + strconv.Quote(name)
+	return m
+}
+
+// execUnpin handles ":unpin <name>".
+func (m Model) execUnpin(args []string) Model {
+	if m.activeFolderIdx < 0 {
+		m.cmdError = "no active request"
+		return m
+	}
+	r := &m.folders[m.activeFolderIdx].requests[m.activeReqIdx]
+	name := strings.Join(args, " ")
+	i := r.exampleIndex(name)
+	if i < 0 {
+		m.cmdError = "no example " + strconv.Quote(name)
+		return m
+	}
+	r.examples = append(r.examples[:i:i], r.examples[i+1:]...)
+	m.cmdInfo = "unpinned example " + strconv.Quote(name)
+	return m
+}
+
+// execHistory handles ":history", listing the earlier responses of the active
+// request as :diff refers to them: #1 is the one before the latest.
+func (m Model) execHistory() Model {
+	if m.activeFolderIdx < 0 {
+		m.cmdError = "no active request"
+		return m
+	}
+	h := m.folders[m.activeFolderIdx].requests[m.activeReqIdx].history
+	if len(h) < 2 {
+		m.cmdInfo = "no earlier responses"
+		return m
+	}
+	var parts []string
+	for n := 1; n < len(h); n++ {
+		s := h[len(h)-1-n]
+		parts = append(parts, fmt.Sprintf("#%d %d %s", n, s.statusCode, s.at.Format("15:04:05")))
+	}
+	m.cmdInfo = strings.Join(parts, " · ")
+	return m
+}
+
+// maxExampleLines bounds the body of the example shown in the picker preview.
+const maxExampleLines = 12
+
+// renderExamples lists the examples of a request for the folder picker
+// preview, with the headers and the start of the body of the selected one.
+func (m Model) renderExamples(r request, width int) []string {
+	labelStyle := m.theme.highlight().Bold(true)
+	dimStyle := m.theme.dim()
+	valStyle := m.theme.textMuted()
+
+	title := labelStyle.Render("Examples")
+	if len(r.examples) > 1 {
+		title += "  " + m.theme.keyHint("[/]") + dimStyle.Render("cycle")
+	}
+	lines := []string{title}
+	if len(r.examples) == 0 {
+		return append(lines, dimStyle.Render("  (none — :pin or :import adds some)"))
+	}
+	sel := m.fpExample % len(r.examples)
+	for i, e := range r.examples {
+		marker := "  "
+		name := valStyle.Render(e.name)
+		if i == sel {
+			marker = m.theme.accent().Bold(true).Render("▸ ")
+			name = m.theme.text().Render(e.name)
+		}
+		lines = append(lines, marker+name+"  "+m.statusStyle(e.statusCode).Render(e.status)+
+			dimStyle.Render(" · "+formatBytes(len(e.body))))
+	}
+
+	e := r.examples[sel]
+	lines = append(lines, dimStyle.Render("  "+strings.Repeat("─", max(0, width-4))))
+	keys := make([]string, 0, len(e.header))
+	for k := range e.header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lines = append(lines, "  "+dimStyle.Render(k+": ")+valStyle.Render(strings.Join(e.header[k], ", ")))
+	}
+	if len(keys) > 0 {
+		lines = append(lines, "")
+	}
+	if isBinary(e.body) {
+		return append(lines, "  "+valStyle.Render(binarySummary(e.body)))
+	}
+	body := e.body
+	var indented bytes.Buffer
+	if isJSON(e.header, body) && json.Indent(&indented, body, "", "  ") == nil {
+		body = indented.Bytes()
+	}
+	bodyLines := strings.Split(strings.TrimRight(string(body), "\n"), "\n")
+	for i, l := range bodyLines {
+		if i == maxExampleLines {
+			lines = append(lines, dimStyle.Render(fmt.Sprintf("  … %d more lines", len(bodyLines)-i)))
+			break
+		}
+		lines = append(lines, "  "+valStyle.Render(printable(l)))
+	}
+	return lines
+}
@@ -0,0 +1,557 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// execImport handles ":import <file>", adding the requests of a Postman v2.1
+// collection or an OpenAPI 3 document as new folders. Saved responses and
+// documented examples become examples of the imported requests.
+func (m Model) execImport(args []string) Model {
+	if len(args) != 1 {
+		m.cmdError = "usage: import <file>"
+		return m
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		m.cmdError = err.Error()
+		return m
+	}
+	folders, err := importCollection(data)
+	if err != nil {
+		m.cmdError = err.Error()
+		return m
+	}
+	reqs, examples := 0, 0
+	for fi := range folders {
+		for ri := range folders[fi].requests {
+			r := &folders[fi].requests[ri]
+			r.searchable = r.searchText()
+			examples += len(r.examples)
+		}
+		reqs += len(folders[fi].requests)
+	}
+	m.folders = append(m.folders, folders...)
+	m.fpSearchResults = nil
+	m.cmdInfo = fmt.Sprintf("imported %s and %s into %s",
+		plural(reqs, "request"), plural(examples, "example"), plural(len(folders), "folder"))
+	return m
+}
+
+// importCollection recognizes the format of data and converts it.
+func importCollection(data []byte) ([]folder, error) {
+	var probe struct {
+		Info struct {
+			Schema string `json:"schema"`
+		} `json:"info"`
+		OpenAPI string `json:"openapi" yaml:"openapi"`
+		Swagger string `json:"swagger" yaml:"swagger"`
+	}
+	if json.Unmarshal(data, &probe) != nil {
+		// not JSON: OpenAPI documents are often YAML
+		if err := yaml.Unmarshal(data, &probe); err != nil {
+			return nil, errors.New("not a JSON or YAML document")
+		}
+	}
+	switch {
+	case strings.Contains(probe.Info.Schema, "getpostman.com"):
+		if !strings.Contains(probe.Info.Schema, "v2.") {
+			return nil, errors.New("only Postman collection format v2.x is supported")
+		}
+		return importPostman(data)
+	case strings.HasPrefix(probe.OpenAPI, "3."):
+		return importOpenAPI(data)
+	case probe.Swagger != "":
+		return nil, errors.New("Swagger 2.0 is not supported; convert it to OpenAPI 3 first")
+	}
+	return nil, errors.New("not a Postman v2.1 collection or an OpenAPI 3 document")
+}
+
+// --- Postman ---
+
+type pmCollection struct {
+	Info struct {
+		Name string `json:"name"`
+	} `json:"info"`
+	Item []pmItem `json:"item"`
+}
+
+// pmItem is a folder when Item is set, a request otherwise.
+type pmItem struct {
+	Name     string       `json:"name"`
+	Item     []pmItem     `json:"item"`
+	Request  *pmRequest   `json:"request"`
+	Response []pmResponse `json:"response"`
+}
+
+type pmKV struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled"`
+}
+
+type pmRequest struct {
+	Method string `json:"method"`
+	Header []pmKV `json:"header"`
+	URL    pmURL  `json:"url"`
+	Body   *struct {
+		Mode       string `json:"mode"`
+		Raw        string `json:"raw"`
+		URLEncoded []pmKV `json:"urlencoded"`
+		GraphQL    *struct {
+			Query     string `json:"query"`
+			Variables string `json:"variables"`
+		} `json:"graphql"`
+	} `json:"body"`
+	Auth *struct {
+		Type   string `json:"type"`
+		Bearer []pmKV `json:"bearer"`
+		Basic  []pmKV `json:"basic"`
+		APIKey []pmKV `json:"apikey"`
+	} `json:"auth"`
+}
+
+// UnmarshalJSON accepts the shorthand form where the request is just a URL.
+func (r *pmRequest) UnmarshalJSON(data []byte) error {
+	var raw string
+	if json.Unmarshal(data, &raw) == nil {
+		*r = pmRequest{Method: "GET", URL: pmURL{Raw: raw}}
+		return nil
+	}
+	type plain pmRequest
+	return json.Unmarshal(data, (*plain)(r))
+}
+
+type pmURL struct {
+	Raw   string `json:"raw"`
+	Query []pmKV `json:"query"`
+}
+
+// UnmarshalJSON accepts the URL as a plain string or as an object.
+func (u *pmURL) UnmarshalJSON(data []byte) error {
+	if json.Unmarshal(data, &u.Raw) == nil {
+		return nil
+	}
+	type plain pmURL
+	return json.Unmarshal(data, (*plain)(u))
+}
+
+type pmResponse struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Code   int    `json:"code"`
+	Header []pmKV `json:"header"`
+	Body   string `json:"body"`
+}
+
+func importPostman(data []byte) ([]folder, error) {
+	var c pmCollection
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("postman collection: %w", err)
+	}
+	name := c.Info.Name
+	if name == "" {
+		name = "Postman"
+	}
+	var folders []folder
+	var walk func(path string, items []pmItem)
+	walk = func(path string, items []pmItem) {
+		f := folder{name: path}
+		for _, it := range items {
+			if it.Request != nil {
+				f.requests = append(f.requests, postmanRequest(it))
+			}
+		}
+		if len(f.requests) > 0 {
+			folders = append(folders, f)
+		}
+		// tuiman folders do not nest, so subfolders are flattened into paths
+		for _, it := range items {
+			if it.Request == nil && it.Item != nil {
+				walk(path+" / "+it.Name, it.Item)
+			}
+		}
+	}
+	walk(name, c.Item)
+	if len(folders) == 0 {
+		return nil, errors.New("the collection has no requests")
+	}
+	return folders, nil
+}
+
+func postmanRequest(it pmItem) request {
+	pr := it.Request
+	r := request{method: strings.ToUpper(pr.Method), name: it.Name, auth: requestAuth{kind: authNone}}
+	if r.method == "" {
+		r.method = "GET"
+	}
+	r.url = pr.URL.Raw
+	if len(pr.URL.Query) > 0 {
+		// the query is kept as params so it can be edited like any other
+		r.url, _, _ = strings.Cut(r.url, "?")
+		for _, q := range pr.URL.Query {
+			if !q.Disabled {
+				r.params = append(r.params, param{key: q.Key, value: q.Value})
+			}
+		}
+	}
+	for _, h := range pr.Header {
+		if !h.Disabled {
+			r.headers = append(r.headers, header{key: h.Key, value: h.Value})
+		}
+	}
+	if b := pr.Body; b != nil {
+		switch b.Mode {
+		case "raw":
+			r.body = b.Raw
+		case "urlencoded":
+			form := url.Values{}
+			for _, kv := range b.URLEncoded {
+				if !kv.Disabled {
+					form.Add(kv.Key, kv.Value)
+				}
+			}
+			r.body = form.Encode()
+			r.headers = append(r.headers, header{key: "Content-Type", value: "application/x-www-form-urlencoded"})
+		case "graphql":
+			if b.GraphQL != nil {
+				r.graphql = &graphQL{query: b.GraphQL.Query, variables: b.GraphQL.Variables}
+			}
+		}
+	}
+	if a := pr.Auth; a != nil {
+		get := func(kvs []pmKV, key string) string {
+			for _, kv := range kvs {
+				if kv.Key == key {
+					return kv.Value
+				}
+			}
+			return ""
+		}
+		switch a.Type {
+		case "bearer":
+			r.auth = requestAuth{kind: authBearer, token: get(a.Bearer, "token")}
+		case "basic":
+			r.auth = requestAuth{kind: authBasic, username: get(a.Basic, "username"), password: get(a.Basic, "password")}
+		case "apikey":
+			if get(a.APIKey, "in") == "query" {
+				r.params = append(r.params, param{key: get(a.APIKey, "key"), value: get(a.APIKey, "value")})
+			} else {
+				r.auth = requestAuth{kind: authAPIKey, apiKey: get(a.APIKey, "key"), apiValue: get(a.APIKey, "value")}
+			}
+		}
+	}
+	for _, res := range it.Response {
+		h := http.Header{}
+		for _, kv := range res.Header {
+			h.Add(kv.Key, kv.Value)
+		}
+		name := res.Name
+		if name == "" {
+			name = strconv.Itoa(res.Code)
+		}
+		r.examples = append(r.examples, example{name: name, snapshot: snapshot{
+			status:     statusText(res.Code, res.Status),
+			statusCode: res.Code,
+			header:     h,
+			body:       []byte(res.Body),
+		}})
+	}
+	return r
+}
+
+// statusText builds a status line like "200 OK" from a code and an optional reason.
+func statusText(code int, reason string) string {
+	if reason == "" {
+		reason = http.StatusText(code)
+	}
+	return strings.TrimSpace(strconv.Itoa(code) + " " + reason)
+}
+
+// --- OpenAPI ---
+
+// openAPIMethods lists the operations of a path item in display order.
+var openAPIMethods = []string{"get", "post", "put", "patch", "delete", "head", "options"}
+
+// pathParam matches an OpenAPI path template parameter such as {id}.
+var pathParam = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// openAPIDoc is a decoded OpenAPI document with local $ref resolution.
+type openAPIDoc struct {
+	root map[string]any
+}
+
+// resolve follows local "$ref" pointers such as "#/components/examples/ok".
+func (d openAPIDoc) resolve(v any) map[string]any {
+	for range 32 { // bounds reference cycles
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return m
+		}
+		v = d.pointer(ref)
+	}
+	return nil
+}
+
+func (d openAPIDoc) pointer(ref string) any {
+	path, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil
+	}
+	var cur any = d.root
+	for _, tok := range strings.Split(path, "/") {
+		tok = strings.NewReplacer("~1", "/", "~0", "~").Replace(tok)
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[tok]
+	}
+	return cur
+}
+
+func importOpenAPI(data []byte) ([]folder, error) {
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	root, _ := stringKeys(raw).(map[string]any)
+	d := openAPIDoc{root: root}
+	title := "OpenAPI"
+	if info := d.resolve(root["info"]); info != nil {
+		if t, ok := info["title"].(string); ok && t != "" {
+			title = t
+		}
+	}
+	base := ""
+	if servers, ok := root["servers"].([]any); ok && len(servers) > 0 {
+		if s := d.resolve(servers[0]); s != nil {
+			base, _ = s["url"].(string)
+			base = strings.TrimSuffix(pathParam.ReplaceAllString(base, "{{$1}}"), "/")
+		}
+	}
+
+	paths := d.resolve(root["paths"])
+	keys := make([]string, 0, len(paths))
+	for p := range paths {
+		keys = append(keys, p)
+	}
+	sort.Strings(keys)
+
+	// one folder per first tag, in order of appearance; untagged operations
+	// go into a folder named after the API
+	var folders []folder
+	index := map[string]int{}
+	for _, p := range keys {
+		item := d.resolve(paths[p])
+		for _, method := range openAPIMethods {
+			op := d.resolve(item[method])
+			if op == nil {
+				continue
+			}
+			r := d.request(base, p, method, item, op)
+			tag := title
+			if tags, ok := op["tags"].([]any); ok && len(tags) > 0 {
+				if t, ok := tags[0].(string); ok {
+					tag = t
+				}
+			}
+			fi, ok := index[tag]
+			if !ok {
+				fi = len(folders)
+				index[tag] = fi
+				folders = append(folders, folder{name: tag})
+			}
+			folders[fi].requests = append(folders[fi].requests, r)
+		}
+	}
+	if len(folders) == 0 {
+		return nil, errors.New("the document has no operations")
+	}
+	return folders, nil
+}
+
+// request converts one operation. Path parameters become {{variables}} so an
+// environment can fill them in.
+func (d openAPIDoc) request(base, path, method string, item, op map[string]any) request {
+	r := request{
+		method: strings.ToUpper(method),
+		url:    base + pathParam.ReplaceAllString(path, "{{$1}}"),
+		auth:   requestAuth{kind: authNone},
+	}
+	r.name, _ = op["summary"].(string)
+	if r.name == "" {
+		r.name, _ = op["operationId"].(string)
+	}
+	if r.name == "" {
+		r.name = r.method + " " + path
+	}
+
+	params, _ := item["parameters"].([]any)
+	opParams, _ := op["parameters"].([]any)
+	for _, p := range append(params, opParams...) {
+		pm := d.resolve(p)
+		if pm == nil {
+			continue
+		}
+		name, _ := pm["name"].(string)
+		value := ""
+		if ex, ok := pm["example"]; ok {
+			value = scalarString(ex)
+		}
+		switch pm["in"] {
+		case "query":
+			r.params = append(r.params, param{key: name, value: value})
+		case "header":
+			r.headers = append(r.headers, header{key: name, value: value})
+		}
+	}
+
+	if body := d.resolve(op["requestBody"]); body != nil {
+		if mt, media := d.firstMedia(body["content"]); media != nil {
+			r.headers = append(r.headers, header{key: "Content-Type", value: mt})
+			if ex := d.mediaExamples(media); len(ex) > 0 {
+				r.body = exampleBody(ex[0].value)
+			}
+		}
+	}
+
+	responses := d.resolve(op["responses"])
+	codes := make([]string, 0, len(responses))
+	for c := range responses {
+		codes = append(codes, c)
+	}
+	sort.Strings(codes)
+	for _, c := range codes {
+		res := d.resolve(responses[c])
+		content := d.resolve(res["content"])
+		mts := make([]string, 0, len(content))
+		for mt := range content {
+			mts = append(mts, mt)
+		}
+		sort.Strings(mts)
+		code, _ := strconv.Atoi(c) // "default" and "2XX" have no single code
+		for _, mt := range mts {
+			for _, ex := range d.mediaExamples(d.resolve(content[mt])) {
+				name := c
+				if ex.name != "" {
+					name += " " + ex.name
+				}
+				r.examples = append(r.examples, example{name: name, snapshot: snapshot{
+					status:     statusText(code, ""),
+					statusCode: code,
+					header:     http.Header{"Content-Type": {mt}},
+					body:       []byte(exampleBody(ex.value)),
+				}})
+			}
+		}
+	}
+	return r
+}
+
+// firstMedia picks the media type of a content map to use for a request body,
+// preferring JSON.
+func (d openAPIDoc) firstMedia(v any) (string, map[string]any) {
+	content := d.resolve(v)
+	if media := d.resolve(content["application/json"]); media != nil {
+		return "application/json", media
+	}
+	mts := make([]string, 0, len(content))
+	for mt := range content {
+		mts = append(mts, mt)
+	}
+	sort.Strings(mts)
+	if len(mts) == 0 {
+		return "", nil
+	}
+	return mts[0], d.resolve(content[mts[0]])
+}
+
+type namedExample struct {
+	name  string
+	value any
+}
+
+// mediaExamples returns the example of a media type object, or its named
+// examples in name order.
+func (d openAPIDoc) mediaExamples(media map[string]any) []namedExample {
+	if ex, ok := media["example"]; ok {
+		return []namedExample{{value: ex}}
+	}
+	examples := d.resolve(media["examples"])
+	names := make([]string, 0, len(examples))
+	for n := range examples {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	var out []namedExample
+	for _, n := range names {
+		if ex := d.resolve(examples[n]); ex != nil {
+			if v, ok := ex["value"]; ok {
+				out = append(out, namedExample{n, v})
+			}
+		}
+	}
+	return out
+}
+
+// exampleBody renders an example value: strings as they are, anything else
+// as indented JSON.
+func exampleBody(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// stringKeys converts the maps YAML decodes with non-string keys, such as
+// unquoted status codes, into map[string]any.
+func stringKeys(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, e := range t {
+			t[k] = stringKeys(e)
+		}
+	case map[any]any:
+		m := make(map[string]any, len(t))
+		for k, e := range t {
+			m[fmt.Sprint(k)] = stringKeys(e)
+		}
+		return m
+	case []any:
+		for i, e := range t {
+			t[i] = stringKeys(e)
+		}
+	}
+	return v
+}
+
+func scalarString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,1507 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// This file implements the subset of jq used by the response filter bar:
+// paths (.a.b, ."k", .[0], .[-1], .[2:4], .[], ..), optional access (?),
+// pipes, commas, alternatives (//), comparisons, and/or, arithmetic,
+// array and object construction, and the common builtins such as map,
+// select, length, keys, has, sort_by and test.
+
+// jqObject is a JSON object that keeps its key order, so filtered output
+// reads like the response it came from.
+type jqObject struct {
+	keys []string
+	vals map[string]any
+}
+
+func (o *jqObject) get(k string) (any, bool) {
+	v, ok := o.vals[k]
+	return v, ok
+}
+
+func (o *jqObject) set(k string, v any) {
+	if _, ok := o.vals[k]; !ok {
+		o.keys = append(o.keys, k)
+	}
+	o.vals[k] = v
+}
+
+func newJQObject() *jqObject {
+	return &jqObject{vals: map[string]any{}}
+}
+
+// jqDecode decodes a JSON document into jq values: nil, bool, json.Number,
+// string, []any and *jqObject.
+func jqDecode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return jqDecodeValue(dec)
+}
+
+func jqDecodeValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			obj := newJQObject()
+			for dec.More() {
+				kt, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				v, err := jqDecodeValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				obj.set(kt.(string), v)
+			}
+			_, err := dec.Token()
+			return obj, err
+		case '[':
+			arr := []any{}
+			for dec.More() {
+				v, err := jqDecodeValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, v)
+			}
+			_, err := dec.Token()
+			return arr, err
+		}
+	}
+	return tok, nil
+}
+
+// jqEncode writes v as compact JSON.
+func jqEncode(buf *bytes.Buffer, v any) {
+	switch t := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(t))
+	case json.Number:
+		buf.WriteString(t.String())
+	case float64:
+		buf.WriteString(jqFormatNumber(t))
+	case string:
+		enc := json.NewEncoder(buf)
+		enc.SetEscapeHTML(false)
+		enc.Encode(t)
+		buf.Truncate(buf.Len() - 1) // Encode appends a newline
+	case []any:
+		buf.WriteByte('[')
+		for i, e := range t {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			jqEncode(buf, e)
+		}
+		buf.WriteByte(']')
+	case *jqObject:
+		buf.WriteByte('{')
+		for i, k := range t.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			jqEncode(buf, k)
+			buf.WriteByte(':')
+			jqEncode(buf, t.vals[k])
+		}
+		buf.WriteByte('}')
+	}
+}
+
+func jqFormatNumber(f float64) string {
+	if f == math.Trunc(f) && math.Abs(f) < 1e17 {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// jqRun evaluates expr against input and returns the results as a stream of
+// JSON documents, one per line.
+func jqRun(expr string, input any) ([]byte, error) {
+	q, err := jqParse(expr)
+	if err != nil {
+		return nil, err
+	}
+	out, err := q.eval(input)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for _, v := range out {
+		jqEncode(&buf, v)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// --- lexer ---
+
+type jqTokKind int
+
+const (
+	jqEOF jqTokKind = iota
+	jqPunct
+	jqIdent
+	jqField // .name
+	jqNumber
+	jqString
+)
+
+type jqTok struct {
+	kind jqTokKind
+	text string
+	pos  int
+}
+
+func jqLex(src string) ([]jqTok, error) {
+	var toks []jqTok
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(src) && src[j] != '"' {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			s, err := strconv.Unquote(src[i : j+1])
+			if err != nil {
+				return nil, fmt.Errorf("bad string at %d", i)
+			}
+			toks = append(toks, jqTok{jqString, s, i})
+			i = j + 1
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.' || src[j] == 'e' || src[j] == 'E') {
+				j++
+			}
+			toks = append(toks, jqTok{jqNumber, src[i:j], i})
+			i = j
+		case c == '.' && i+1 < len(src) && src[i+1] == '.':
+			toks = append(toks, jqTok{jqPunct, "..", i})
+			i += 2
+		case c == '.' && i+1 < len(src) && jqIdentStart(rune(src[i+1])):
+			j := i + 1
+			for j < len(src) && jqIdentPart(rune(src[j])) {
+				j++
+			}
+			toks = append(toks, jqTok{jqField, src[i+1 : j], i})
+			i = j
+		case jqIdentStart(rune(c)):
+			j := i
+			for j < len(src) && jqIdentPart(rune(src[j])) {
+				j++
+			}
+			toks = append(toks, jqTok{jqIdent, src[i:j], i})
+			i = j
+		default:
+			two := ""
+			if i+1 < len(src) {
+				two = src[i : i+2]
+			}
+			switch two {
+			case "==", "!=", "<=", ">=", "//":
+				toks = append(toks, jqTok{jqPunct, two, i})
+				i += 2
+				continue
+			}
+			if !strings.ContainsRune(".[]{}()|,:;?<>+-*/%", rune(c)) {
+				return nil, fmt.Errorf("unexpected %q at %d", c, i)
+			}
+			toks = append(toks, jqTok{jqPunct, string(c), i})
+			i++
+		}
+	}
+	return append(toks, jqTok{kind: jqEOF, pos: len(src)}), nil
+}
+
+func jqIdentStart(r rune) bool { return r == '_' || unicode.IsLetter(r) }
+func jqIdentPart(r rune) bool  { return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) }
+
+// --- parser ---
+
+type jqNode interface {
+	eval(in any) ([]any, error)
+}
+
+type jqParser struct {
+	toks []jqTok
+	pos  int
+}
+
+func jqParse(src string) (jqNode, error) {
+	if strings.TrimSpace(src) == "" {
+		return jqIdentity{}, nil
+	}
+	toks, err := jqLex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &jqParser{toks: toks}
+	n, err := p.pipe()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != jqEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+	}
+	return n, nil
+}
+
+func (p *jqParser) peek() jqTok { return p.toks[p.pos] }
+func (p *jqParser) next() jqTok { t := p.toks[p.pos]; p.pos++; return t }
+
+func (p *jqParser) is(text string) bool {
+	t := p.peek()
+	return (t.kind == jqPunct || t.kind == jqIdent) && t.text == text
+}
+
+func (p *jqParser) expect(text string) error {
+	if !p.is(text) {
+		t := p.peek()
+		if t.kind == jqEOF {
+			return fmt.Errorf("expected %q at end", text)
+		}
+		return fmt.Errorf("expected %q at %d", text, t.pos)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *jqParser) pipe() (jqNode, error) {
+	left, err := p.comma()
+	if err != nil {
+		return nil, err
+	}
+	for p.is("|") {
+		p.pos++
+		right, err := p.comma()
+		if err != nil {
+			return nil, err
+		}
+		left = jqPipe{left, right}
+	}
+	return left, nil
+}
+
+func (p *jqParser) comma() (jqNode, error) {
+	left, err := p.alt()
+	if err != nil {
+		return nil, err
+	}
+	for p.is(",") {
+		p.pos++
+		right, err := p.alt()
+		if err != nil {
+			return nil, err
+		}
+		left = jqComma{left, right}
+	}
+	return left, nil
+}
+
+// binaryLevel parses left-associative operators of one precedence level.
+func (p *jqParser) binaryLevel(ops []string, sub func() (jqNode, error)) (jqNode, error) {
+	left, err := sub()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := ""
+		for _, o := range ops {
+			if p.is(o) {
+				op = o
+			}
+		}
+		if op == "" {
+			return left, nil
+		}
+		p.pos++
+		right, err := sub()
+		if err != nil {
+			return nil, err
+		}
+		left = jqBinary{op, left, right}
+	}
+}
+
+func (p *jqParser) alt() (jqNode, error) {
+	return p.binaryLevel([]string{"//"}, p.or)
+}
+
+func (p *jqParser) or() (jqNode, error) {
+	return p.binaryLevel([]string{"or"}, p.and)
+}
+
+func (p *jqParser) and() (jqNode, error) {
+	return p.binaryLevel([]string{"and"}, p.cmp)
+}
+
+func (p *jqParser) cmp() (jqNode, error) {
+	return p.binaryLevel([]string{"==", "!=", "<", "<=", ">", ">="}, p.add)
+}
+
+func (p *jqParser) add() (jqNode, error) {
+	return p.binaryLevel([]string{"+", "-"}, p.mul)
+}
+
+func (p *jqParser) mul() (jqNode, error) {
+	return p.binaryLevel([]string{"*", "/", "%"}, p.postfix)
+}
+
+func (p *jqParser) postfix() (jqNode, error) {
+	n, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case t.kind == jqField:
+			p.pos++
+			n = jqIndex{n, jqLiteral{t.text}}
+		case p.is(".") && p.toks[p.pos+1].kind == jqString:
+			p.pos++
+			n = jqIndex{n, jqLiteral{p.next().text}}
+		case p.is(".") && p.toks[p.pos+1].text == "[":
+			p.pos++
+		case p.is("["):
+			n, err = p.bracket(n)
+			if err != nil {
+				return nil, err
+			}
+		case p.is("?"):
+			p.pos++
+			n = jqTry{n}
+		default:
+			return n, nil
+		}
+	}
+}
+
+// bracket parses [], [i], [a:b] applied to target.
+func (p *jqParser) bracket(target jqNode) (jqNode, error) {
+	p.pos++ // [
+	if p.is("]") {
+		p.pos++
+		return jqIterate{target}, nil
+	}
+	var from, to jqNode
+	var err error
+	if !p.is(":") {
+		if from, err = p.pipe(); err != nil {
+			return nil, err
+		}
+	}
+	if p.is(":") {
+		p.pos++
+		if !p.is("]") {
+			if to, err = p.pipe(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return jqSlice{target, from, to}, nil
+	}
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return jqIndex{target, from}, nil
+}
+
+func (p *jqParser) primary() (jqNode, error) {
+	t := p.next()
+	switch t.kind {
+	case jqField:
+		return jqIndex{jqIdentity{}, jqLiteral{t.text}}, nil
+	case jqNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad number %q at %d", t.text, t.pos)
+		}
+		return jqLiteral{f}, nil
+	case jqString:
+		return jqLiteral{t.text}, nil
+	case jqIdent:
+		switch t.text {
+		case "true":
+			return jqLiteral{true}, nil
+		case "false":
+			return jqLiteral{false}, nil
+		case "null":
+			return jqLiteral{nil}, nil
+		}
+		var args []jqNode
+		if p.is("(") {
+			p.pos++
+			for {
+				a, err := p.pipe()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, a)
+				if p.is(";") {
+					p.pos++
+					continue
+				}
+				break
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+		}
+		n, err := newJQCall(t.text, args)
+		if err != nil {
+			return nil, fmt.Errorf("%w at %d", err, t.pos)
+		}
+		return n, nil
+	case jqPunct:
+		switch t.text {
+		case ".":
+			if p.peek().kind == jqString {
+				return jqIndex{jqIdentity{}, jqLiteral{p.next().text}}, nil
+			}
+			return jqIdentity{}, nil
+		case "..":
+			return jqRecurse{}, nil
+		case "-":
+			n, err := p.postfix()
+			if err != nil {
+				return nil, err
+			}
+			return jqBinary{"-", jqLiteral{0.0}, n}, nil
+		case "(":
+			n, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		case "[":
+			if p.is("]") {
+				p.pos++
+				return jqCollect{nil}, nil
+			}
+			n, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			return jqCollect{n}, p.expect("]")
+		case "{":
+			return p.object()
+		}
+	case jqEOF:
+		return nil, errors.New("unexpected end of filter")
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+}
+
+func (p *jqParser) object() (jqNode, error) {
+	var entries []jqObjEntry
+	for !p.is("}") {
+		t := p.next()
+		var key string
+		switch t.kind {
+		case jqIdent, jqString:
+			key = t.text
+		default:
+			return nil, fmt.Errorf("expected object key at %d", t.pos)
+		}
+		var val jqNode = jqIndex{jqIdentity{}, jqLiteral{key}}
+		if p.is(":") {
+			p.pos++
+			v, err := p.alt()
+			if err != nil {
+				return nil, err
+			}
+			val = v
+		}
+		entries = append(entries, jqObjEntry{key, val})
+		if !p.is(",") {
+			break
+		}
+		p.pos++
+	}
+	return jqObjectCons{entries}, p.expect("}")
+}
+
+// --- evaluation ---
+
+type jqIdentity struct{}
+
+func (jqIdentity) eval(in any) ([]any, error) { return []any{in}, nil }
+
+type jqLiteral struct{ v any }
+
+func (n jqLiteral) eval(any) ([]any, error) { return []any{n.v}, nil }
+
+type jqRecurse struct{}
+
+func (jqRecurse) eval(in any) ([]any, error) {
+	var out []any
+	var walk func(v any)
+	walk = func(v any) {
+		out = append(out, v)
+		switch t := v.(type) {
+		case []any:
+			for _, e := range t {
+				walk(e)
+			}
+		case *jqObject:
+			for _, k := range t.keys {
+				walk(t.vals[k])
+			}
+		}
+	}
+	walk(in)
+	return out, nil
+}
+
+type jqPipe struct{ left, right jqNode }
+
+func (n jqPipe) eval(in any) ([]any, error) {
+	lv, err := n.left.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	var out []any
+	for _, v := range lv {
+		rv, err := n.right.eval(v)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rv...)
+	}
+	return out, nil
+}
+
+type jqComma struct{ left, right jqNode }
+
+func (n jqComma) eval(in any) ([]any, error) {
+	lv, err := n.left.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	rv, err := n.right.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	return append(lv, rv...), nil
+}
+
+type jqTry struct{ n jqNode }
+
+func (n jqTry) eval(in any) ([]any, error) {
+	out, err := n.n.eval(in)
+	if err != nil {
+		return nil, nil
+	}
+	return out, nil
+}
+
+type jqIndex struct{ target, key jqNode }
+
+func (n jqIndex) eval(in any) ([]any, error) {
+	targets, err := n.target.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	var out []any
+	for _, t := range targets {
+		keys, err := n.key.eval(in)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range keys {
+			v, err := jqIndexValue(t, k)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+func jqIndexValue(t, k any) (any, error) {
+	switch tv := t.(type) {
+	case nil:
+		return nil, nil
+	case *jqObject:
+		s, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot index object with %s", jqType(k))
+		}
+		v, _ := tv.get(s)
+		return v, nil
+	case []any:
+		f, ok := jqToFloat(k)
+		if !ok {
+			return nil, fmt.Errorf("cannot index array with %s", jqType(k))
+		}
+		i := int(f)
+		if i < 0 {
+			i += len(tv)
+		}
+		if i < 0 || i >= len(tv) {
+			return nil, nil
+		}
+		return tv[i], nil
+	}
+	return nil, fmt.Errorf("cannot index %s with %s", jqType(t), jqDescribe(k))
+}
+
+type jqSlice struct{ target, from, to jqNode }
+
+func (n jqSlice) eval(in any) ([]any, error) {
+	targets, err := n.target.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	bound := func(b jqNode, def, length int) (int, error) {
+		if b == nil {
+			return def, nil
+		}
+		vs, err := b.eval(in)
+		if err != nil || len(vs) != 1 {
+			return 0, errors.New("slice bounds must be single numbers")
+		}
+		f, ok := jqToFloat(vs[0])
+		if !ok {
+			return 0, errors.New("slice bounds must be numbers")
+		}
+		i := int(f)
+		if i < 0 {
+			i += length
+		}
+		return max(0, min(i, length)), nil
+	}
+	var out []any
+	for _, t := range targets {
+		var length int
+		switch tv := t.(type) {
+		case []any:
+			length = len(tv)
+		case string:
+			length = len([]rune(tv))
+		case nil:
+			out = append(out, nil)
+			continue
+		default:
+			return nil, fmt.Errorf("cannot slice %s", jqType(t))
+		}
+		from, err := bound(n.from, 0, length)
+		if err != nil {
+			return nil, err
+		}
+		to, err := bound(n.to, length, length)
+		if err != nil {
+			return nil, err
+		}
+		to = max(from, to)
+		switch tv := t.(type) {
+		case []any:
+			out = append(out, tv[from:to])
+		case string:
+			out = append(out, string([]rune(tv)[from:to]))
+		}
+	}
+	return out, nil
+}
+
+type jqIterate struct{ target jqNode }
+
+func (n jqIterate) eval(in any) ([]any, error) {
+	targets, err := n.target.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	var out []any
+	for _, t := range targets {
+		switch tv := t.(type) {
+		case []any:
+			out = append(out, tv...)
+		case *jqObject:
+			for _, k := range tv.keys {
+				out = append(out, tv.vals[k])
+			}
+		default:
+			return nil, fmt.Errorf("cannot iterate over %s", jqDescribe(t))
+		}
+	}
+	return out, nil
+}
+
+type jqCollect struct{ n jqNode }
+
+func (n jqCollect) eval(in any) ([]any, error) {
+	if n.n == nil {
+		return []any{[]any{}}, nil
+	}
+	vs, err := n.n.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	if vs == nil {
+		vs = []any{}
+	}
+	return []any{vs}, nil
+}
+
+type jqObjEntry struct {
+	key string
+	val jqNode
+}
+
+type jqObjectCons struct{ entries []jqObjEntry }
+
+// eval builds one object per combination of entry values, as jq does.
+func (n jqObjectCons) eval(in any) ([]any, error) {
+	objs := []*jqObject{newJQObject()}
+	for _, e := range n.entries {
+		vs, err := e.val.eval(in)
+		if err != nil {
+			return nil, err
+		}
+		var next []*jqObject
+		for _, o := range objs {
+			for _, v := range vs {
+				c := &jqObject{keys: append([]string(nil), o.keys...), vals: map[string]any{}}
+				for k, x := range o.vals {
+					c.vals[k] = x
+				}
+				c.set(e.key, v)
+				next = append(next, c)
+			}
+		}
+		objs = next
+	}
+	out := make([]any, len(objs))
+	for i, o := range objs {
+		out[i] = o
+	}
+	return out, nil
+}
+
+type jqBinary struct {
+	op          string
+	left, right jqNode
+}
+
+func (n jqBinary) eval(in any) ([]any, error) {
+	lv, err := n.left.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "//":
+		var out []any
+		for _, v := range lv {
+			if jqTruthy(v) {
+				out = append(out, v)
+			}
+		}
+		if len(out) > 0 {
+			return out, nil
+		}
+		return n.right.eval(in)
+	case "and", "or":
+		var out []any
+		for _, l := range lv {
+			if n.op == "and" && !jqTruthy(l) {
+				out = append(out, false)
+				continue
+			}
+			if n.op == "or" && jqTruthy(l) {
+				out = append(out, true)
+				continue
+			}
+			rv, err := n.right.eval(in)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range rv {
+				out = append(out, jqTruthy(r))
+			}
+		}
+		return out, nil
+	}
+	rv, err := n.right.eval(in)
+	if err != nil {
+		return nil, err
+	}
+	var out []any
+	for _, r := range rv {
+		for _, l := range lv {
+			v, err := jqApply(n.op, l, r)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+func jqApply(op string, l, r any) (any, error) {
+	switch op {
+	case "==":
+		return jqCompare(l, r) == 0, nil
+	case "!=":
+		return jqCompare(l, r) != 0, nil
+	case "<":
+		return jqCompare(l, r) < 0, nil
+	case "<=":
+		return jqCompare(l, r) <= 0, nil
+	case ">":
+		return jqCompare(l, r) > 0, nil
+	case ">=":
+		return jqCompare(l, r) >= 0, nil
+	}
+
+	lf, lok := jqToFloat(l)
+	rf, rok := jqToFloat(r)
+	if lok && rok {
+		switch op {
+		case "+":
+			return lf + rf, nil
+		case "-":
+			return lf - rf, nil
+		case "*":
+			return lf * rf, nil
+		case "/":
+			if rf == 0 {
+				return nil, errors.New("division by zero")
+			}
+			return lf / rf, nil
+		case "%":
+			if int(rf) == 0 {
+				return nil, errors.New("modulo by zero")
+			}
+			return float64(int(lf) % int(rf)), nil
+		}
+	}
+	if op == "+" {
+		switch {
+		case l == nil:
+			return r, nil
+		case r == nil:
+			return l, nil
+		}
+		switch lv := l.(type) {
+		case string:
+			if rv, ok := r.(string); ok {
+				return lv + rv, nil
+			}
+		case []any:
+			if rv, ok := r.([]any); ok {
+				return append(append([]any{}, lv...), rv...), nil
+			}
+		case *jqObject:
+			if rv, ok := r.(*jqObject); ok {
+				o := newJQObject()
+				for _, k := range lv.keys {
+					o.set(k, lv.vals[k])
+				}
+				for _, k := range rv.keys {
+					o.set(k, rv.vals[k])
+				}
+				return o, nil
+			}
+		}
+	}
+	if op == "-" {
+		if lv, ok := l.([]any); ok {
+			if rv, ok := r.([]any); ok {
+				var out []any
+				for _, x := range lv {
+					keep := true
+					for _, y := range rv {
+						if jqCompare(x, y) == 0 {
+							keep = false
+						}
+					}
+					if keep {
+						out = append(out, x)
+					}
+				}
+				return out, nil
+			}
+		}
+	}
+	if op == "/" {
+		if lv, ok := l.(string); ok {
+			if rv, ok := r.(string); ok {
+				return jqStrings(strings.Split(lv, rv)), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s and %s cannot be combined with %s", jqType(l), jqType(r), op)
+}
+
+// --- builtins ---
+
+type jqCall struct {
+	name string
+	args []jqNode
+}
+
+var jqArity = map[string]int{
+	"length": 0, "keys": 0, "values": 0, "not": 0, "first": 0, "last": 0,
+	"type": 0, "sort": 0, "unique": 0, "reverse": 0, "add": 0, "min": 0,
+	"max": 0, "tostring": 0, "tonumber": 0, "to_entries": 0, "empty": 0,
+	"ascii_downcase": 0, "ascii_upcase": 0, "any": 0, "all": 0, "floor": 0,
+	"map": 1, "select": 1, "has": 1, "sort_by": 1, "group_by": 1, "unique_by": 1,
+	"min_by": 1, "max_by": 1, "test": 1, "join": 1, "split": 1,
+	"contains": 1, "startswith": 1, "endswith": 1, "map_values": 1,
+}
+
+func newJQCall(name string, args []jqNode) (jqNode, error) {
+	want, ok := jqArity[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s", name)
+	}
+	if len(args) != want {
+		return nil, fmt.Errorf("%s/%d is not defined", name, len(args))
+	}
+	return jqCall{name, args}, nil
+}
+
+func (n jqCall) eval(in any) ([]any, error) {
+	// Functions whose argument is a filter over the input's elements.
+	switch n.name {
+	case "map":
+		return jqPipe{jqCollect{jqPipe{jqIterate{jqIdentity{}}, n.args[0]}}, jqIdentity{}}.eval(in)
+	case "select":
+		conds, err := n.args[0].eval(in)
+		if err != nil {
+			return nil, err
+		}
+		var out []any
+		for _, c := range conds {
+			if jqTruthy(c) {
+				out = append(out, in)
+			}
+		}
+		return out, nil
+	case "map_values":
+		obj, ok := in.(*jqObject)
+		if !ok {
+			return n.mapArray(in)
+		}
+		o := newJQObject()
+		for _, k := range obj.keys {
+			vs, err := n.args[0].eval(obj.vals[k])
+			if err != nil {
+				return nil, err
+			}
+			if len(vs) > 0 {
+				o.set(k, vs[0])
+			}
+		}
+		return []any{o}, nil
+	case "sort_by", "group_by", "unique_by", "min_by", "max_by":
+		return n.byKey(in)
+	case "empty":
+		return nil, nil
+	}
+
+	// Functions taking plain value arguments: evaluate each combination.
+	if len(n.args) == 1 {
+		args, err := n.args[0].eval(in)
+		if err != nil {
+			return nil, err
+		}
+		var out []any
+		for _, a := range args {
+			v, err := jqBuiltin1(n.name, in, a)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	}
+	v, err := jqBuiltin0(n.name, in)
+	if err != nil {
+		return nil, err
+	}
+	return []any{v}, nil
+}
+
+func (n jqCall) mapArray(in any) ([]any, error) {
+	return jqCall{"map", n.args}.eval(in)
+}
+
+func (n jqCall) byKey(in any) ([]any, error) {
+	arr, ok := in.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s cannot be applied to %s", n.name, jqType(in))
+	}
+	type keyed struct {
+		key any
+		val any
+	}
+	items := make([]keyed, len(arr))
+	for i, v := range arr {
+		ks, err := n.args[0].eval(v)
+		if err != nil {
+			return nil, err
+		}
+		items[i] = keyed{jqStrings(nil), v}
+		if len(ks) == 1 {
+			items[i].key = ks[0]
+		} else {
+			items[i].key = ks
+		}
+	}
+	sort.SliceStable(items, func(a, b int) bool { return jqCompare(items[a].key, items[b].key) < 0 })
+
+	switch n.name {
+	case "sort_by":
+		out := make([]any, len(items))
+		for i, it := range items {
+			out[i] = it.val
+		}
+		return []any{out}, nil
+	case "min_by", "max_by":
+		if len(items) == 0 {
+			return []any{nil}, nil
+		}
+		if n.name == "min_by" {
+			return []any{items[0].val}, nil
+		}
+		return []any{items[len(items)-1].val}, nil
+	}
+	var groups []any
+	for i, it := range items {
+		if i == 0 || jqCompare(items[i-1].key, it.key) != 0 {
+			groups = append(groups, []any{it.val})
+		} else if n.name == "group_by" {
+			g := groups[len(groups)-1].([]any)
+			groups[len(groups)-1] = append(g, it.val)
+		}
+	}
+	if n.name == "unique_by" {
+		for i, g := range groups {
+			groups[i] = g.([]any)[0]
+		}
+	}
+	if groups == nil {
+		groups = []any{}
+	}
+	return []any{groups}, nil
+}
+
+func jqBuiltin0(name string, in any) (any, error) {
+	switch name {
+	case "length":
+		switch t := in.(type) {
+		case nil:
+			return 0.0, nil
+		case string:
+			return float64(len([]rune(t))), nil
+		case []any:
+			return float64(len(t)), nil
+		case *jqObject:
+			return float64(len(t.keys)), nil
+		case bool:
+			return nil, errors.New("boolean has no length")
+		}
+		f, _ := jqToFloat(in)
+		return math.Abs(f), nil
+	case "keys":
+		switch t := in.(type) {
+		case *jqObject:
+			keys := append([]string(nil), t.keys...)
+			sort.Strings(keys)
+			return jqStrings(keys), nil
+		case []any:
+			out := make([]any, len(t))
+			for i := range t {
+				out[i] = float64(i)
+			}
+			return out, nil
+		}
+	case "values":
+		return jqIterate{jqIdentity{}}.eval(in)
+	case "not":
+		return !jqTruthy(in), nil
+	case "first", "last":
+		if arr, ok := in.([]any); ok {
+			if len(arr) == 0 {
+				return nil, nil
+			}
+			if name == "first" {
+				return arr[0], nil
+			}
+			return arr[len(arr)-1], nil
+		}
+	case "type":
+		return jqType(in), nil
+	case "sort", "unique", "reverse", "min", "max", "add", "any", "all":
+		arr, ok := in.([]any)
+		if !ok {
+			break
+		}
+		return jqArrayBuiltin(name, arr)
+	case "tostring":
+		if s, ok := in.(string); ok {
+			return s, nil
+		}
+		var buf bytes.Buffer
+		jqEncode(&buf, in)
+		return buf.String(), nil
+	case "tonumber":
+		if s, ok := in.(string); ok {
+			f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse %q as a number", s)
+			}
+			return f, nil
+		}
+		if f, ok := jqToFloat(in); ok {
+			return f, nil
+		}
+	case "floor":
+		if f, ok := jqToFloat(in); ok {
+			return math.Floor(f), nil
+		}
+	case "to_entries":
+		if obj, ok := in.(*jqObject); ok {
+			out := make([]any, len(obj.keys))
+			for i, k := range obj.keys {
+				e := newJQObject()
+				e.set("key", k)
+				e.set("value", obj.vals[k])
+				out[i] = e
+			}
+			return out, nil
+		}
+	case "ascii_downcase", "ascii_upcase":
+		if s, ok := in.(string); ok {
+			if name == "ascii_downcase" {
+				return strings.ToLower(s), nil
+			}
+			return strings.ToUpper(s), nil
+		}
+	}
+	return nil, fmt.Errorf("%s cannot be applied to %s", name, jqType(in))
+}
+
+func jqArrayBuiltin(name string, arr []any) (any, error) {
+	switch name {
+	case "sort", "unique":
+		out := append([]any(nil), arr...)
+		sort.SliceStable(out, func(a, b int) bool { return jqCompare(out[a], out[b]) < 0 })
+		if name == "unique" {
+			var uniq []any
+			for i, v := range out {
+				if i == 0 || jqCompare(out[i-1], v) != 0 {
+					uniq = append(uniq, v)
+				}
+			}
+			out = uniq
+		}
+		if out == nil {
+			out = []any{}
+		}
+		return out, nil
+	case "reverse":
+		out := make([]any, len(arr))
+		for i, v := range arr {
+			out[len(arr)-1-i] = v
+		}
+		return out, nil
+	case "min", "max":
+		if len(arr) == 0 {
+			return nil, nil
+		}
+		best := arr[0]
+		for _, v := range arr[1:] {
+			c := jqCompare(v, best)
+			if (name == "min" && c < 0) || (name == "max" && c > 0) {
+				best = v
+			}
+		}
+		return best, nil
+	case "add":
+		var acc any
+		for _, v := range arr {
+			var err error
+			if acc, err = jqApply("+", acc, v); err != nil {
+				return nil, err
+			}
+		}
+		return acc, nil
+	case "any":
+		for _, v := range arr {
+			if jqTruthy(v) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "all":
+		for _, v := range arr {
+			if !jqTruthy(v) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	return nil, fmt.Errorf("unknown function %s", name)
+}
+
+func jqBuiltin1(name string, in, arg any) (any, error) {
+	switch name {
+	case "has":
+		switch t := in.(type) {
+		case *jqObject:
+			if k, ok := arg.(string); ok {
+				_, has := t.get(k)
+				return has, nil
+			}
+		case []any:
+			if f, ok := jqToFloat(arg); ok {
+				return f >= 0 && int(f) < len(t), nil
+			}
+		}
+	case "test":
+		s, ok1 := in.(string)
+		pat, ok2 := arg.(string)
+		if ok1 && ok2 {
+			re, err := regexp.Compile(pat)
+			if err != nil {
+				return nil, fmt.Errorf("test: %v", err)
+			}
+			return re.MatchString(s), nil
+		}
+	case "join":
+		arr, ok1 := in.([]any)
+		sep, ok2 := arg.(string)
+		if ok1 && ok2 {
+			parts := make([]string, len(arr))
+			for i, v := range arr {
+				switch tv := v.(type) {
+				case nil:
+				case string:
+					parts[i] = tv
+				default:
+					var buf bytes.Buffer
+					jqEncode(&buf, v)
+					parts[i] = buf.String()
+				}
+			}
+			return strings.Join(parts, sep), nil
+		}
+	case "split":
+		s, ok1 := in.(string)
+		sep, ok2 := arg.(string)
+		if ok1 && ok2 {
+			return jqStrings(strings.Split(s, sep)), nil
+		}
+	case "startswith", "endswith":
+		s, ok1 := in.(string)
+		a, ok2 := arg.(string)
+		if ok1 && ok2 {
+			if name == "startswith" {
+				return strings.HasPrefix(s, a), nil
+			}
+			return strings.HasSuffix(s, a), nil
+		}
+	case "contains":
+		return jqContains(in, arg), nil
+	}
+	return nil, fmt.Errorf("%s cannot be applied to %s and %s", name, jqType(in), jqType(arg))
+}
+
+func jqContains(a, b any) bool {
+	switch bv := b.(type) {
+	case string:
+		av, ok := a.(string)
+		return ok && strings.Contains(av, bv)
+	case []any:
+		av, ok := a.([]any)
+		if !ok {
+			return false
+		}
+		for _, y := range bv {
+			found := false
+			for _, x := range av {
+				if jqContains(x, y) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	case *jqObject:
+		av, ok := a.(*jqObject)
+		if !ok {
+			return false
+		}
+		for _, k := range bv.keys {
+			x, has := av.get(k)
+			if !has || !jqContains(x, bv.vals[k]) {
+				return false
+			}
+		}
+		return true
+	}
+	return jqCompare(a, b) == 0
+}
+
+// --- value helpers ---
+
+func jqStrings(ss []string) []any {
+	out := make([]any, len(ss))
+	for i, s := range ss {
+		out[i] = s
+	}
+	return out
+}
+
+func jqToFloat(v any) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func jqTruthy(v any) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	}
+	return true
+}
+
+func jqType(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64, json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case *jqObject:
+		return "object"
+	}
+	return "unknown"
+}
+
+func jqDescribe(v any) string {
+	var buf bytes.Buffer
+	jqEncode(&buf, v)
+	s := buf.String()
+	if len(s) > 20 {
+		s = s[:20] + "…"
+	}
+	return jqType(v) + " (" + s + ")"
+}
+
+// jqCompare orders values the way jq does:
+// null < false < true < numbers < strings < arrays < objects.
+func jqCompare(a, b any) int {
+	rank := func(v any) int {
+		switch t := v.(type) {
+		case nil:
+			return 0
+		case bool:
+			if t {
+				return 2
+			}
+			return 1
+		case float64, json.Number:
+			return 3
+		case string:
+			return 4
+		case []any:
+			return 5
+		}
+		return 6
+	}
+	ra, rb := rank(a), rank(b)
+	if ra != rb {
+		return ra - rb
+	}
+	switch av := a.(type) {
+	case float64, json.Number:
+		x, _ := jqToFloat(av)
+		y, _ := jqToFloat(b)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(av, b.(string))
+	case []any:
+		bv := b.([]any)
+		for i := 0; i < len(av) && i < len(bv); i++ {
+			if c := jqCompare(av[i], bv[i]); c != 0 {
+				return c
+			}
+		}
+		return len(av) - len(bv)
+	case *jqObject:
+		bv := b.(*jqObject)
+		ak := append([]string(nil), av.keys...)
+		bk := append([]string(nil), bv.keys...)
+		sort.Strings(ak)
+		sort.Strings(bk)
+		if c := jqCompare(jqStrings(ak), jqStrings(bk)); c != 0 {
+			return c
+		}
+		for _, k := range ak {
+			if c := jqCompare(av.vals[k], bv.vals[k]); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
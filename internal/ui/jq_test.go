@@ -0,0 +1,132 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+const jqInput = `{
+  "user": {"name": "ann", "tags": ["a", "b"], "age": 41},
+  "items": [
+    {"id": 1, "price": 9.5, "ok": true},
+    {"id": 2, "price": 20, "ok": false},
+    {"id": 3, "price": null}
+  ],
+  "empty": null,
+  "weird key": 7
+}`
+
+func TestJQ(t *testing.T) {
+	input, err := jqDecode([]byte(jqInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		expr string
+		want string // the results, one per line
+	}{
+		// Paths.
+		{".user.name", `"ann"`},
+		{".user.tags[1]", `"b"`},
+		{".user.tags[-1]", `"b"`},
+		{".items[0].id", "1"},
+		{`.["weird key"]`, "7"},
+		{`."weird key"`, "7"},
+		{".missing", "null"},
+		{".missing.deeper", "null"},
+		{".items[].id", "1\n2\n3"},
+		{".user.tags[:1]", `["a"]`},
+		{".user | keys", `["age","name","tags"]`},
+		{".user.name.first?", ""},
+		// Pipes and commas.
+		{".items | length", "3"},
+		{".items[] | .id | . * 2", "2\n4\n6"},
+		{".user.name, .user.age", "\"ann\"\n41"},
+		{"[.items[] | .id] | add", "6"},
+		{"{name: .user.name, n: (.items | length)}", `{"name":"ann","n":3}`},
+		// select and map.
+		{".items[] | select(.ok) | .id", "1"},
+		{".items | map(.id)", "[1,2,3]"},
+		{".items | map(select(.price > 10)) | map(.id)", "[2]"},
+		{".items | map(.price // 0)", "[9.5,20,0]"},
+		{`.user.tags | map(ascii_upcase) | join(",")`, `"A,B"`},
+		// Comparisons.
+		{".user.age == 41", "true"},
+		{".user.age != 41", "false"},
+		{".user.age < 50 and .user.age >= 41", "true"},
+		{`.user.name > "a"`, "true"},
+		{"null < false", "true"},
+		{`1 < "1"`, "true"},
+		{".items[0].ok or false", "true"},
+		{".items[1].ok | not", "true"},
+		// Alternatives.
+		{`.empty // "fallback"`, `"fallback"`},
+		{`.items[1].ok // "fallback"`, `"fallback"`},
+		{`.user.name // "fallback"`, `"ann"`},
+		{".items[].price // 0", "9.5\n20"},
+		{"empty // 1", "1"},
+	}
+	for _, tt := range tests {
+		out, err := jqRun(tt.expr, input)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		if got := strings.TrimSuffix(string(out), "\n"); got != tt.want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestJQErrors(t *testing.T) {
+	input, err := jqDecode([]byte(jqInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct{ expr, want string }{
+		// Syntax errors point at the offending byte.
+		{`"abc`, "unterminated string at 0"},
+		{`.user | "\q"`, "bad string at 8"},
+		{".user @ 1", "unexpected '@' at 6"},
+		{"{a: }", `unexpected "}" at 4`},
+		{".user)", `unexpected ")" at 5`},
+		{". | | .", `unexpected "|" at 4`},
+		{".items[1:2:3]", `expected "]" at 10`},
+		{"[.items[] | select(.ok > 1", `expected ")" at end`},
+		{".items | map(", "unexpected end of filter"},
+		{"1 + 1e", `bad number "1e" at 4`},
+		{".user | foo", "unknown function foo at 8"},
+		{"map(.a; .b)", "map/2 is not defined at 0"},
+		// Evaluation errors name the values involved.
+		{".user.tags.b", "cannot index array with string"},
+		{".user.name - 1", "string and number cannot be combined with -"},
+		{".empty | keys", "keys cannot be applied to null"},
+		{"1 / 0", "division by zero"},
+	}
+	for _, tt := range tests {
+		_, err := jqRun(tt.expr, input)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %v, want %q", tt.expr, err, tt.want)
+		}
+	}
+}
+
+// FuzzJQ checks that no filter, however malformed, panics on any input.
+func FuzzJQ(f *testing.F) {
+	for _, expr := range []string{
+		"", ".", "..", ".a.b[0]", ".[1:-1]", ".a[]?", "[.[] | select(. > 1)]",
+		"map(.x // 0) | add", `{a: .b, "c": 1}`, "(((", ")))", ".[", "{a", "$x",
+		`"\u00e9`, ".é", "-", "1e", "keys[0]", "sort_by(.a) | first", ".a as $x",
+	} {
+		for _, in := range []string{`null`, `{"a": {"b": [1, 2]}}`, `[3, "x", null, {}]`, `"s"`, `{"a"`, `[1,`} {
+			f.Add(expr, in)
+		}
+	}
+	f.Fuzz(func(t *testing.T, expr, in string) {
+		v, err := jqDecode([]byte(in))
+		if err != nil {
+			return
+		}
+		jqRun(expr, v)
+	})
+}
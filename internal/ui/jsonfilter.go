@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// filterMsg carries the result of evaluating a jq filter off the UI goroutine.
+type filterMsg struct {
+	seq int
+	doc *jsonDoc
+	err error
+}
+
+// applyFilter re-evaluates filterInput against the JSON body. Every call
+// bumps filterSeq so results of earlier keystrokes that arrive late are dropped.
+func (m Model) applyFilter() (Model, tea.Cmd) {
+	m.filterSeq++
+	if m.resp == nil || m.jvFull == nil {
+		return m, nil
+	}
+	expr := strings.TrimSpace(m.filterInput)
+	if expr == "" {
+		m.filterErr = ""
+		m = m.clearSearch()
+		m.jv = m.jvFull
+		m.bodyCursor = 0
+		m.respScroll = 0
+		return m, nil
+	}
+	seq, input := m.filterSeq, m.resp.jsonValue
+	return m, func() tea.Msg {
+		out, err := jqRun(expr, input)
+		if err != nil {
+			return filterMsg{seq: seq, err: err}
+		}
+		return filterMsg{seq: seq, doc: parseJSONDoc(out)}
+	}
+}
+
+// handleFilterMsg shows a filter result, or keeps the last good result and
+// reports the error while the expression is still being typed.
+func (m Model) handleFilterMsg(msg filterMsg) Model {
+	if msg.seq != m.filterSeq {
+		return m
+	}
+	if msg.err != nil {
+		m.filterErr = msg.err.Error()
+		return m
+	}
+	m.filterErr = ""
+	m = m.clearSearch()
+	m.jv = newJSONView(msg.doc)
+	m.bodyCursor = 0
+	m.respScroll = 0
+	return m
+}
+
+// updateFilterBar handles keys while the filter bar has focus. The result
+// updates on every keystroke; enter keeps the filter and remembers it on the
+// active request, esc restores the filter that was in effect before editing.
+func (m Model) updateFilterBar(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.filtering = false
+		m.filterInput = m.filterPrev
+		return m.applyFilter()
+	case "enter":
+		m.filtering = false
+		if m.activeFolderIdx >= 0 {
+			m.folders[m.activeFolderIdx].requests[m.activeReqIdx].filter = strings.TrimSpace(m.filterInput)
+		}
+		return m, nil
+	case "ctrl+u":
+		m.filterInput = ""
+	case "backspace":
+		runes := []rune(m.filterInput)
+		if len(runes) == 0 {
+			return m, nil
+		}
+		m.filterInput = string(runes[:len(runes)-1])
+	default:
+		if len([]rune(msg.String())) != 1 {
+			return m, nil
+		}
+		m.filterInput += msg.String()
+	}
+	return m.applyFilter()
+}
+
+// renderFilterBar renders the line above the JSON body: the filter being
+// edited, the filter in effect, or the key hints when there is none.
+func (m Model) renderFilterBar(w int) string {
+	dim := m.theme.dim()
+	var line string
+	switch {
+	case m.filtering:
+		line = m.theme.accent().Bold(true).Render("  jq ") +
+			m.theme.text().Render(m.filterInput) + m.theme.accent().Render("█")
+		if m.filterErr != "" {
+			line += "  " + m.theme.errStyle().Render(m.filterErr)
+		} else {
+			line += dim.Render("  " + m.filterResultSummary())
+		}
+	case m.filterInput != "":
+		line = m.theme.accent().Render("  jq ") + m.theme.text().Render(m.filterInput) +
+			dim.Render("  "+m.filterResultSummary()+"  ") +
+			m.theme.keyHint("|") + dim.Render("edit")
+		if m.filterErr != "" {
+			line += "  " + m.theme.errStyle().Render(m.filterErr)
+		}
+	default:
+		line = dim.Render("  JSON · "+m.jv.status()+"  ") +
+			m.theme.keyHint("enter") + dim.Render("fold  ") +
+			m.theme.keyHint("-/+") + dim.Render("all  ") +
+			m.theme.keyHint("|") + dim.Render("filter  ") +
+			m.theme.keyHint("/") + dim.Render("search")
+	}
+	return lipgloss.NewStyle().MaxWidth(w).Render(line)
+}
+
+func (m Model) filterResultSummary() string {
+	if m.jv == m.jvFull {
+		return "unfiltered"
+	}
+	roots := 0
+	for _, l := range m.jv.doc.lines {
+		if l.depth == 0 && l.kind != jsonClose {
+			roots++
+		}
+	}
+	if roots == 0 {
+		return "no results"
+	}
+	return plural(roots, "result")
+}
@@ -0,0 +1,31 @@
+package ui
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseKeepsTheSentRequestsFilter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"a": 1, "b": 2}`)
+	}))
+	defer srv.Close()
+	m := New()
+	m.folders = []folder{{name: "F", requests: []request{
+		{method: "GET", name: "sent", url: srv.URL, filter: ".a"},
+		{method: "GET", name: "other", url: srv.URL, filter: ".b"},
+	}}}
+	m.activeFolderIdx, m.activeReqIdx = 0, 0
+	cmd := m.sendCmd(context.Background())
+	// The user moves to another request while the first is in flight.
+	m.activeReqIdx = 1
+
+	next, _ := m.Update(cmd())
+	if got := next.(Model).filterInput; got != ".a" {
+		t.Errorf("filter %q, want the sent request's .a", got)
+	}
+}
@@ -0,0 +1,325 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+type jsonLineKind int
+
+const (
+	jsonScalar jsonLineKind = iota
+	jsonOpen                // { or [ with children
+	jsonClose               // } or ]
+)
+
+type jsonScalarKind int
+
+const (
+	jsonString jsonScalarKind = iota
+	jsonNumber
+	jsonBool
+	jsonNull
+	jsonEmpty // {} or []
+)
+
+// jsonLine is one line of the pretty-printed document.
+type jsonLine struct {
+	kind     jsonLineKind
+	depth    int
+	key      string // raw quoted key; empty for array elements and the root
+	value    string // raw literal for scalars, bracket for open/close
+	scalar   jsonScalarKind
+	comma    bool // followed by a comma
+	match    int  // open ↔ close line index
+	children int  // direct children of an open line
+}
+
+// jsonDoc is a parsed JSON body, flattened into pretty-printed lines.
+// It is built off the UI goroutine when the response arrives.
+type jsonDoc struct {
+	lines []jsonLine
+}
+
+// isJSON reports whether a body should be shown as JSON: by Content-Type
+// (application/json or any +json type), or by sniffing when the type is
+// missing or generic.
+func isJSON(h http.Header, body []byte) bool {
+	ct, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	switch {
+	case ct == "application/json" || strings.HasSuffix(ct, "+json"):
+		return json.Valid(body)
+	case ct == "" || ct == "text/plain" || ct == "application/octet-stream":
+		trimmed := bytes.TrimSpace(body)
+		return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed)
+	}
+	return false
+}
+
+// parseJSONDoc flattens a valid JSON document into lines. It scans the raw
+// bytes rather than decoding so literals keep their original spelling and
+// object keys keep their order. A stream of several documents, as produced by
+// a filter, is flattened one after another.
+func parseJSONDoc(body []byte) *jsonDoc {
+	p := &jsonParser{src: body}
+	d := &jsonDoc{}
+	for p.peek() != 0 {
+		p.value(d, 0, "")
+	}
+	return d
+}
+
+type jsonParser struct {
+	src []byte
+	pos int
+}
+
+func (p *jsonParser) skipSpace() {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *jsonParser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// str scans a quoted string starting at pos and returns it raw, quotes included.
+func (p *jsonParser) str() string {
+	start := p.pos
+	p.pos++ // opening quote
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '\\':
+			p.pos += 2
+			continue
+		case '"':
+			p.pos++
+			return string(p.src[start:p.pos])
+		}
+		p.pos++
+	}
+	return string(p.src[start:])
+}
+
+func (p *jsonParser) literal() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ',', '}', ']', ' ', '\t', '\n', '\r':
+			return string(p.src[start:p.pos])
+		}
+		p.pos++
+	}
+	return string(p.src[start:])
+}
+
+func (p *jsonParser) value(d *jsonDoc, depth int, key string) {
+	c := p.peek()
+	switch c {
+	case '{', '[':
+		closer := byte('}')
+		if c == '[' {
+			closer = ']'
+		}
+		p.pos++
+		if p.peek() == closer {
+			p.pos++
+			d.lines = append(d.lines, jsonLine{depth: depth, key: key, value: string(c) + string(closer), scalar: jsonEmpty})
+			return
+		}
+		open := len(d.lines)
+		d.lines = append(d.lines, jsonLine{kind: jsonOpen, depth: depth, key: key, value: string(c)})
+		for {
+			childKey := ""
+			if c == '{' {
+				childKey = p.str()
+				p.peek()
+				p.pos++ // colon
+			}
+			p.value(d, depth+1, childKey)
+			d.lines[open].children++
+			if p.peek() == ',' {
+				p.pos++
+				d.lines[len(d.lines)-1].comma = true
+				p.peek()
+				continue
+			}
+			p.pos++ // closer
+			break
+		}
+		d.lines = append(d.lines, jsonLine{kind: jsonClose, depth: depth, value: string(closer), match: open})
+		d.lines[open].match = len(d.lines) - 1
+	case '"':
+		d.lines = append(d.lines, jsonLine{depth: depth, key: key, value: p.str(), scalar: jsonString})
+	default:
+		lit := p.literal()
+		kind := jsonNumber
+		switch lit {
+		case "true", "false":
+			kind = jsonBool
+		case "null":
+			kind = jsonNull
+		}
+		d.lines = append(d.lines, jsonLine{depth: depth, key: key, value: lit, scalar: kind})
+	}
+}
+
+// jsonView is the folding state of a jsonDoc. It is shared by pointer so the
+// visible-line index is only rebuilt when a fold changes, not on every frame.
+type jsonView struct {
+	doc     *jsonDoc
+	folded  map[int]bool // open-line indices that are collapsed
+	visible []int        // doc line indices currently shown
+}
+
+func newJSONView(doc *jsonDoc) *jsonView {
+	v := &jsonView{doc: doc, folded: map[int]bool{}}
+	v.rebuild()
+	return v
+}
+
+func (v *jsonView) rebuild() {
+	v.visible = v.visible[:0]
+	lines := v.doc.lines
+	for i := 0; i < len(lines); i++ {
+		v.visible = append(v.visible, i)
+		if lines[i].kind == jsonOpen && v.folded[i] {
+			i = lines[i].match
+		}
+	}
+}
+
+// toggle folds or unfolds the node on visible row row. On a closing bracket
+// it folds the node it closes. It returns the row of the node's opening line.
+func (v *jsonView) toggle(row int) int {
+	if row < 0 || row >= len(v.visible) {
+		return row
+	}
+	i := v.visible[row]
+	l := v.doc.lines[i]
+	if l.kind == jsonClose {
+		i = l.match
+	} else if l.kind != jsonOpen {
+		return row
+	}
+	if v.folded[i] {
+		delete(v.folded, i)
+	} else {
+		v.folded[i] = true
+	}
+	v.rebuild()
+	return v.rowOf(i)
+}
+
+// setAll collapses every node below the root, or expands everything.
+func (v *jsonView) setAll(collapse bool) {
+	v.folded = map[int]bool{}
+	if collapse {
+		for i, l := range v.doc.lines {
+			if l.kind == jsonOpen && l.depth == 1 {
+				v.folded[i] = true
+			}
+		}
+	}
+	v.rebuild()
+}
+
+// rowOf returns the visible row showing doc line i, or the last row before it.
+func (v *jsonView) rowOf(i int) int {
+	lo, hi := 0, len(v.visible)-1
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if v.visible[mid] <= i {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return lo
+}
+
+// renderJSONView renders the h visible rows starting at the scroll offset,
+// keeping the cursor row on screen.
+func (m Model) renderJSONView(v *jsonView, w, h int) string {
+	if h < 1 {
+		return ""
+	}
+	top := m.respScroll
+	end := min(top+h, len(v.visible))
+	lines := make([]string, 0, end-top)
+	for row := top; row < end; row++ {
+		lines = append(lines, m.renderJSONLine(v, row, w))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m Model) renderJSONLine(v *jsonView, row, w int) string {
+	i := v.visible[row]
+	l := v.doc.lines[i]
+	punct := m.theme.dim()
+
+	var sb strings.Builder
+	if row == m.bodyCursor {
+		sb.WriteString(m.theme.accent().Bold(true).Render("> "))
+	} else {
+		sb.WriteString("  ")
+	}
+	sb.WriteString(strings.Repeat("  ", l.depth))
+
+	// The pieces spell out jsonLineText so search hits line up with them.
+	var pieces []piece
+	if l.key != "" {
+		pieces = append(pieces, piece{m.theme.jsonKeyStyle(), l.key}, piece{punct, ": "})
+	}
+	switch l.kind {
+	case jsonOpen, jsonClose:
+		pieces = append(pieces, piece{punct, l.value})
+	default:
+		value := l.value
+		var key string
+		if json.Unmarshal([]byte(l.key), &key); m.presenting && l.scalar != jsonEmpty && m.redactions.key(key) {
+			value = strconv.Quote(redactedMask)
+		}
+		pieces = append(pieces, piece{m.theme.jsonValueStyle(l.scalar), value})
+	}
+	if l.comma && l.kind != jsonOpen {
+		pieces = append(pieces, piece{punct, ","})
+	}
+	sb.WriteString(m.renderPieces(pieces, i))
+
+	if l.kind == jsonOpen && v.folded[i] {
+		closer := v.doc.lines[l.match]
+		summary := plural(l.children, "item")
+		if l.value == "{" {
+			summary = plural(l.children, "key")
+		}
+		sb.WriteString(punct.Render(" … " + closer.value))
+		sb.WriteString(m.theme.dim().Italic(true).Render(" " + summary))
+		if closer.comma {
+			sb.WriteString(punct.Render(","))
+		}
+	}
+	return lipgloss.NewStyle().MaxWidth(w).Render(sb.String())
+}
+
+// jsonStatus summarizes the document for the Body tab hint line.
+func (v *jsonView) status() string {
+	return fmt.Sprintf("%d lines · %d folded", len(v.doc.lines), len(v.folded))
+}
@@ -0,0 +1,386 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// formatXMLBody re-indents an XML document, one element per line. An element
+// holding only text stays on one line, and an empty element is written as <x/>.
+func formatXMLBody(body []byte) ([]synLine, error) {
+	d := xml.NewDecoder(bytes.NewReader(body))
+	var toks []xml.Token
+	var open []string // RawToken does not check nesting, so track it here
+	for {
+		t, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := t.(type) {
+		case xml.StartElement:
+			open = append(open, xmlName(t.Name))
+		case xml.EndElement:
+			line, _ := d.InputPos()
+			if len(open) == 0 || open[len(open)-1] != xmlName(t.Name) {
+				return nil, fmt.Errorf("line %d: unexpected </%s>", line, xmlName(t.Name))
+			}
+			open = open[:len(open)-1]
+		}
+		if cd, ok := t.(xml.CharData); ok && len(bytes.TrimSpace(cd)) == 0 {
+			continue
+		}
+		toks = append(toks, xml.CopyToken(t))
+	}
+	if len(open) > 0 {
+		return nil, fmt.Errorf("<%s> is never closed", open[len(open)-1])
+	}
+
+	var lines []synLine
+	depth := 0
+	for i := 0; i < len(toks); i++ {
+		switch t := toks[i].(type) {
+		case xml.StartElement:
+			spans := xmlStartSpans(t)
+			// <a/> and <a>text</a> fit on one line.
+			if i+1 < len(toks) {
+				if _, ok := toks[i+1].(xml.EndElement); ok {
+					spans = append(spans, synSpan{synPunct, "/>"})
+					lines = append(lines, synLine{depth, spans})
+					i++
+					continue
+				}
+			}
+			if i+2 < len(toks) {
+				cd, isText := toks[i+1].(xml.CharData)
+				end, isEnd := toks[i+2].(xml.EndElement)
+				if isText && isEnd && !bytes.Contains(bytes.TrimSpace(cd), []byte("\n")) {
+					spans = append(spans, synSpan{synPunct, ">"},
+						synSpan{synPlain, xmlEscape(strings.TrimSpace(string(cd)), false)})
+					spans = append(spans, xmlEndSpans(end)...)
+					lines = append(lines, synLine{depth, spans})
+					i += 2
+					continue
+				}
+			}
+			spans = append(spans, synSpan{synPunct, ">"})
+			lines = append(lines, synLine{depth, spans})
+			depth++
+		case xml.EndElement:
+			depth = max(0, depth-1)
+			lines = append(lines, synLine{depth, xmlEndSpans(t)})
+		case xml.CharData:
+			for _, l := range strings.Split(strings.TrimSpace(string(t)), "\n") {
+				lines = append(lines, synLine{depth, []synSpan{{synPlain, xmlEscape(strings.TrimSpace(l), false)}}})
+			}
+		case xml.Comment:
+			lines = append(lines, commentLines(depth, "<!--"+string(t)+"-->")...)
+		case xml.ProcInst:
+			lines = append(lines, synLine{depth, []synSpan{
+				{synPunct, "<?"}, {synKey, t.Target}, {synPlain, " " + string(t.Inst)}, {synPunct, "?>"},
+			}})
+		case xml.Directive:
+			lines = append(lines, synLine{depth, []synSpan{{synPunct, "<!" + string(t) + ">"}}})
+		}
+	}
+	return lines, nil
+}
+
+func xmlName(n xml.Name) string {
+	if n.Space != "" {
+		return n.Space + ":" + n.Local
+	}
+	return n.Local
+}
+
+func xmlStartSpans(t xml.StartElement) []synSpan {
+	spans := []synSpan{{synPunct, "<"}, {synKey, xmlName(t.Name)}}
+	for _, a := range t.Attr {
+		spans = append(spans,
+			synSpan{synPlain, " "},
+			synSpan{synAttr, xmlName(a.Name)},
+			synSpan{synPunct, "="},
+			synSpan{synString, `"` + xmlEscape(a.Value, true) + `"`})
+	}
+	return spans
+}
+
+func xmlEndSpans(t xml.EndElement) []synSpan {
+	return []synSpan{{synPunct, "</"}, {synKey, xmlName(t.Name)}, {synPunct, ">"}}
+}
+
+// xmlEscape re-escapes text decoded by RawToken so the output is valid markup.
+func xmlEscape(s string, attr bool) string {
+	r := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	if attr {
+		r = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;")
+	}
+	return r.Replace(s)
+}
+
+func commentLines(depth int, text string) []synLine {
+	var lines []synLine
+	for _, l := range strings.Split(text, "\n") {
+		lines = append(lines, synLine{depth, []synSpan{{synComment, strings.TrimSpace(l)}}})
+	}
+	return lines
+}
+
+// HTML elements that never have content, and the elements whose content is
+// kept verbatim rather than re-flowed.
+var (
+	htmlVoid = map[string]bool{
+		"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true,
+		"img": true, "input": true, "link": true, "meta": true, "source": true,
+		"track": true, "wbr": true,
+	}
+	htmlVerbatim = map[string]bool{"script": true, "style": true, "pre": true, "textarea": true}
+	// elements whose open tag closes a still-open sibling of the same name
+	htmlSelfNesting = map[string]bool{"p": true, "li": true, "dt": true, "dd": true, "tr": true, "td": true, "th": true, "option": true}
+)
+
+// formatHTMLBody re-indents an HTML document. HTML does not need to be well
+// formed, so a stack of open elements decides the depth: an end tag closes
+// back to its matching open tag, and tags such as <li> and <p> close a
+// still-open sibling.
+func formatHTMLBody(body []byte) []synLine {
+	z := html.NewTokenizer(bytes.NewReader(body))
+	var toks []html.Token
+	for {
+		if z.Next() == html.ErrorToken {
+			break
+		}
+		toks = append(toks, z.Token())
+	}
+
+	var lines []synLine
+	var stack []string
+	verbatim := ""
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		depth := len(stack)
+		switch t.Type {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if htmlSelfNesting[t.Data] && len(stack) > 0 && stack[len(stack)-1] == t.Data {
+				stack = stack[:len(stack)-1]
+				depth--
+			}
+			spans := htmlTagSpans(t)
+			if t.Type == html.SelfClosingTagToken || htmlVoid[t.Data] {
+				lines = append(lines, synLine{depth, spans})
+				continue
+			}
+			// <a>text</a> stays on one line.
+			if i+2 < len(toks) && toks[i+1].Type == html.TextToken && toks[i+2].Type == html.EndTagToken &&
+				toks[i+2].Data == t.Data && !strings.Contains(strings.TrimSpace(toks[i+1].Data), "\n") {
+				spans = append(spans, synSpan{synPlain, html.EscapeString(strings.TrimSpace(toks[i+1].Data))})
+				spans = append(spans, htmlEndSpans(t.Data)...)
+				lines = append(lines, synLine{depth, spans})
+				i += 2
+				continue
+			}
+			lines = append(lines, synLine{depth, spans})
+			stack = append(stack, t.Data)
+			if htmlVerbatim[t.Data] {
+				verbatim = t.Data
+			}
+		case html.EndTagToken:
+			for j := len(stack) - 1; j >= 0; j-- {
+				if stack[j] == t.Data {
+					stack = stack[:j]
+					break
+				}
+			}
+			if t.Data == verbatim {
+				verbatim = ""
+			}
+			lines = append(lines, synLine{len(stack), htmlEndSpans(t.Data)})
+		case html.TextToken:
+			if verbatim != "" {
+				for _, l := range dedent(t.Data) {
+					lines = append(lines, synLine{depth, []synSpan{{synPlain, l}}})
+				}
+				continue
+			}
+			text := strings.Join(strings.Fields(t.Data), " ")
+			if text != "" {
+				lines = append(lines, synLine{depth, []synSpan{{synPlain, html.EscapeString(text)}}})
+			}
+		case html.CommentToken:
+			lines = append(lines, commentLines(depth, "<!--"+t.Data+"-->")...)
+		case html.DoctypeToken:
+			lines = append(lines, synLine{depth, []synSpan{{synPunct, "<!DOCTYPE " + t.Data + ">"}}})
+		}
+	}
+	return lines
+}
+
+func htmlTagSpans(t html.Token) []synSpan {
+	spans := []synSpan{{synPunct, "<"}, {synKey, t.Data}}
+	for _, a := range t.Attr {
+		name := a.Key
+		if a.Namespace != "" {
+			name = a.Namespace + ":" + a.Key
+		}
+		spans = append(spans, synSpan{synPlain, " "}, synSpan{synAttr, name})
+		if a.Val != "" {
+			spans = append(spans, synSpan{synPunct, "="}, synSpan{synString, `"` + html.EscapeString(a.Val) + `"`})
+		}
+	}
+	if t.Type == html.SelfClosingTagToken {
+		return append(spans, synSpan{synPunct, "/>"})
+	}
+	return append(spans, synSpan{synPunct, ">"})
+}
+
+func htmlEndSpans(name string) []synSpan {
+	return []synSpan{{synPunct, "</"}, {synKey, name}, {synPunct, ">"}}
+}
+
+// dedent splits s into lines, dropping blank lines at either end and the
+// indentation common to the rest.
+func dedent(s string) []string {
+	lines := strings.Split(strings.Trim(s, "\n"), "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	common := -1
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		n := len(l) - len(strings.TrimLeft(l, " \t"))
+		if common < 0 || n < common {
+			common = n
+		}
+	}
+	for i, l := range lines {
+		if len(l) >= common && common > 0 {
+			lines[i] = l[common:]
+		}
+		lines[i] = strings.TrimRight(lines[i], " \t\r")
+	}
+	return lines
+}
+
+// Elements that start a new line in the text rendering, and elements whose
+// content is not readable text.
+var (
+	htmlBlock = map[string]bool{
+		"address": true, "article": true, "aside": true, "blockquote": true, "br": true,
+		"dd": true, "div": true, "dl": true, "dt": true, "fieldset": true, "figcaption": true,
+		"figure": true, "footer": true, "form": true, "h1": true, "h2": true, "h3": true,
+		"h4": true, "h5": true, "h6": true, "header": true, "hr": true, "li": true,
+		"main": true, "nav": true, "ol": true, "p": true, "pre": true, "section": true,
+		"table": true, "tr": true, "ul": true, "title": true,
+	}
+	htmlHidden = map[string]bool{"script": true, "style": true, "noscript": true, "template": true, "head": true}
+	// block elements followed by a blank line
+	htmlParagraph = map[string]bool{
+		"blockquote": true, "dl": true, "figure": true, "form": true, "h1": true, "h2": true,
+		"h3": true, "h4": true, "h5": true, "h6": true, "hr": true, "ol": true, "p": true,
+		"pre": true, "table": true, "title": true, "ul": true,
+	}
+)
+
+// htmlText reduces an HTML document to its readable text: scripts, styles
+// and the head (except the title) are dropped, block elements break lines,
+// list items get bullets and headings are highlighted.
+func htmlText(body []byte) []synLine {
+	z := html.NewTokenizer(bytes.NewReader(body))
+	var lines []synLine
+	var cur []synSpan
+	hidden, pre := 0, 0
+	heading, title := false, false
+
+	flush := func(paragraph bool) {
+		if len(cur) > 0 {
+			lines = append(lines, synLine{spans: cur})
+			cur = nil
+		}
+		if paragraph && len(lines) > 0 && len(lines[len(lines)-1].spans) > 0 {
+			lines = append(lines, synLine{}) // one blank line between paragraphs
+		}
+	}
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		t := z.Token()
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			start := tt != html.EndTagToken
+			switch {
+			case t.Data == "title":
+				title = start
+			case htmlHidden[t.Data] && tt != html.SelfClosingTagToken:
+				if start {
+					hidden++
+				} else if hidden > 0 {
+					hidden--
+				}
+			case t.Data == "pre":
+				if start {
+					pre++
+				} else if pre > 0 {
+					pre--
+				}
+			case len(t.Data) == 2 && t.Data[0] == 'h' && t.Data[1] >= '1' && t.Data[1] <= '6':
+				heading = start
+			}
+			if htmlBlock[t.Data] {
+				flush(htmlParagraph[t.Data])
+				if start && t.Data == "li" {
+					cur = append(cur, synSpan{synPunct, "• "})
+				}
+			}
+		case html.TextToken:
+			if hidden > 0 && !title {
+				continue
+			}
+			kind := synPlain
+			if heading || title {
+				kind = synHeading
+			}
+			if pre > 0 {
+				for i, l := range strings.Split(t.Data, "\n") {
+					if i > 0 {
+						lines = append(lines, synLine{spans: cur})
+						cur = nil
+					}
+					cur = append(cur, synSpan{kind, strings.TrimRight(l, "\r")})
+				}
+				continue
+			}
+			text := strings.Join(strings.Fields(t.Data), " ")
+			if text == "" {
+				continue
+			}
+			// Keep the space between inline runs that the source had.
+			if len(cur) > 0 && t.Data[0] <= ' ' {
+				text = " " + text
+			}
+			if strings.TrimRight(t.Data, " \t\r\n") != t.Data {
+				text += " "
+			}
+			cur = append(cur, synSpan{kind, text})
+		}
+	}
+	flush(false)
+	for len(lines) > 0 && len(lines[len(lines)-1].spans) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
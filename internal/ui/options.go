@@ -0,0 +1,205 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sendOptions control timeouts, redirects and retries for one send.
+type sendOptions struct {
+	connectTimeout  time.Duration // TCP / socket connect; 0 = no limit
+	tlsTimeout      time.Duration // TLS handshake; 0 = no limit
+	totalTimeout    time.Duration // whole exchange per attempt; 0 = no limit
+	followRedirects bool
+	maxRedirects    int
+	retries         int   // extra attempts after the first
+	retryOn         []int // status codes that trigger a retry
+	retryBackoff    time.Duration
+}
+
+const (
+	maxRetries      = 10
+	maxRetryBackoff = time.Minute // the doubled delay stops growing here
+)
+
+var defaultSendOptions = sendOptions{
+	connectTimeout:  10 * time.Second,
+	tlsTimeout:      10 * time.Second,
+	totalTimeout:    60 * time.Second,
+	followRedirects: true,
+	maxRedirects:    10,
+	retryOn:         []int{429, 502, 503, 504},
+	retryBackoff:    500 * time.Millisecond,
+}
+
+// optionSetters maps each :set key to the function that applies its value.
+// Folders and requests store raw strings so an override can be removed again.
+var optionSetters = map[string]func(o *sendOptions, v string) error{
+	"connect-timeout": func(o *sendOptions, v string) error { return parseDuration(v, &o.connectTimeout) },
+	"tls-timeout":     func(o *sendOptions, v string) error { return parseDuration(v, &o.tlsTimeout) },
+	"timeout":         func(o *sendOptions, v string) error { return parseDuration(v, &o.totalTimeout) },
+	"follow-redirects": func(o *sendOptions, v string) error {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("follow-redirects: expected true or false")
+		}
+		o.followRedirects = b
+		return nil
+	},
+	"max-redirects": func(o *sendOptions, v string) error { return parseCount(v, &o.maxRedirects) },
+	"retries": func(o *sendOptions, v string) error {
+		if err := parseCount(v, &o.retries); err != nil {
+			return err
+		}
+		if o.retries > maxRetries {
+			return fmt.Errorf("retries: at most %d", maxRetries)
+		}
+		return nil
+	},
+	"retry-on": func(o *sendOptions, v string) error {
+		var codes []int
+		for _, s := range strings.Split(v, ",") {
+			c, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil || c < 100 || c > 599 {
+				return fmt.Errorf("retry-on: %q is not a status code", s)
+			}
+			codes = append(codes, c)
+		}
+		o.retryOn = codes
+		return nil
+	},
+	"retry-backoff": func(o *sendOptions, v string) error { return parseDuration(v, &o.retryBackoff) },
+}
+
+func parseDuration(v string, dst *time.Duration) error {
+	if v == "0" || v == "off" {
+		*dst = 0
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return fmt.Errorf("%q is not a duration (e.g. 5s, 250ms, off)", v)
+	}
+	*dst = d
+	return nil
+}
+
+func parseCount(v string, dst *int) error {
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return fmt.Errorf("%q is not a non-negative number", v)
+	}
+	*dst = n
+	return nil
+}
+
+// resolveOptions layers the folder and then the request overrides on the defaults.
+func resolveOptions(f folder, r request) (sendOptions, error) {
+	o := defaultSendOptions
+	for _, layer := range []map[string]string{f.options, r.options} {
+		for _, k := range sortedKeys(layer) {
+			if err := optionSetters[k](&o, layer[k]); err != nil {
+				return o, err
+			}
+		}
+	}
+	return o, nil
+}
+
+func (o sendOptions) retryable(status int) bool {
+	for _, c := range o.retryOn {
+		if c == status {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay before retry attempt n (1-based): base·2^(n-1),
+// at most maxRetryBackoff unless the base alone is longer.
+func (o sendOptions) backoff(n int) time.Duration {
+	limit := max(o.retryBackoff, maxRetryBackoff)
+	d := o.retryBackoff
+	for i := 1; i < n && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// execSet handles ":set [folder|request] <key> <value>" and ":unset [folder|request] <key>".
+// Without a scope the override applies to the request. ":set" alone lists the
+// overrides in effect for the current request.
+func (m Model) execSet(args []string, unset bool) Model {
+	if m.activeFolderIdx < 0 {
+		m.cmdError = "no request selected"
+		return m
+	}
+	f := &m.folders[m.activeFolderIdx]
+	r := &f.requests[m.activeReqIdx]
+
+	if len(args) == 0 && !unset {
+		var s []string
+		for _, k := range sortedKeys(f.options) {
+			s = append(s, "folder "+k+"="+f.options[k])
+		}
+		for _, k := range sortedKeys(r.options) {
+			s = append(s, k+"="+r.options[k])
+		}
+		if len(s) == 0 {
+			m.cmdInfo = "defaults in effect"
+		} else {
+			m.cmdInfo = strings.Join(s, " · ")
+		}
+		return m
+	}
+
+	target := &r.options
+	if len(args) > 0 && (args[0] == "folder" || args[0] == "request") {
+		if args[0] == "folder" {
+			target = &f.options
+		}
+		args = args[1:]
+	}
+
+	want := 2
+	usage := "usage: set [folder|request] <key> <value>"
+	if unset {
+		want, usage = 1, "usage: unset [folder|request] <key>"
+	}
+	if len(args) != want {
+		m.cmdError = usage
+		return m
+	}
+	setter, ok := optionSetters[args[0]]
+	if !ok {
+		m.cmdError = "unknown setting: " + args[0]
+		return m
+	}
+
+	if unset {
+		delete(*target, args[0])
+		return m.closeCmdPalette()
+	}
+	probe := defaultSendOptions
+	if err := setter(&probe, args[1]); err != nil {
+		m.cmdError = err.Error()
+		return m
+	}
+	if *target == nil {
+		*target = map[string]string{}
+	}
+	(*target)[args[0]] = args[1]
+	return m.closeCmdPalette()
+}
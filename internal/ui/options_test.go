@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBackoffStopsGrowing(t *testing.T) {
+	o := sendOptions{retryBackoff: 500 * time.Millisecond}
+	tests := []struct {
+		n    int
+		want time.Duration
+	}{
+		{1, 500 * time.Millisecond},
+		{2, time.Second},
+		{4, 4 * time.Second},
+		{8, maxRetryBackoff},
+		{70, maxRetryBackoff}, // a shift this far would overflow
+	}
+	for _, tt := range tests {
+		if got := o.backoff(tt.n); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+	o.retryBackoff = 5 * time.Minute
+	if got := o.backoff(3); got != o.retryBackoff {
+		t.Errorf("a base over the cap: %v, want %v", got, o.retryBackoff)
+	}
+}
+
+func TestRetriesAreBounded(t *testing.T) {
+	var o sendOptions
+	if err := optionSetters["retries"](&o, "10"); err != nil || o.retries != 10 {
+		t.Errorf("retries 10: %v, %d", err, o.retries)
+	}
+	for _, v := range []string{"11", "1000000", "-1"} {
+		if err := optionSetters["retries"](&o, v); err == nil {
+			t.Errorf("retries %s was accepted", v)
+		}
+	}
+}
+
+func TestTooManyRedirectsKeepsTheHops(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	}))
+	defer srv.Close()
+	opts := defaultSendOptions
+	opts.maxRedirects = 2
+	resp := execute(context.Background(), folder{}, request{method: "GET", url: srv.URL},
+		environment{}, newCookieJar(), transportSettings{}, opts)
+	if resp.err == nil || !strings.Contains(resp.err.Error(), "stopped after 2 redirects") {
+		t.Fatalf("err %v", resp.err)
+	}
+	if len(resp.redirects) != 3 {
+		t.Fatalf("%d hops, want the 3 redirects received", len(resp.redirects))
+	}
+
+	m := New()
+	m.resp = &resp
+	m.width, m.height = 120, 40
+	if out := m.renderResponse(100, 30); !strings.Contains(out, "Redirects") {
+		t.Errorf("the error view does not show the hops:\n%s", out)
+	}
+}
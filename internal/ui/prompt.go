@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// promptState asks the {{$prompt}} questions of a send or run, one at a
+// time, and then carries it out with the answers.
+type promptState struct {
+	title   string // "Before sending" when empty
+	action  string // what enter does after the last answer; "send" when empty
+	masked  bool   // answers are secrets: shown as bullets
+	labels  []string
+	answers map[string]string
+	idx     int // question being answered
+	input   string
+	then    func(Model) (Model, tea.Cmd)
+}
+
+// askPrompts opens the prompt overlay for labels, or does then straight
+// away when there is nothing to ask.
+func (m Model) askPrompts(labels []string, then func(Model) (Model, tea.Cmd)) (Model, tea.Cmd) {
+	if len(labels) == 0 {
+		return then(m)
+	}
+	m.prompt = &promptState{labels: labels, answers: map[string]string{}, then: then}
+	return m, nil
+}
+
+// startSend sends the active request, asking for the vault passphrase and
+// its prompts first.
+func (m Model) startSend() (Model, tea.Cmd) {
+	r := m.folders[m.activeFolderIdx].requests[m.activeReqIdx]
+	if requestSecrets(r) && m.vault.locked() {
+		return m.askPassphrase(Model.startSend)
+	}
+	return m.askPrompts(requestPrompts(r), func(m Model) (Model, tea.Cmd) {
+		ctx, cancel := context.WithCancel(context.Background())
+		m.sending = true
+		m.cancelSend = cancel
+		return m, m.sendCmd(ctx)
+	})
+}
+
+// folderPrompts lists the prompts of every request of folder fi.
+func (m Model) folderPrompts(fi int) []string {
+	var labels []string
+	for _, r := range m.folders[fi].requests {
+		for _, l := range requestPrompts(r) {
+			if !slices.Contains(labels, l) {
+				labels = append(labels, l)
+			}
+		}
+	}
+	return labels
+}
+
+func (m Model) updatePrompt(msg tea.KeyMsg) (Model, tea.Cmd) {
+	p := *m.prompt
+	switch msg.String() {
+	case "esc":
+		m.prompt = nil
+		m.notice = "cancelled"
+		return m, nil
+	case "enter":
+		p.answers = maps.Clone(p.answers)
+		p.answers[p.labels[p.idx]] = p.input
+		p.input = ""
+		p.idx++
+		if p.idx == len(p.labels) {
+			m.prompt = nil
+			// The answers hold for this send only.
+			m.promptAnswers = p.answers
+			next, cmd := p.then(m)
+			next.promptAnswers = nil
+			return next, cmd
+		}
+	case "ctrl+u":
+		p.input = ""
+	case "backspace":
+		runes := []rune(p.input)
+		if len(runes) > 0 {
+			p.input = string(runes[:len(runes)-1])
+		}
+	default:
+		if len([]rune(msg.String())) == 1 {
+			p.input += msg.String()
+		}
+	}
+	m.prompt = &p
+	return m, nil
+}
+
+// renderPrompt renders the {{$prompt}} overlay.
+func (m Model) renderPrompt() string {
+	p := m.prompt
+	innerW := min(70, max(40, m.width-10))
+	dim := m.theme.dim()
+	title, action := cmp.Or(p.title, "Before sending"), cmp.Or(p.action, "send")
+	header := m.theme.highlight().Bold(true).Render(" " + title)
+	if len(p.labels) > 1 {
+		header += dim.Render(fmt.Sprintf(" · %d of %d", p.idx+1, len(p.labels)))
+	}
+	shown := func(s string) string {
+		if p.masked {
+			return strings.Repeat("●", utf8.RuneCountInString(s))
+		}
+		return s
+	}
+	var lines []string
+	for _, l := range p.labels[:p.idx] {
+		lines = append(lines, dim.Render(" "+l+": ")+m.theme.textMuted().Render(shown(p.answers[l])))
+	}
+	lines = append(lines, m.theme.text().Bold(true).Render(" "+p.labels[p.idx]+": ")+
+		m.theme.text().Render(shown(p.input))+m.theme.accent().Render("█"))
+	if p.idx < len(p.labels)-1 {
+		action = "next"
+	}
+	hints := " " + m.theme.keyHint("enter") + dim.Render(action) +
+		"  " + m.theme.keyHint("esc") + dim.Render("cancel")
+	for i, l := range lines {
+		lines[i] = lipgloss.NewStyle().MaxWidth(innerW - 2).Render(l)
+	}
+	content := strings.Join(append(append([]string{header, ""}, lines...), "", hints), "\n")
+	return m.theme.overlayStyle().
+		Padding(0, 1).
+		Width(innerW).
+		Render(content)
+}
+
+// readPrompts asks labels on out and reads one answer per line from in, for
+// the headless commands.
+func readPrompts(labels []string, in io.Reader, out io.Writer) (map[string]string, error) {
+	answers := map[string]string{}
+	sc := bufio.NewScanner(in)
+	for _, l := range labels {
+		fmt.Fprintf(out, "%s: ", l)
+		if !sc.Scan() {
+			if err := sc.Err(); err != nil {
+				return nil, err
+			}
+			return nil, fmt.Errorf("no answer for {{$prompt %q}}", l)
+		}
+		answers[l] = strings.TrimRight(sc.Text(), "\r")
+	}
+	return answers, nil
+}
@@ -0,0 +1,329 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// redactedText replaces a sensitive value in history, exports and logs;
+// redactedMask does on screen.
+const (
+	redactedText = "[redacted]"
+	redactedMask = "••••••••"
+)
+
+// Kinds of entries in a redactionList.
+const (
+	redactHeader = "header"
+	redactKey    = "key"
+	redactVar    = "var"
+)
+
+// redactionList names what is sensitive, as case-insensitive glob patterns:
+// header names, JSON keys (also matched against query parameters) and
+// variable names. It is a value; edits replace the slices.
+type redactionList struct {
+	headers []string
+	keys    []string
+	vars    []string
+}
+
+var defaultRedactions = redactionList{
+	headers: []string{"authorization", "proxy-authorization", "cookie", "set-cookie", "x-api-key", "x-auth-token", "*-token"},
+	keys:    []string{"password", "passwd", "secret", "client_secret", "token", "*_token", "api_key", "apikey", "private_key"},
+	vars:    []string{"*token*", "*secret*", "*password*", "*apikey*", "*api_key*"},
+}
+
+func matchAny(patterns []string, name string) bool {
+	name = strings.ToLower(name)
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (l redactionList) header(name string) bool   { return matchAny(l.headers, name) }
+func (l redactionList) key(name string) bool      { return matchAny(l.keys, name) }
+func (l redactionList) variable(name string) bool { return matchAny(l.vars, name) }
+
+// list points at the patterns of kind, or is nil for an unknown kind.
+func (l *redactionList) list(kind string) *[]string {
+	switch kind {
+	case redactHeader:
+		return &l.headers
+	case redactKey:
+		return &l.keys
+	case redactVar:
+		return &l.vars
+	}
+	return nil
+}
+
+// redactHeaders returns a copy of h with the values of sensitive headers
+// replaced.
+func (l redactionList) redactHeaders(h http.Header) http.Header {
+	if h == nil {
+		return nil
+	}
+	out := h.Clone()
+	for k, vs := range out {
+		if l.header(k) {
+			out[k] = slices.Repeat([]string{redactedText}, len(vs))
+		}
+	}
+	return out
+}
+
+// redactJSON replaces the values of sensitive keys in a JSON body, keeping
+// the rest byte for byte. Other bodies are returned as they are.
+func (l redactionList) redactJSON(body []byte) []byte {
+	if len(l.keys) == 0 || !json.Valid(body) {
+		return body
+	}
+	p := &jsonParser{src: body}
+	var out bytes.Buffer
+	last := 0
+	var walk func()
+	walk = func() {
+		switch c := p.peek(); c {
+		case '{', '[':
+			closer := byte('}')
+			if c == '[' {
+				closer = ']'
+			}
+			p.pos++
+			for p.peek() != closer && p.peek() != 0 {
+				if c == '{' {
+					var key string
+					json.Unmarshal([]byte(p.str()), &key)
+					p.peek()
+					p.pos++ // colon
+					if l.key(key) {
+						p.peek()
+						start := p.pos
+						p.value(&jsonDoc{}, 0, "")
+						out.Write(body[last:start])
+						out.WriteString(strconv.Quote(redactedText))
+						last = p.pos
+					} else {
+						walk()
+					}
+				} else {
+					walk()
+				}
+				if p.peek() == ',' {
+					p.pos++
+				}
+			}
+			p.pos++
+		case '"':
+			p.str()
+		default:
+			p.literal()
+		}
+	}
+	for p.peek() != 0 {
+		walk()
+	}
+	if last == 0 {
+		return body
+	}
+	out.Write(body[last:])
+	return out.Bytes()
+}
+
+// redactURL replaces the values of sensitive query parameters of u.
+func (l redactionList) redactURL(u string) string {
+	base, query, ok := strings.Cut(u, "?")
+	if !ok {
+		return u
+	}
+	parts := strings.Split(query, "&")
+	for i, p := range parts {
+		k, _, _ := strings.Cut(p, "=")
+		if name, err := url.QueryUnescape(k); err == nil && l.key(name) {
+			parts[i] = k + "=" + url.QueryEscape(redactedText)
+		}
+	}
+	return base + "?" + strings.Join(parts, "&")
+}
+
+// redactResponse returns a copy of resp fit for history and exports: the
+// sensitive headers, JSON keys and query parameters of the request and the
+// response are replaced.
+func (l redactionList) redactResponse(resp response) response {
+	resp.header = l.redactHeaders(resp.header)
+	resp.body = l.redactJSON(resp.body)
+	resp.url = l.redactURL(resp.url)
+	if resp.request != nil {
+		req := resp.request.Clone(resp.request.Context())
+		req.Header = l.redactHeaders(req.Header)
+		if u, err := url.Parse(l.redactURL(req.URL.String())); err == nil {
+			req.URL = u
+		}
+		if req.GetBody != nil {
+			if rc, err := req.GetBody(); err == nil {
+				body, err := io.ReadAll(rc)
+				rc.Close()
+				if err == nil {
+					body = l.redactJSON(body)
+					req.ContentLength = int64(len(body))
+					req.GetBody = func() (io.ReadCloser, error) {
+						return io.NopCloser(bytes.NewReader(body)), nil
+					}
+				}
+			}
+		}
+		resp.request = req
+	}
+	return resp
+}
+
+// presentURL hides the values of the sensitive query parameters of u in
+// presentation mode.
+func (m Model) presentURL(u string) string {
+	if !m.presenting {
+		return u
+	}
+	return m.redactions.redactURL(u)
+}
+
+// presentBody hides the values of the sensitive keys of a JSON body, or of
+// a JSON payload or log line, in presentation mode.
+func (m Model) presentBody(s string) string {
+	if !m.presenting {
+		return s
+	}
+	return string(m.redactions.redactJSON([]byte(s)))
+}
+
+// sensitiveValues lists the values of the sensitive variables of env.
+func (l redactionList) sensitiveValues(env environment) []string {
+	var out []string
+	for _, v := range env.vars {
+		if l.variable(v.key) && len(v.value) >= minMaskedLen && !varPattern.MatchString(v.value) {
+			out = append(out, v.value)
+		}
+	}
+	return out
+}
+
+// snapshot keeps resp for history, examples and diffs, redacted.
+func (m Model) snapshot(resp response) snapshot {
+	s := snapshotOf(m.redactions.redactResponse(resp))
+	s.body = []byte(m.maskValues(string(s.body)))
+	for k, vs := range s.header {
+		for i, v := range vs {
+			vs[i] = m.maskValues(v)
+		}
+		s.header[k] = vs
+	}
+	return s
+}
+
+// maskValues replaces the secrets and the values of sensitive variables in
+// s, keeping their width. Logs and exports go through it, and so does the
+// screen in presentation mode.
+func (m Model) maskValues(s string) string {
+	s = m.vault.mask(s)
+	values := m.redactions.sensitiveValues(m.env())
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	for _, v := range values {
+		s = strings.ReplaceAll(s, v, strings.Repeat("•", utf8.RuneCountInString(v)))
+	}
+	return s
+}
+
+// redactPairs hides the values of the pairs whose key sensitive matches,
+// for the key/value tables. Values that are only {{references}} are kept.
+func (m Model) redactPairs(pairs [][2]string, sensitive func(string) bool) [][2]string {
+	if !m.presenting {
+		return pairs
+	}
+	out := make([][2]string, len(pairs))
+	for i, p := range pairs {
+		out[i] = p
+		if sensitive(p[0]) && p[1] != "" && !isReference(p[1]) {
+			out[i][1] = redactedMask
+		}
+	}
+	return out
+}
+
+// isReference reports whether s holds nothing but {{templates}} and
+// whitespace, such as "{{$secret token}}".
+func isReference(s string) bool {
+	return strings.TrimSpace(varPattern.ReplaceAllString(s, "")) == ""
+}
+
+// execRedact handles ":redact [header|key|var <pattern>]", adding a pattern
+// to the redaction list; alone, it lists the patterns.
+func (m Model) execRedact(args []string, add bool) Model {
+	usage := "usage: redact [header|key|var <pattern>]"
+	if !add {
+		usage = "usage: unredact header|key|var <pattern>"
+	}
+	if len(args) == 0 && add {
+		m.cmdInfo = "headers: " + strings.Join(m.redactions.headers, " ") +
+			" · keys: " + strings.Join(m.redactions.keys, " ") +
+			" · vars: " + strings.Join(m.redactions.vars, " ")
+		return m
+	}
+	l := m.redactions
+	if len(args) != 2 || l.list(args[0]) == nil {
+		m.cmdError = usage
+		return m
+	}
+	pattern := strings.ToLower(args[1])
+	if _, err := path.Match(pattern, ""); err != nil {
+		m.cmdError = "bad pattern: " + err.Error()
+		return m
+	}
+	list := l.list(args[0])
+	i := slices.Index(*list, pattern)
+	switch {
+	case add && i >= 0:
+		m.cmdInfo = args[0] + " " + pattern + " is already redacted"
+		return m
+	case add:
+		*list = append(slices.Clip(*list), pattern)
+		m.cmdInfo = "redacting " + args[0] + " " + pattern
+	case i < 0:
+		m.cmdError = "no " + args[0] + " pattern " + strconv.Quote(pattern)
+		return m
+	default:
+		*list = slices.Delete(slices.Clone(*list), i, i+1)
+		m.cmdInfo = "no longer redacting " + args[0] + " " + pattern
+	}
+	m.redactions = l
+	return m
+}
+
+// execPresent handles ":present [on|off]", toggling presentation mode.
+func (m Model) execPresent(args []string) Model {
+	switch {
+	case len(args) == 0:
+		m.presenting = !m.presenting
+	case len(args) == 1 && (args[0] == "on" || args[0] == "off"):
+		m.presenting = args[0] == "on"
+	default:
+		m.cmdError = "usage: present [on|off]"
+		return m
+	}
+	m.notice = "presentation mode off"
+	if m.presenting {
+		m.notice = "presentation mode on · sensitive values are hidden"
+	}
+	return m.closeCmdPalette()
+}
@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactJSON(t *testing.T) {
+	l := redactionList{keys: []string{"password", "*_token", "a/b"}}
+	tests := []struct {
+		name, body, want string
+	}{
+		{"plain key", `{"user": "ann", "password": "hunter2"}`, `{"user": "ann", "password": "[redacted]"}`},
+		{"glob and case", `{"Refresh_Token":{"v":1}}`, `{"Refresh_Token":"[redacted]"}`},
+		{"escaped slash", `{"a\/b": "x", "c\/d": "y"}`, `{"a\/b": "[redacted]", "c\/d": "y"}`},
+		{"unicode escape", `{"pass\u0077ord": "x"}`, `{"pass\u0077ord": "[redacted]"}`},
+		{"nested in arrays", `[{"id": 1, "password": null}, [{"password": [1, 2]}]]`, `[{"id": 1, "password": "[redacted]"}, [{"password": "[redacted]"}]]`},
+		{"only a value", `{"note": "password"}`, `{"note": "password"}`},
+		{"not JSON", `password=hunter2`, `password=hunter2`},
+	}
+	for _, tt := range tests {
+		if got := string(l.redactJSON([]byte(tt.body))); got != tt.want {
+			t.Errorf("%s: %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPresentationHidesRequestValues(t *testing.T) {
+	m := New()
+	r := request{
+		method: "POST",
+		name:   "login",
+		url:    "https://api.test/login?api_key=k3y-value&page=2",
+		body:   `{"user": "ann", "password": "hunter2"}`,
+	}
+	m.folders = []folder{{name: "F", requests: []request{r}}}
+	m.activeFolderIdx, m.activeReqIdx = 0, 0
+	m.urlInput = r.url
+	m.requestTab = 3
+	m.width, m.height = 160, 40
+	resp := response{console: []consoleLine{{phase: "post", level: "log", text: `{"password": "hunter2"}`}}}
+
+	views := map[string]func(Model) string{
+		"request preview": func(m Model) string { return m.renderRequestPreview(r, 120) },
+		"url bar":         func(m Model) string { return m.renderURLBar(160) },
+		"url preview":     func(m Model) string { return m.renderURLPreview() },
+		"body":            func(m Model) string { return m.renderRequestTabContent(120, 20) },
+		"console":         func(m Model) string { return m.renderConsole(resp, 120) },
+		"websocket frame": func(m Model) string {
+			return m.renderWSFrame(wsFrame{dir: wsIn, kind: frameText, data: []byte(`{"password": "hunter2"}`)}, false, 120)
+		},
+		"event stream": func(m Model) string {
+			return strings.Join(m.renderSSEEvent(sseEvent{typ: "login", data: `{"password": "hunter2"}`}, false, 120), "\n")
+		},
+	}
+	for name, view := range views {
+		out := view(m)
+		if !strings.Contains(out, "hunter2") && !strings.Contains(out, "k3y-value") {
+			// The view must show a value for hiding it to mean anything.
+			t.Errorf("%s shows nothing to hide:\n%s", name, out)
+		}
+		m.presenting = true
+		out = view(m)
+		m.presenting = false
+		if strings.Contains(out, "hunter2") || strings.Contains(out, "k3y-value") {
+			t.Errorf("%s in presentation mode:\n%s", name, out)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package ui
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Report formats a finished run can be written in.
+const (
+	reportJUnit = "junit"
+	reportJSON  = "json"
+)
+
+// writeReport writes run in format to w, with sensitive values redacted.
+// Values are masked before they are encoded, since the escaped form of a
+// secret would no longer match it.
+func writeReport(w io.Writer, format string, run *collectionRun) error {
+	switch format {
+	case reportJUnit:
+		return writeJUnit(w, run)
+	case reportJSON:
+		return writeJSONReport(w, run)
+	}
+	return fmt.Errorf("unknown report format %q (junit or json)", format)
+}
+
+// saveReport writes run to the file at path.
+func saveReport(path, format string, run *collectionRun) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeReport(f, format, run); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitCase     `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut *junitText    `xml:"system-out,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+// junitText keeps line breaks readable, which plain character data would
+// encode as &#xA;.
+type junitText struct {
+	Text string `xml:",cdata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// writeJUnit writes run as JUnit XML: the folder is the test suite and each
+// request a test case. Failed assertions are failures, and a send that got
+// no response is an error.
+func writeJUnit(w io.Writer, run *collectionRun) error {
+	mask := run.mask
+	suite := junitSuite{
+		Name:      mask(run.folder),
+		Tests:     len(run.results),
+		Time:      junitSeconds(run.elapsed),
+		Timestamp: run.started.Format("2006-01-02T15:04:05"),
+	}
+	if run.env != "" {
+		suite.Properties = append(suite.Properties, junitProperty{"environment", mask(run.env)})
+	}
+	for _, r := range run.results {
+		c := junitCase{Name: mask(r.name), Classname: suite.Name}
+		switch r.state {
+		case runSkipped:
+			c.Skipped = &junitSkipped{Message: mask(run.stopped)}
+			suite.Skipped++
+		case runPassed, runFailed:
+			c.Time = junitSeconds(r.resp.duration)
+			if r.resp.err != nil {
+				msg := mask(r.resp.err.Error())
+				c.Error = &junitProblem{Message: msg, Type: "error", Text: msg}
+				suite.Errors++
+				break
+			}
+			c.SystemOut = &junitText{mask(r.method + " " + run.redactions.redactURL(r.resp.url) + "\n" + r.resp.status)}
+			var failed []string
+			for _, t := range r.resp.tests {
+				if !t.pass {
+					failed = append(failed, mask(t.spec+" · "+t.detail))
+				}
+			}
+			if len(failed) > 0 {
+				c.Failure = &junitProblem{
+					Message: fmt.Sprintf("%d of %d assertions failed", len(failed), len(r.resp.tests)),
+					Type:    "assertion",
+					Text:    strings.Join(failed, "\n"),
+				}
+				suite.Failures++
+			}
+		}
+		if c.Time == "" {
+			c.Time = junitSeconds(0)
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	doc := junitSuites{
+		Name:     "tuiman",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitSuite{suite},
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+type jsonReport struct {
+	Folder      string              `json:"folder"`
+	Environment string              `json:"environment,omitempty"`
+	Started     time.Time           `json:"started"`
+	DurationMS  float64             `json:"durationMs"`
+	Concurrency int                 `json:"concurrency"`
+	Total       int                 `json:"total"`
+	Passed      int                 `json:"passed"`
+	Failed      int                 `json:"failed"`
+	Skipped     int                 `json:"skipped"`
+	Stopped     string              `json:"stopped,omitempty"`
+	Requests    []jsonReportRequest `json:"requests"`
+}
+
+type jsonReportRequest struct {
+	Name        string             `json:"name"`
+	Method      string             `json:"method"`
+	Result      string             `json:"result"` // passed, failed or skipped
+	URL         string             `json:"url,omitempty"`
+	Status      string             `json:"status,omitempty"`
+	StatusCode  int                `json:"statusCode,omitempty"`
+	Proto       string             `json:"proto,omitempty"`
+	ContentType string             `json:"contentType,omitempty"`
+	Size        int                `json:"size"`
+	DurationMS  float64            `json:"durationMs"`
+	Attempts    int                `json:"attempts,omitempty"`
+	Redirects   int                `json:"redirects,omitempty"`
+	Timing      map[string]float64 `json:"timing,omitempty"`
+	Error       string             `json:"error,omitempty"`
+	Assertions  []jsonReportCheck  `json:"assertions"`
+}
+
+type jsonReportCheck struct {
+	Check  string `json:"check"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail"`
+}
+
+// writeJSONReport writes run with the timings and response metadata of every
+// request; bodies are left out.
+func writeJSONReport(w io.Writer, run *collectionRun) error {
+	mask := run.mask
+	rep := jsonReport{
+		Folder:      mask(run.folder),
+		Environment: mask(run.env),
+		Started:     run.started,
+		DurationMS:  millis(run.elapsed),
+		Concurrency: run.concurrency,
+		Total:       len(run.results),
+		Passed:      run.count(runPassed),
+		Failed:      run.count(runFailed),
+		Skipped:     run.count(runSkipped),
+		Stopped:     mask(run.stopped),
+		Requests:    []jsonReportRequest{},
+	}
+	for _, r := range run.results {
+		jr := jsonReportRequest{
+			Name:       mask(r.name),
+			Method:     r.method,
+			Result:     map[runState]string{runPassed: "passed", runFailed: "failed"}[r.state],
+			Assertions: []jsonReportCheck{},
+		}
+		if jr.Result == "" {
+			jr.Result = "skipped"
+			rep.Requests = append(rep.Requests, jr)
+			continue
+		}
+		resp := r.resp
+		jr.URL = mask(run.redactions.redactURL(resp.url))
+		jr.Status = mask(resp.status)
+		jr.StatusCode = resp.statusCode
+		jr.Proto = resp.proto
+		jr.ContentType = mask(resp.header.Get("Content-Type"))
+		jr.Size = len(resp.body)
+		jr.DurationMS = millis(resp.duration)
+		jr.Attempts = resp.attempts
+		jr.Redirects = len(resp.redirects)
+		jr.Timing = timingMillis(resp.timing)
+		if resp.err != nil {
+			jr.Error = mask(resp.err.Error())
+		}
+		for _, t := range resp.tests {
+			jr.Assertions = append(jr.Assertions, jsonReportCheck{mask(t.spec), t.pass, mask(t.detail)})
+		}
+		rep.Requests = append(rep.Requests, jr)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rep)
+}
+
+// timingMillis lists the phases of t that happened, in milliseconds.
+func timingMillis(t timing) map[string]float64 {
+	out := map[string]float64{}
+	for _, p := range []struct {
+		key        string
+		start, end time.Time
+	}{
+		{"dns", t.dnsStart, t.dnsDone},
+		{"connect", t.connectStart, t.connectEnd},
+		{"tls", t.tlsStart, t.tlsDone},
+		{"send", t.gotConn, t.wroteRequest},
+		{"wait", t.wroteRequest, t.firstByte},
+		{"transfer", t.firstByte, t.bodyDone},
+	} {
+		if !p.start.IsZero() && !p.end.IsZero() {
+			out[p.key] = millis(p.end.Sub(p.start))
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// execReport handles ":report junit|json <path>", writing the run on screen.
+func (m Model) execReport(args []string) Model {
+	if len(args) != 2 || (args[0] != reportJUnit && args[0] != reportJSON) {
+		m.cmdError = "usage: report junit|json <path>"
+		return m
+	}
+	if m.run == nil {
+		m.cmdError = "no run to report (:run a folder first)"
+		return m
+	}
+	if !m.run.done {
+		m.cmdError = "the run is still going"
+		return m
+	}
+	if err := saveReport(args[1], args[0], m.run); err != nil {
+		m.cmdError = err.Error()
+		return m
+	}
+	m.notice = "wrote " + args[0] + " report to " + args[1]
+	return m.closeCmdPalette()
+}
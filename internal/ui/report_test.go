@@ -0,0 +1,42 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReportMasksBeforeEncoding(t *testing.T) {
+	// Both encodings escape these characters, so masking the encoded report
+	// would miss the secret.
+	const secret = `p<ss&"w>rd`
+	run := &collectionRun{
+		folder: "F",
+		mask:   func(s string) string { return strings.ReplaceAll(s, secret, "••••") },
+		results: []runResult{
+			{name: "fails", method: "GET", state: runFailed, resp: response{
+				url:    "https://example.com/",
+				status: "200 OK",
+				tests:  []testResult{{spec: "body.token == " + secret, detail: secret}},
+			}},
+			{name: "errors", method: "GET", state: runFailed, resp: response{
+				err: errors.New("dial " + secret + ": refused"),
+			}},
+		},
+	}
+	for _, format := range []string{reportJUnit, reportJSON} {
+		var b strings.Builder
+		if err := writeReport(&b, format, run); err != nil {
+			t.Fatal(err)
+		}
+		out := b.String()
+		for _, leak := range []string{"p&lt;ss", `p\u003css`, "p<ss", "w&gt;rd", `w\u003erd`} {
+			if strings.Contains(out, leak) {
+				t.Errorf("%s report has %q:\n%s", format, leak, out)
+			}
+		}
+		if strings.Count(out, "••••") < 3 {
+			t.Errorf("%s report is not masked:\n%s", format, out)
+		}
+	}
+}
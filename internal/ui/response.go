@@ -0,0 +1,349 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Response pane tabs.
+const (
+	respTabBody = iota
+	respTabHeaders
+	respTabCookies
+	respTabTiming
+	respTabTests
+	respTabVars
+	respTabConsole
+	respTabCount
+)
+
+var respTabLabels = []string{"Body", "Headers", "Cookies", "Timing", "Tests", "Vars", "Console"}
+
+func (m Model) renderResponse(w, h int) string {
+	title := m.theme.paneTitle(" Response ", m.focused == 1)
+	if m.showingWS() {
+		return m.renderWSLog(w, h)
+	}
+	if isWebSocketURL(m.urlInput) {
+		return title + "\n" + m.theme.dim().Render("  Not connected — press s to connect")
+	}
+	if m.sending {
+		return title + "\n" + m.theme.accent().Render("  sending…  ") + m.theme.keyHint("x") + m.theme.dim().Render("cancel")
+	}
+	if m.resp == nil {
+		return title + "\n" + m.theme.dim().Render("  No response yet — press s to send")
+	}
+
+	resp := *m.resp
+	if resp.err != nil && resp.statusCode == 0 {
+		errText := resp.err.Error()
+		if errors.Is(resp.err, context.Canceled) {
+			errText = "request canceled"
+		}
+		msg := title + "\n" + m.theme.errStyle().PaddingLeft(2).Width(w).Render(errText)
+		if resp.proxy != "" {
+			msg += "\n" + m.theme.dim().Render("  via "+resp.proxy)
+		}
+		if len(resp.redirects) > 0 {
+			msg += "\n\n" + m.renderRedirects(resp.redirects, w)
+		}
+		if len(resp.console) > 0 {
+			msg += "\n\n" + m.renderConsole(resp, w)
+		}
+		return msg
+	}
+
+	div := m.theme.dim().Render(strings.Repeat("─", w))
+	// 4 rows overhead: status line + divider + tab bar + divider
+	contentH := h - 4
+	var content string
+	if m.showingSSE() {
+		content = m.renderSSELog(w, contentH)
+	} else if m.showingJSON() {
+		// JSON renders only the rows on screen, so huge bodies stay responsive
+		bar := m.renderFilterBar(w)
+		if m.searching {
+			bar = m.renderSearchBar(w)
+		}
+		content = bar + "\n" + m.renderJSONView(m.jv, w, contentH-1)
+	} else if m.responseTab == respTabBody && m.pretty != nil {
+		content = m.renderPrettyBody(w, contentH)
+	} else {
+		content = scrollLines(m.renderResponseTabContent(resp, w), m.respScroll, contentH)
+	}
+	return strings.Join([]string{
+		m.renderStatusLine(resp, w),
+		div,
+		m.renderResponseTabs(w),
+		div,
+		content,
+	}, "\n")
+}
+
+// statusStyle colors a status code by its class.
+func (m Model) statusStyle(code int) lipgloss.Style {
+	switch {
+	case code >= 400:
+		return m.theme.errStyle().Bold(true)
+	case code >= 300:
+		return m.theme.highlight().Bold(true)
+	}
+	return m.theme.successStyle()
+}
+
+func (m Model) renderStatusLine(resp response, w int) string {
+	dim := m.theme.dim()
+	parts := []string{
+		m.statusStyle(resp.statusCode).Render(resp.status),
+		m.theme.textMuted().Render(resp.duration.Round(time.Millisecond).String()),
+		m.theme.textMuted().Render(formatBytes(len(resp.body))),
+	}
+	if resp.stream != nil {
+		parts[2] = m.theme.textMuted().Render("event stream")
+	}
+	if resp.proxy != "" {
+		parts = append(parts, dim.Render("via "+resp.proxy))
+	}
+	if resp.socket != "" {
+		parts = append(parts, dim.Render("via unix:"+resp.socket))
+	}
+	if n := len(resp.redirects); n > 0 {
+		parts = append(parts, m.theme.highlight().Render(plural(n, "redirect")))
+	}
+	if resp.attempts > 1 {
+		parts = append(parts, m.theme.highlight().Render(plural(resp.attempts, "attempt")))
+	}
+	if s := m.searchStatus(); s != "" {
+		parts = append(parts, s)
+	}
+	line := " " + strings.Join(parts, dim.Render(" · "))
+	if resp.err != nil {
+		line += "  " + m.theme.errStyle().Render(resp.err.Error())
+	}
+	return lipgloss.NewStyle().MaxWidth(w).Render(line)
+}
+
+// renderResponseTabs lays the tabs out in w columns, closing up the gaps
+// between them before cutting any off; tabs before the active one give way
+// first, so it always shows.
+func (m Model) renderResponseTabs(w int) string {
+	var parts []string
+	for i, label := range respTabLabels {
+		var tab string
+		if m.responseTab == i {
+			tab = m.theme.activeTabStyle().Render(" " + label + " ")
+		} else {
+			tab = m.theme.dim().Render(" " + label + " ")
+		}
+		if i == respTabTests && m.resp != nil && len(m.resp.tests) > 0 {
+			// pass count next to the label, so failures show from any tab
+			passed := testsPassed(m.resp.tests)
+			count := m.theme.successStyle().Render(fmt.Sprintf("%d/%d", passed, len(m.resp.tests)))
+			if passed < len(m.resp.tests) {
+				count = m.theme.errStyle().Bold(true).Render(fmt.Sprintf("%d/%d", passed, len(m.resp.tests)))
+			}
+			tab += count
+		}
+		if i == respTabConsole && m.resp != nil && len(m.resp.console) > 0 {
+			tab += m.theme.dim().Render(fmt.Sprintf("%d", len(m.resp.console)))
+		}
+		parts = append(parts, tab)
+	}
+	bar := " " + strings.Join(parts, "  ")
+	if lipgloss.Width(bar) > w {
+		start := 0
+		for start < m.responseTab && lipgloss.Width(strings.Join(parts[start:m.responseTab+1], "")) > w-1 {
+			start++
+		}
+		bar = strings.Join(parts[start:], "")
+		if start > 0 {
+			bar = m.theme.dim().Render("‹") + bar
+		}
+	}
+	return lipgloss.NewStyle().MaxWidth(w).Render(bar)
+}
+
+func (m Model) renderResponseTabContent(resp response, w int) string {
+	switch m.responseTab {
+	case respTabBody:
+		return m.renderBodyContent(string(resp.body))
+	case respTabHeaders:
+		keys := make([]string, 0, len(resp.header))
+		for k := range resp.header {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var pairs [][2]string
+		for _, k := range keys {
+			for _, v := range resp.header[k] {
+				pairs = append(pairs, [2]string{k, v})
+			}
+		}
+		table := m.renderKVTable(m.redactPairs(pairs, m.redactions.header), "Key", "Value", w)
+		if len(resp.redirects) == 0 {
+			return table
+		}
+		return m.renderRedirects(resp.redirects, w) + "\n\n" + table
+	case respTabCookies:
+		return m.renderCookieChanges(resp, w)
+	case respTabTiming:
+		return m.renderTiming(resp, w)
+	case respTabTests:
+		return m.renderTests(resp, w)
+	case respTabVars:
+		return m.renderVars(resp, w)
+	case respTabConsole:
+		return m.renderConsole(resp, w)
+	}
+	return ""
+}
+
+// updateResponsePane handles keys while the response pane is focused.
+// ok is false when the key is not handled here.
+func (m Model) updateResponsePane(msg tea.KeyMsg) (Model, bool) {
+	if m.responseTab == respTabBody {
+		switch msg.String() {
+		case "v":
+			return m.setBodyFormat((m.bodyFormat + 1) % formatCount), true
+		case "i":
+			m.hideImagePreview = !m.hideImagePreview
+			return m, true
+		case "w":
+			if m.resp == nil || m.resp.statusCode == 0 {
+				return m, true
+			}
+			return m.openSavePrompt(saveBody), true
+		}
+	}
+	if next, ok := m.updateSearchKeys(msg); ok {
+		return next, true
+	}
+	if m.showingJSON() {
+		return m.updateJSONView(msg)
+	}
+	switch msg.String() {
+	case "j", "down":
+		m.respScroll++
+	case "k", "up":
+		if m.respScroll > 0 {
+			m.respScroll--
+		}
+	case "g":
+		m.respScroll = 0
+	case "G":
+		if m.responseTab == respTabBody && m.pretty != nil {
+			m.respScroll = m.pretty.lineCount()
+		}
+	default:
+		return m, false
+	}
+	if m.responseTab == respTabBody && m.pretty != nil {
+		m.respScroll = max(0, min(m.respScroll, m.pretty.lineCount()-1))
+	}
+	return m, true
+}
+
+func (m Model) updateJSONView(msg tea.KeyMsg) (Model, bool) {
+	v := m.jv
+	page := max(1, m.responseBodyHeight()-1)
+	switch msg.String() {
+	case "j", "down":
+		m.bodyCursor++
+	case "k", "up":
+		m.bodyCursor--
+	case "ctrl+d", "pgdown":
+		m.bodyCursor += page / 2
+	case "ctrl+u", "pgup":
+		m.bodyCursor -= page / 2
+	case "g", "home":
+		m.bodyCursor = 0
+	case "G", "end":
+		m.bodyCursor = len(v.visible) - 1
+	case "enter", " ":
+		m.bodyCursor = v.toggle(m.bodyCursor)
+	case "-":
+		v.setAll(true)
+		m.bodyCursor = 0
+	case "+", "=":
+		v.setAll(false)
+		m.bodyCursor = 0
+	case "|":
+		m.filtering = true
+		m.filterPrev = m.filterInput
+		return m, true
+	default:
+		return m, false
+	}
+	m.bodyCursor = max(0, min(m.bodyCursor, len(v.visible)-1))
+	if m.bodyCursor < m.respScroll {
+		m.respScroll = m.bodyCursor
+	} else if m.bodyCursor >= m.respScroll+page {
+		m.respScroll = m.bodyCursor - page + 1
+	}
+	return m, true
+}
+
+// responseBodyHeight is the number of content rows in the response pane,
+// mirroring the layout arithmetic of renderMain and renderResponse.
+func (m Model) responseBodyHeight() int {
+	const footerH = 1
+	mainH := m.height - footerH
+	innerH := mainH - 2
+	if !m.splitVertical {
+		innerH = mainH - mainH/2 - 2
+	}
+	return innerH - 4
+}
+
+// renderRedirects lists the redirect chain that led to the final response.
+func (m Model) renderRedirects(hops []redirectHop, w int) string {
+	dim := m.theme.dim()
+	lines := []string{m.theme.highlight().Bold(true).Render("  Redirects")}
+	for i, h := range hops {
+		st, ok := m.theme.methodStyle(h.method)
+		if !ok {
+			st = dim
+		}
+		line := dim.Render(fmt.Sprintf("  %d. ", i+1)) + st.Render(h.method) + " " +
+			m.theme.textMuted().Render(m.presentURL(h.url)) + dim.Render(" → "+h.status)
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(w).Render(line))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// scrollLines returns at most h lines of s starting at offset.
+func scrollLines(s string, offset, h int) string {
+	lines := strings.Split(s, "\n")
+	if offset > len(lines)-1 {
+		offset = max(0, len(lines)-1)
+	}
+	lines = lines[offset:]
+	if h >= 0 && len(lines) > h {
+		lines = lines[:h]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatBytes(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 	"strconv"
@@ -24,8 +25,51 @@ type Model struct {
 	urlInput      string // editable URL (in-memory only)
 	urlInputPrev  string // saved before edit, restored on esc
 	editingURL    bool
+	urlPreview    bool // show the URL bar with variables resolved
 	methodInput   string // selected HTTP method (in-memory only)
 
+	// environments
+	envs        []environment
+	activeEnv   int
+	runtimeVars []variable // set by extractors; override the environment
+	promptAnswers map[string]string // {{$prompt}} answers, while a send starts
+	vault       *vault // secrets for {{$secret name}}
+	showSecrets bool   // unmask secret values on screen
+	redactions  redactionList // what history, exports, logs and presentation mode hide
+	presenting  bool          // presentation mode: hide sensitive values on screen
+	jars      map[string]*cookieJar // cookie jar per environment name
+
+	// transport
+	proxy    proxyConfig    // global; folders and requests may override
+	resolves []resolveEntry // host:port → address overrides used by the dialer
+
+	// response
+	sending     bool
+	cancelSend  context.CancelFunc // aborts the in-flight send
+	resp        *response          // nil until the first send completes
+	responseTab int       // one of the respTab constants (response.go)
+	respScroll  int
+	jv          *jsonView // JSON body viewer; nil when the body is not JSON
+	bodyCursor  int       // cursor row in the JSON body viewer
+	jvFull      *jsonView // unfiltered JSON body; jv shows the filter result
+	filtering   bool      // the jq filter bar has focus
+	filterInput string    // jq expression applied to the JSON body
+	filterPrev  string    // filter before editing, restored on esc
+	filterErr   string    // why the current filter failed, if it did
+	filterSeq   int       // drops results of stale keystrokes
+	bodyFormat  bodyFormat  // Body tab format override; formatAuto follows Content-Type
+	pretty      *prettyBody // formatted body for every format but JSON
+	hideImagePreview bool
+
+	// body search
+	searching     bool   // the search bar has focus
+	searchInput   string // pattern being typed
+	searchQuery   string // pattern the current matches are for
+	searchRegex   bool
+	searchErr     string
+	searchMatches []bodyMatch
+	searchIdx     int // match that n / N last moved to
+
 	// method picker
 	showMethodPicker bool
 	methodCursor     int
@@ -34,8 +78,59 @@ type Model struct {
 	showCmdPalette bool
 	cmdInput       string
 	cmdError       string
+	cmdInfo        string // informational reply shown instead of the hint
 	showCmdHelp    bool
 
+	// save path prompt
+	saving    bool
+	saveKind  string // saveBody or saveExchange
+	saveInput string
+	saveErr   string
+	notice    string // result shown in the footer until the next key
+
+	// response diff
+	diff       *responseDiff // nil unless :diff is on screen
+	diffScroll int
+
+	// collection run
+	run       *collectionRun // nil unless :run is on screen
+	runScroll int
+	runSeq    int
+
+	// {{$prompt}} questions asked before a send or run
+	prompt *promptState
+
+	// GraphQL body editors
+	gqlEditing  bool     // typing into the Query or Variables editor
+	gqlPane     int      // gqlQueryPane or gqlVarsPane
+	gqlEdit     textArea // text of the editor and its cursor
+	gqlComplete int      // selected completion
+	gqlForce    bool     // ctrl+space: suggest before a word is typed
+
+	// GraphQL schemas
+	schemas    map[string]*gqlSchema // introspected schema per endpoint URL
+	schemaLoad *schemaLoad           // nil unless an introspection is in flight
+	showSchema bool
+	sbCursor   int
+	sbExpanded map[string]bool // set of expanded type names
+	sbQuery    string
+	sbInsert   bool // typing into the filter
+
+	// WebSocket session and its message composer
+	ws          *wsSession // nil until a WebSocket request connects
+	wsSeq       int
+	wsCursor    int  // selected frame of the log; -1 follows the newest
+	wsExpand    bool // show the payload of the selected frame in full
+	wsFiltering bool // typing into the log filter
+	wsFilter    string
+	wsDirFilter int  // wsShowAll, wsShowIn or wsShowOut
+	wsEditing   bool // typing into the composer
+	wsEdit      textArea
+
+	// event stream of the last response
+	sse       *sseStream // nil unless the last response is an event stream
+	sseCursor int        // selected event; -1 follows the newest
+
 	// folder picker
 	showFolderPicker bool
 	fpExpanded       map[int]bool // set of expanded folder indices
@@ -46,7 +141,14 @@ type Model struct {
 	fpAdding         bool
 	fpAddKind        string // "folder" or "request"
 	fpAddInput       string
+	fpExample        int // example shown in the request preview
 	fpConfirmDelete  bool
+
+	// cookies overlay
+	showCookies bool
+	ckCursor    int
+	ckEditing   bool
+	ckEditInput string
 }
 
 // New creates the initial application model with mocked data.
@@ -60,6 +162,13 @@ func New() Model {
 	return Model{
 		folders:         folders,
 		fpExpanded:      map[int]bool{},
+		envs:            mockEnvironments,
+		jars:            map[string]*cookieJar{},
+		schemas:         map[string]*gqlSchema{},
+		sbExpanded:      map[string]bool{},
+		vault:           newVault(defaultVaultPath()),
+		redactions:      defaultRedactions,
+		proxy:           proxyConfig{mode: proxyEnv},
 		methodInput:     "GET",
 		splitVertical:   true,
 		theme:           themeXcode,
@@ -78,7 +187,67 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 		return m, nil
 
+	case responseMsg:
+		m.sending = false
+		m = m.stopSSE()
+		var watch tea.Cmd
+		if msg.resp.stream != nil {
+			// The send's context is the stream's now.
+			m, watch = m.watchSSE(msg.resp, m.cancelSend)
+		} else if m.cancelSend != nil {
+			m.cancelSend()
+		}
+		m.cancelSend = nil
+		resp := msg.resp
+		m.resp = &resp
+		m.respScroll = 0
+		m.bodyCursor = 0
+		m.jv = nil
+		m.jvFull = nil
+		m.filtering = false
+		m.filterInput = ""
+		m.filterErr = ""
+		m.bodyFormat = formatAuto
+		m.pretty = resp.pretty
+		m = m.clearSearch()
+		m = m.recordHistory(resp)
+		m = m.applyEnvChanges(resp.envName, resp.envChanges)
+		m = m.applyRuntimeChanges(resp.runtimeChanges)
+		m = m.refreshDiff()
+		if resp.json != nil {
+			m.jv = newJSONView(resp.json)
+			m.jvFull = m.jv
+			m.filterInput = msg.filter
+		}
+		next, cmd := m.applyFilter()
+		return next, tea.Batch(cmd, watch)
+
+	case filterMsg:
+		return m.handleFilterMsg(msg), nil
+
+	case runEvent:
+		return m.handleRunEvent(msg)
+
+	case runDoneMsg:
+		return m.finishRun(msg), nil
+
+	case schemaMsg:
+		return m.handleSchemaMsg(msg), nil
+
+	case wsEvent:
+		return m.handleWSEvent(msg)
+
+	case wsDoneMsg:
+		return m.handleWSDone(msg), nil
+
+	case sseMsg:
+		return m.handleSSEMsg(msg)
+
+	case sseDoneMsg:
+		return m.handleSSEDone(msg), nil
+
 	case tea.KeyMsg:
+		m.notice = ""
 		if m.showHelp {
 			switch msg.String() {
 			case "q", "?", "esc":
@@ -99,6 +268,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateURLInput(msg)
 		}
 
+		if m.gqlEditing {
+			return m.updateGraphQLEditor(msg)
+		}
+
+		if m.wsEditing {
+			return m.updateWSEditor(msg)
+		}
+
 		if m.showMethodPicker {
 			return m.updateMethodPicker(msg)
 		}
@@ -107,8 +284,90 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.updateFolderPicker(msg), nil
 		}
 
+		if m.showCookies {
+			return m.updateCookies(msg), nil
+		}
+
+		if m.showSchema {
+			return m.updateSchemaBrowser(msg)
+		}
+
+		if m.prompt != nil {
+			return m.updatePrompt(msg)
+		}
+
 		if m.showCmdPalette {
-			return m.updateCmdPalette(msg), nil
+			prev, prevLoad := m.run, m.schemaLoad
+			m = m.updateCmdPalette(msg)
+			if m.run != nil && m.run != prev {
+				return m, waitRun(m.run)
+			}
+			if m.schemaLoad != nil && m.schemaLoad != prevLoad {
+				return m, m.schemaLoad.cmd
+			}
+			return m, nil
+		}
+
+		if m.saving {
+			return m.updateSavePrompt(msg)
+		}
+
+		if m.sending {
+			switch msg.String() {
+			case "x", "esc":
+				m.cancelSend()
+				return m, nil
+			}
+		}
+
+		if m.filtering {
+			return m.updateFilterBar(msg)
+		}
+
+		if m.searching {
+			return m.updateSearchBar(msg)
+		}
+
+		if m.run != nil {
+			if next, ok := m.updateRun(msg); ok {
+				return next, nil
+			}
+		}
+
+		if m.diff != nil {
+			if next, ok := m.updateDiff(msg); ok {
+				return next, nil
+			}
+		}
+
+		if m.focused == 1 && m.showingSSE() {
+			if next, ok := m.updateSSELog(msg); ok {
+				return next, nil
+			}
+		}
+
+		if m.focused == 1 && m.showingWS() {
+			if next, ok := m.updateWSLog(msg); ok {
+				return next, nil
+			}
+		}
+
+		if m.focused == 1 {
+			if next, ok := m.updateResponsePane(msg); ok {
+				return next, nil
+			}
+		}
+
+		if m.focused == 0 && m.requestTab == 3 && m.activeGraphQL() != nil {
+			if next, cmd, ok := m.updateGraphQLKeys(msg); ok {
+				return next, cmd
+			}
+		}
+
+		if m.focused == 0 && m.requestTab == 3 && isWebSocketURL(m.urlInput) {
+			if next, cmd, ok := m.updateWSKeys(msg); ok {
+				return next, cmd
+			}
 		}
 
 		switch msg.String() {
@@ -127,9 +386,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.fpQuery = ""
 			m.fpCursor = 0
 
-		// Send request (placeholder)
+		// Send request
 		case "s":
-			// TODO: trigger HTTP request
+			if m.activeFolderIdx >= 0 && isWebSocketURL(m.urlInput) {
+				return m.toggleWS()
+			}
+			if m.activeFolderIdx >= 0 && !m.sending {
+				return m.startSend()
+			}
 
 		// Method picker
 		case "m":
@@ -150,21 +414,34 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.urlInputPrev = m.urlInput
 			}
 
+		// Resolved URL preview
+		case "u":
+			if m.focused == 0 {
+				m.urlPreview = !m.urlPreview
+			}
+
 		// Command palette
 		case ":":
 			m.showCmdPalette = true
 			m.cmdInput = ""
 			m.cmdError = ""
 
-		// Tab cycling in request pane — h/l or arrow keys
+		// Tab cycling in request/response pane — h/l or arrow keys
 		case "h", "left":
 			if m.focused == 0 {
 				m.requestTab = (m.requestTab + 3) % 4
+			} else {
+				m.responseTab = (m.responseTab + respTabCount - 1) % respTabCount
+				m.respScroll = 0
 			}
 		case "l", "right":
 			if m.focused == 0 {
 				m.requestTab = (m.requestTab + 1) % 4
+			} else {
+				m.responseTab = (m.responseTab + 1) % respTabCount
+				m.respScroll = 0
 			}
+
 		// Direct tab jump — p/a/r/b
 		case "p":
 			if m.focused == 0 {
@@ -343,10 +620,23 @@ func (m Model) updateFolderPicker(msg tea.KeyMsg) Model {
 	case "j", "down", "ctrl+j":
 		if m.fpCursor < len(items)-1 {
 			m.fpCursor++
+			m.fpExample = 0
 		}
 	case "k", "up", "ctrl+k":
 		if m.fpCursor > 0 {
 			m.fpCursor--
+			m.fpExample = 0
+		}
+	case "[", "]":
+		if m.fpCursor < len(items) && items[m.fpCursor].reqIdx >= 0 {
+			it := items[m.fpCursor]
+			if n := len(m.folders[it.folderIdx].requests[it.reqIdx].examples); n > 0 {
+				step := 1
+				if msg.String() == "[" {
+					step = n - 1
+				}
+				m.fpExample = (m.fpExample + step) % n
+			}
 		}
 	case "i":
 		m.fpInsert = true
@@ -386,6 +676,7 @@ func (m Model) performFpEnter() Model {
 		m.activeReqIdx = item.reqIdx
 		m.urlInput = req.url
 		m.methodInput = req.method
+		m.diff = nil
 		m.showFolderPicker = false
 		m.fpQuery = ""
 		m.fpSearchResults = nil
@@ -511,9 +802,7 @@ func (m Model) updateURLInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 func (m Model) updateCmdPalette(msg tea.KeyMsg) Model {
 	switch msg.String() {
 	case "esc":
-		m.showCmdPalette = false
-		m.cmdInput = ""
-		m.cmdError = ""
+		m = m.closeCmdPalette()
 	case "enter":
 		m = m.execCmd(strings.TrimSpace(m.cmdInput))
 	case "backspace":
@@ -525,11 +814,20 @@ func (m Model) updateCmdPalette(msg tea.KeyMsg) Model {
 		if len([]rune(msg.String())) == 1 {
 			m.cmdInput += msg.String()
 			m.cmdError = ""
+			m.cmdInfo = ""
 		}
 	}
 	return m
 }
 
+func (m Model) closeCmdPalette() Model {
+	m.showCmdPalette = false
+	m.cmdInput = ""
+	m.cmdError = ""
+	m.cmdInfo = ""
+	return m
+}
+
 func (m Model) execCmd(cmd string) Model {
 	parts := strings.Fields(cmd)
 	if len(parts) == 0 {
@@ -538,25 +836,104 @@ func (m Model) execCmd(cmd string) Model {
 	switch parts[0] {
 	case "orient":
 		m.splitVertical = !m.splitVertical
-		m.showCmdPalette = false
-		m.cmdInput = ""
-		m.cmdError = ""
+		m = m.closeCmdPalette()
 	case "theme":
 		if len(parts) < 2 {
 			m.cmdError = "usage: theme <name>"
 		} else if t, ok := themes[parts[1]]; ok {
 			m.theme = t
-			m.showCmdPalette = false
-			m.cmdInput = ""
-			m.cmdError = ""
+			m = m.closeCmdPalette()
 		} else {
 			m.cmdError = "unknown theme: " + parts[1]
 		}
+	case "env":
+		if len(parts) < 2 {
+			m.cmdError = "usage: env <name>"
+			return m
+		}
+		for i, e := range m.envs {
+			if e.name == parts[1] {
+				m.activeEnv = i
+				m = m.closeCmdPalette()
+				return m
+			}
+		}
+		m.cmdError = "unknown environment: " + parts[1]
+	case "cookies":
+		m.showCookies = true
+		m.ckCursor = 0
+		m.ckEditing = false
+		m = m.closeCmdPalette()
+	case "jar":
+		if m.activeFolderIdx < 0 {
+			m.cmdError = "no request selected"
+		} else if len(parts) < 2 || (parts[1] != "on" && parts[1] != "off") {
+			m.cmdError = "usage: jar on|off"
+		} else {
+			m.folders[m.activeFolderIdx].requests[m.activeReqIdx].noCookies = parts[1] == "off"
+			m = m.closeCmdPalette()
+		}
+	case "proxy":
+		m = m.execProxy(parts[1:])
+	case "socket":
+		m = m.execSocket(parts[1:])
+	case "resolve":
+		m = m.execResolve(parts[1:])
+	case "set":
+		m = m.execSet(parts[1:], false)
+	case "unset":
+		m = m.execSet(parts[1:], true)
+	case "format":
+		m = m.execFormat(parts[1:])
+	case "save":
+		m = m.execSave(parts[1:])
+	case "pin":
+		m = m.execPin(parts[1:])
+	case "unpin":
+		m = m.execUnpin(parts[1:])
+	case "diff":
+		m = m.execDiff(parts[1:])
+	case "history":
+		m = m.execHistory()
+	case "import":
+		m = m.execImport(parts[1:])
+	case "assert":
+		m = m.execAssert(strings.TrimSpace(strings.TrimPrefix(cmd, "assert")))
+	case "unassert":
+		m = m.execUnassert(parts[1:])
+	case "run":
+		m = m.execRun(parts[1:])
+	case "report":
+		m = m.execReport(parts[1:])
+	case "extract":
+		m = m.execExtract(strings.TrimSpace(strings.TrimPrefix(cmd, "extract")))
+	case "unextract":
+		m = m.execUnextract(parts[1:])
+	case "vars":
+		m = m.execVars(parts[1:])
+	case "vault":
+		m = m.execVault(parts[1:])
+	case "secret":
+		m = m.execSecret(strings.TrimSpace(strings.TrimPrefix(cmd, "secret")))
+	case "redact":
+		m = m.execRedact(parts[1:], true)
+	case "unredact":
+		m = m.execRedact(parts[1:], false)
+	case "present":
+		m = m.execPresent(parts[1:])
+	case "graphql":
+		m = m.execGraphQL(parts[1:])
+	case "operation":
+		m = m.execOperation(parts[1:])
+	case "schema":
+		m = m.execSchema(parts[1:])
+	case "ws":
+		m = m.execWS(parts[1:])
+	case "script":
+		m = m.execScript(strings.TrimSpace(strings.TrimPrefix(cmd, "script")))
 	case "help":
 		m.showCmdHelp = true
-		m.showCmdPalette = false
-		m.cmdInput = ""
-		m.cmdError = ""
+		m = m.closeCmdPalette()
 	default:
 		m.cmdError = "unknown command: " + parts[0]
 	}
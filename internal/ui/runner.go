@@ -0,0 +1,540 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// runState is where one request of a collection run stands.
+type runState int
+
+const (
+	runPending runState = iota
+	runActive
+	runPassed
+	runFailed
+	runSkipped // never started, or cancelled when the run stopped
+)
+
+// runResult is one request of a collection run.
+type runResult struct {
+	reqIdx int
+	name   string
+	method string
+	state  runState
+	resp   response
+}
+
+// collectionRun is a :run in progress or finished. Requests start in folder
+// order, at most concurrency at a time.
+type collectionRun struct {
+	id          int // tells events of this run from those of a closed one
+	folderIdx   int
+	folder      string
+	env         string // environment the variables came from
+	results     []runResult
+	concurrency int
+	bail        bool // stop at the first failure
+	started     time.Time
+	elapsed     time.Duration
+	done        bool
+	stopped     string // why the run ended early, if it did
+	cancel      context.CancelFunc
+	events      chan runEvent
+	redactions  redactionList
+	mask        func(string) string // hides secrets and sensitive values in reports
+}
+
+// runEvent reports that request idx of a run started or finished.
+type runEvent struct {
+	run   int
+	idx   int
+	start bool
+	resp  response
+}
+
+// runDoneMsg is delivered once every request of a run has finished.
+type runDoneMsg struct {
+	run int
+}
+
+// runPassedResp reports whether a response counts as a pass: it arrived and
+// every assertion held.
+func runPassedResp(resp response) bool {
+	return resp.err == nil && testsPassed(resp.tests) == len(resp.tests)
+}
+
+// count returns how many results are in state s.
+func (c *collectionRun) count(s runState) int {
+	n := 0
+	for _, r := range c.results {
+		if r.state == s {
+			n++
+		}
+	}
+	return n
+}
+
+// finished is the number of requests that have a final state.
+func (c *collectionRun) finished() int {
+	return c.count(runPassed) + c.count(runFailed) + c.count(runSkipped)
+}
+
+// execRun handles ":run [folder] [-c n] [--bail]", running every request of
+// the folder (the active one by default) and showing the progress.
+func (m Model) execRun(args []string) Model {
+	if m.run != nil && !m.run.done {
+		m.cmdError = "a run is in progress (x stops it)"
+		return m
+	}
+	concurrency, bail := 1, false
+	var name []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--bail":
+			bail = true
+		case "-c":
+			if i+1 < len(args) {
+				i++
+				if n, err := strconv.Atoi(args[i]); err == nil && n > 0 {
+					concurrency = n
+					continue
+				}
+			}
+			m.cmdError = "usage: run [folder] [-c n] [--bail]"
+			return m
+		default:
+			name = append(name, args[i])
+		}
+	}
+
+	fi := m.activeFolderIdx
+	if len(name) > 0 {
+		fi = m.folderIndex(strings.Join(name, " "))
+		if fi < 0 {
+			m.cmdError = "no folder " + strconv.Quote(strings.Join(name, " "))
+			return m
+		}
+	}
+	if fi < 0 {
+		m.cmdError = "usage: run <folder> [-c n] [--bail]"
+		return m
+	}
+	if len(m.folders[fi].requests) == 0 {
+		m.cmdError = "folder " + strconv.Quote(m.folders[fi].name) + " has no requests"
+		return m
+	}
+	m = m.closeCmdPalette()
+	start := func(m Model) (Model, tea.Cmd) {
+		return m.askPrompts(m.folderPrompts(fi), func(m Model) (Model, tea.Cmd) {
+			m = m.startRun(fi, concurrency, bail)
+			return m, waitRun(m.run)
+		})
+	}
+	if slices.ContainsFunc(m.folders[fi].requests, requestSecrets) && m.vault.locked() {
+		m, _ = m.askPassphrase(start)
+	} else {
+		m, _ = start(m)
+	}
+	return m
+}
+
+// folderIndex finds a folder by name, ignoring case.
+func (m Model) folderIndex(name string) int {
+	for i, f := range m.folders {
+		if strings.EqualFold(f.name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// newRun prepares a run of folder fi that parent can cancel; nothing is sent
+// until sendAll.
+func (m Model) newRun(parent context.Context, fi, concurrency int, bail bool) (*collectionRun, context.Context) {
+	f := m.folders[fi]
+	ctx, cancel := context.WithCancel(parent)
+	run := &collectionRun{
+		folderIdx:   fi,
+		folder:      f.name,
+		env:         m.env().name,
+		concurrency: concurrency,
+		bail:        bail,
+		started:     time.Now(),
+		cancel:      cancel,
+		redactions:  m.redactions,
+		mask:        m.maskValues,
+	}
+	for i, r := range f.requests {
+		run.results = append(run.results, runResult{reqIdx: i, name: r.name, method: r.method})
+	}
+	return run, ctx
+}
+
+// sendAll sends the requests of the run's folder, starting them in order and
+// at most run.concurrency at a time, and passes every start and result to
+// emit, which may be called from several goroutines. It returns once all
+// sends are over; requests are no longer started after ctx is cancelled.
+func (m Model) sendAll(ctx context.Context, run *collectionRun, emit func(runEvent)) {
+	f := m.folders[run.folderIdx]
+	jar := m.jar()
+	// Scripts may set variables for the requests after them.
+	var mu sync.Mutex
+	env := m.env()
+	settings := make([]transportSettings, len(f.requests))
+	for i, r := range f.requests {
+		settings[i] = m.transportSettingsFor(f, r)
+	}
+	sem := make(chan struct{}, run.concurrency)
+	var wg sync.WaitGroup
+	for i, r := range f.requests {
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i int, r request) {
+			defer func() { <-sem; wg.Done() }()
+			done := func(resp response) {
+				emit(runEvent{run: run.id, idx: i, resp: resp})
+				// A bail run stops here, before the slot is released, so no
+				// request starts after the first failure whoever applies the
+				// event; requests it cancels report after the failure.
+				if run.bail && !runPassedResp(resp) {
+					run.cancel()
+				}
+			}
+			emit(runEvent{run: run.id, idx: i, start: true})
+			opts, err := resolveOptions(f, r)
+			if err != nil {
+				done(response{err: err})
+				return
+			}
+			mu.Lock()
+			cur := env
+			mu.Unlock()
+			resp := execute(ctx, f, r, cur, jar, settings[i], opts)
+			mu.Lock()
+			env = env.apply(resp.envChanges).apply(resp.runtimeChanges)
+			mu.Unlock()
+			done(resp)
+		}(i, r)
+	}
+	wg.Wait()
+}
+
+// startRun launches the requests of folder fi off the UI goroutine. Events
+// arrive through a channel buffered for the whole run, so the workers never
+// block on a UI that has moved on.
+func (m Model) startRun(fi, concurrency int, bail bool) Model {
+	run, ctx := m.newRun(context.Background(), fi, concurrency, bail)
+	m.runSeq++
+	run.id = m.runSeq
+	run.events = make(chan runEvent, 2*len(run.results))
+	m.run = run
+	m.runScroll = 0
+	go func() {
+		defer close(run.events)
+		m.sendAll(ctx, run, func(ev runEvent) { run.events <- ev })
+	}()
+	return m
+}
+
+// waitRun delivers the next event of a run, or runDoneMsg once it is over.
+func waitRun(run *collectionRun) tea.Cmd {
+	return func() tea.Msg {
+		ev, ok := <-run.events
+		if !ok {
+			return runDoneMsg{run: run.id}
+		}
+		return ev
+	}
+}
+
+// handleRunEvent records one event of the run on screen.
+func (m Model) handleRunEvent(ev runEvent) (Model, tea.Cmd) {
+	if m.run == nil || ev.run != m.run.id {
+		return m, nil
+	}
+	run := *m.run
+	run.results = append([]runResult(nil), run.results...)
+	if run.apply(ev) != runSkipped && !ev.start {
+		m = m.recordHistoryAt(run.folderIdx, run.results[ev.idx].reqIdx, ev.resp)
+		m = m.applyEnvChanges(ev.resp.envName, ev.resp.envChanges)
+		m = m.applyRuntimeChanges(ev.resp.runtimeChanges)
+	}
+	m.run = &run
+	return m, waitRun(m.run)
+}
+
+// apply records ev in the results, stopping a bail run at its first failure,
+// and returns the new state of the request.
+func (c *collectionRun) apply(ev runEvent) runState {
+	res := &c.results[ev.idx]
+	switch {
+	case ev.start:
+		res.state = runActive
+	case c.stopped != "" && errors.Is(ev.resp.err, context.Canceled):
+		res.state = runSkipped
+	default:
+		res.resp = ev.resp
+		res.state = runPassed
+		if !runPassedResp(ev.resp) {
+			res.state = runFailed
+			if c.bail && c.stopped == "" {
+				c.stopped = "stopped at the first failure"
+				c.cancel()
+			}
+		}
+	}
+	return res.state
+}
+
+// finishRun marks the run over once its workers are done; requests that
+// never started count as skipped.
+func (m Model) finishRun(msg runDoneMsg) Model {
+	if m.run == nil || msg.run != m.run.id {
+		return m
+	}
+	run := *m.run
+	run.results = append([]runResult(nil), run.results...)
+	run.finish()
+	m.run = &run
+	return m
+}
+
+func (c *collectionRun) finish() {
+	for i := range c.results {
+		if c.results[i].state == runPending || c.results[i].state == runActive {
+			c.results[i].state = runSkipped
+		}
+	}
+	c.done = true
+	c.elapsed = time.Since(c.started)
+	c.cancel()
+}
+
+// stopRun cancels the requests still running and skips the rest.
+func (m Model) stopRun() Model {
+	if m.run != nil && !m.run.done && m.run.stopped == "" {
+		run := *m.run
+		run.stopped = "stopped"
+		run.cancel()
+		m.run = &run
+	}
+	return m
+}
+
+// runBodyHeight is the number of result rows the run view shows: the pane
+// less its border and the four lines above the table.
+func (m Model) runBodyHeight() int {
+	mainH := m.height - 1
+	return mainH - 2 - 4
+}
+
+// updateRun handles keys while the run is on screen. ok is false for keys it
+// leaves to the rest of the UI.
+func (m Model) updateRun(msg tea.KeyMsg) (Model, bool) {
+	page := max(1, m.runBodyHeight())
+	last := max(0, len(m.runRows())-page)
+	switch msg.String() {
+	case "esc":
+		m = m.stopRun()
+		m.run = nil
+	case "x":
+		m = m.stopRun()
+	case "j", "down":
+		m.runScroll = min(m.runScroll+1, last)
+	case "k", "up":
+		m.runScroll = max(m.runScroll-1, 0)
+	case "ctrl+d":
+		m.runScroll = min(m.runScroll+page/2, last)
+	case "ctrl+u":
+		m.runScroll = max(m.runScroll-page/2, 0)
+	case "g":
+		m.runScroll = 0
+	case "G":
+		m.runScroll = last
+	default:
+		return m, false
+	}
+	return m, true
+}
+
+// renderRun shows the progress of a run and, once it is over, its summary.
+func (m Model) renderRun(mainH int) string {
+	innerW := m.width - 2
+	innerH := mainH - 2
+	run := m.run
+	dim := m.theme.dim()
+
+	n := len(run.results)
+	elapsed := run.elapsed
+	if !run.done {
+		elapsed = time.Since(run.started)
+	}
+	head := m.theme.paneTitle(" Run · "+run.folder+" ", true) + dim.Render(fmt.Sprintf(
+		"%d/%d · %s", run.finished(), n, elapsed.Round(time.Millisecond)))
+	if run.concurrency > 1 {
+		head += dim.Render(fmt.Sprintf(" · %d at a time", run.concurrency))
+	}
+	if run.bail {
+		head += dim.Render(" · stop on failure")
+	}
+
+	info := m.runSummary()
+	if run.done {
+		info += "  " + m.theme.keyHint("esc") + dim.Render("close")
+	} else {
+		info += "  " + m.theme.keyHint("x") + dim.Render("stop  ") + m.theme.keyHint("esc") + dim.Render("close")
+	}
+
+	lines := []string{
+		lipgloss.NewStyle().MaxWidth(innerW).Render(head),
+		m.renderRunBar(innerW),
+		lipgloss.NewStyle().MaxWidth(innerW).Render(info),
+		dim.Render(strings.Repeat("─", innerW)),
+	}
+	rows := m.runRows()
+	end := min(m.runScroll+max(0, innerH-len(lines)), len(rows))
+	for _, r := range rows[m.runScroll:end] {
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(innerW).Render(r))
+	}
+	return m.theme.paneStyle(true).Width(innerW).Height(innerH).Render(strings.Join(lines, "\n"))
+}
+
+// renderRunBar draws how far the run has got, failures in the error color.
+func (m Model) renderRunBar(w int) string {
+	run := m.run
+	n := len(run.results)
+	barW := max(0, w-2)
+	failed := barW * run.count(runFailed) / n
+	done := barW*run.finished()/n - failed
+	bar := m.theme.successStyle().Render(strings.Repeat("█", done)) +
+		m.theme.errStyle().Render(strings.Repeat("█", failed)) +
+		m.theme.dim().Render(strings.Repeat("░", barW-done-failed))
+	return " " + bar
+}
+
+// runSummary counts the results, with the latency of the finished requests.
+func (m Model) runSummary() string {
+	run := m.run
+	var total time.Duration
+	timed := 0
+	for _, r := range run.results {
+		if r.state == runPassed || r.state == runFailed {
+			total += r.resp.duration
+			timed++
+		}
+	}
+	parts := []string{m.theme.successStyle().Render(fmt.Sprintf("%d passed", run.count(runPassed)))}
+	if f := run.count(runFailed); f > 0 {
+		parts = append(parts, m.theme.errStyle().Bold(true).Render(fmt.Sprintf("%d failed", f)))
+	}
+	if s := run.count(runSkipped); s > 0 {
+		parts = append(parts, m.theme.textMuted().Render(fmt.Sprintf("%d skipped", s)))
+	}
+	if timed > 0 {
+		parts = append(parts, m.theme.dim().Render("avg "+(total/time.Duration(timed)).Round(time.Millisecond).String()))
+	}
+	if run.stopped != "" {
+		parts = append(parts, m.theme.errStyle().Render(run.stopped))
+	}
+	return "  " + strings.Join(parts, m.theme.dim().Render(" · "))
+}
+
+// runRows is the results table: a row per request, followed by the reason
+// for each failure.
+func (m Model) runRows() []string {
+	run := m.run
+	dim := m.theme.dim()
+	nameW := 0
+	for _, r := range run.results {
+		nameW = max(nameW, len([]rune(r.name)))
+	}
+	nameW = min(nameW, 40)
+
+	rows := []string{dim.Render(fmt.Sprintf("    %-*s  %-7s %-24s %9s  %s", nameW, "Request", "Method", "Status", "Time", "Tests"))}
+	for _, r := range run.results {
+		var mark, status, elapsed, tests string
+		switch r.state {
+		case runPending:
+			mark = dim.Render("  · ")
+		case runActive:
+			mark = m.theme.accent().Render("  ● ")
+			status = m.theme.accent().Render(fmt.Sprintf("%-24s", "running…"))
+		case runSkipped:
+			mark = m.theme.textMuted().Render("  – ")
+			status = m.theme.textMuted().Render(fmt.Sprintf("%-24s", "skipped"))
+		case runPassed:
+			mark = m.theme.successStyle().Render("  ✓ ")
+		case runFailed:
+			mark = m.theme.errStyle().Bold(true).Render("  ✗ ")
+		}
+		if r.state == runPassed || r.state == runFailed {
+			if r.resp.err != nil {
+				status = m.theme.errStyle().Render(fmt.Sprintf("%-24s", "error"))
+			} else {
+				status = m.statusStyle(r.resp.statusCode).Render(fmt.Sprintf("%-24s", clipRunes(r.resp.status, 24)))
+			}
+			elapsed = dim.Render(fmt.Sprintf("%9s", r.resp.duration.Round(time.Millisecond)))
+			if len(r.resp.tests) > 0 {
+				passed := testsPassed(r.resp.tests)
+				style := m.theme.successStyle()
+				if passed < len(r.resp.tests) {
+					style = m.theme.errStyle().Bold(true)
+				}
+				tests = style.Render(fmt.Sprintf("%d/%d", passed, len(r.resp.tests)))
+			}
+		}
+		if status == "" {
+			status = strings.Repeat(" ", 24)
+		}
+		if elapsed == "" {
+			elapsed = strings.Repeat(" ", 9)
+		}
+		name := fmt.Sprintf("%-*s", nameW, clipRunes(r.name, nameW))
+		mStyle, _ := m.theme.methodStyle(r.method)
+		rows = append(rows, mark+m.theme.text().Render(name)+"  "+
+			mStyle.Render(fmt.Sprintf("%-7s", r.method))+" "+status+" "+elapsed+"  "+tests)
+		if r.state == runFailed {
+			rows = append(rows, m.theme.errStyle().Render("       "+runFailure(r.resp)))
+		}
+	}
+	return rows
+}
+
+// runFailure explains why a response failed: the send error or the first
+// assertion that did not hold.
+func runFailure(resp response) string {
+	if resp.err != nil {
+		return resp.err.Error()
+	}
+	for _, t := range resp.tests {
+		if !t.pass {
+			return t.spec + " · " + t.detail
+		}
+	}
+	return ""
+}
+
+// clipRunes shortens s to n runes, ending in an ellipsis when cut.
+func clipRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n <= 1 {
+		return string(r[:n])
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,267 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// What :save writes.
+const (
+	saveBody     = "body"
+	saveExchange = "exchange"
+)
+
+// defaultBodyName picks a file name for the response body: the filename of a
+// Content-Disposition header, else the last segment of the final URL, given an
+// extension from the Content-Type when it has none.
+func defaultBodyName(resp response) string {
+	if name := dispositionName(resp.header); name != "" {
+		return name
+	}
+	name := "response"
+	if u, err := url.Parse(resp.url); err == nil {
+		if base := path.Base(u.Path); base != "/" && base != "." && base != "" {
+			name = base
+		}
+	}
+	if path.Ext(name) == "" {
+		name += bodyExtension(resp)
+	}
+	return name
+}
+
+// dispositionName returns the filename suggested by a Content-Disposition
+// header, reduced to its last path element so a server cannot pick the
+// directory. filename* (RFC 5987) is decoded by mime.ParseMediaType.
+func dispositionName(h http.Header) string {
+	_, params, err := mime.ParseMediaType(h.Get("Content-Disposition"))
+	if err != nil {
+		return ""
+	}
+	name := path.Base(strings.ReplaceAll(params["filename"], `\`, "/"))
+	switch name {
+	case ".", "..", "/":
+		return ""
+	}
+	return name
+}
+
+// defaultExchangeName is the body name with a .http extension.
+func defaultExchangeName(resp response) string {
+	name := defaultBodyName(resp)
+	return strings.TrimSuffix(name, path.Ext(name)) + ".http"
+}
+
+func bodyExtension(resp response) string {
+	if f := imageFormat(resp.body); f != "" {
+		return "." + strings.ToLower(strings.Replace(f, "JPEG", "jpg", 1))
+	}
+	ct, _, _ := mime.ParseMediaType(resp.header.Get("Content-Type"))
+	switch {
+	case ct == "application/json" || strings.HasSuffix(ct, "+json"):
+		return ".json"
+	case ct == "text/plain":
+		return ".txt"
+	}
+	if exts, _ := mime.ExtensionsByType(ct); len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
+
+// writeNewFile writes data to path, refusing to replace an existing file.
+func writeNewFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		return errors.New(path + " already exists")
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// rawExchange renders the last exchange as raw HTTP/1.1 text: the request as
+// it went on the wire for the final hop, a blank line, then the response with
+// the body as received.
+func rawExchange(resp response) ([]byte, error) {
+	if resp.request == nil {
+		return nil, errors.New("the request was not recorded")
+	}
+	// The send's context is cancelled by now; dumping only needs a live one.
+	req := resp.request.Clone(context.Background())
+	req.Body = nil
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = body
+	}
+	dump, err := httputil.DumpRequestOut(req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	b.Write(dump)
+	if !bytes.HasSuffix(dump, []byte("\n")) {
+		b.WriteString("\r\n")
+	}
+	b.WriteString("\r\n")
+	fmt.Fprintf(&b, "%s %s\r\n", resp.proto, resp.status)
+	keys := make([]string, 0, len(resp.header))
+	for k := range resp.header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range resp.header[k] {
+			fmt.Fprintf(&b, "%s: %s\r\n", k, v)
+		}
+	}
+	b.WriteString("\r\n")
+	b.Write(resp.body)
+	return b.Bytes(), nil
+}
+
+// saveData returns what :save writes for kind.
+func saveData(resp response, kind string) ([]byte, error) {
+	if kind == saveExchange {
+		return rawExchange(resp)
+	}
+	return resp.body, nil
+}
+
+// saveTo writes the last response to p and describes the result.
+func (m Model) saveTo(kind, p string) (string, error) {
+	resp := *m.resp
+	if kind == saveExchange {
+		// The request as sent carries the resolved credentials.
+		resp = m.redactions.redactResponse(resp)
+	}
+	data, err := saveData(resp, kind)
+	if err != nil {
+		return "", err
+	}
+	if kind == saveExchange {
+		data = []byte(m.maskValues(string(data)))
+	}
+	if err := writeNewFile(p, data); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("saved %s (%s) to %s", kind, formatBytes(len(data)), p), nil
+}
+
+// execSave handles ":save [body|exchange] [path]". Without a path it asks
+// for one, suggesting the default name.
+func (m Model) execSave(args []string) Model {
+	if m.resp == nil || m.resp.statusCode == 0 {
+		m.cmdError = "no response to save"
+		return m
+	}
+	kind := saveBody
+	if len(args) > 0 && (args[0] == saveBody || args[0] == saveExchange) {
+		kind, args = args[0], args[1:]
+	}
+	if len(args) > 1 {
+		m.cmdError = "usage: save [body|exchange] [path]"
+		return m
+	}
+	if len(args) == 0 {
+		return m.closeCmdPalette().openSavePrompt(kind)
+	}
+	info, err := m.saveTo(kind, args[0])
+	if err != nil {
+		m.cmdError = err.Error()
+		return m
+	}
+	m.cmdInfo = info
+	return m
+}
+
+// openSavePrompt asks for the path to save to, prefilled with the default name.
+func (m Model) openSavePrompt(kind string) Model {
+	m.saving = true
+	m.saveKind = kind
+	m.saveErr = ""
+	m.saveInput = defaultBodyName(*m.resp)
+	if kind == saveExchange {
+		m.saveInput = defaultExchangeName(*m.resp)
+	}
+	return m
+}
+
+// updateSavePrompt handles keys while the save path is being typed. A failed
+// write keeps the prompt open so the path can be corrected.
+func (m Model) updateSavePrompt(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.saving = false
+		m.saveInput = ""
+	case "enter":
+		p := strings.TrimSpace(m.saveInput)
+		if p == "" {
+			m.saveErr = "no path"
+			return m, nil
+		}
+		info, err := m.saveTo(m.saveKind, p)
+		if err != nil {
+			m.saveErr = err.Error()
+			return m, nil
+		}
+		m.saving = false
+		m.saveInput = ""
+		m.notice = info
+	case "ctrl+u":
+		m.saveInput = ""
+	case "backspace":
+		runes := []rune(m.saveInput)
+		if len(runes) > 0 {
+			m.saveInput = string(runes[:len(runes)-1])
+		}
+		m.saveErr = ""
+	default:
+		if len([]rune(msg.String())) == 1 {
+			m.saveInput += msg.String()
+			m.saveErr = ""
+		}
+	}
+	return m, nil
+}
+
+// renderSavePrompt replaces the footer while the save path is being typed.
+func (m Model) renderSavePrompt() string {
+	dim := m.theme.dim()
+	left := dim.Render(" Save "+m.saveKind+" to: ") +
+		m.theme.text().Render(m.saveInput) +
+		m.theme.accent().Render("█")
+	right := "  " + m.theme.keyHint("enter") + dim.Render("save") +
+		"  " + m.theme.keyHint("esc") + dim.Render("cancel")
+	if m.saveErr != "" {
+		right = m.theme.errStyle().Render("  " + m.saveErr)
+	}
+	gap := m.width - lipgloss.Width(left) - lipgloss.Width(right)
+	if gap < 1 {
+		gap = 1
+	}
+	return lipgloss.NewStyle().MaxWidth(m.width).Render(left + strings.Repeat(" ", gap) + right)
+}
@@ -0,0 +1,323 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Bounds of one validation: the errors collected, how deep $refs nest
+// along one path, and the $ref expansions in all, which keep recursive
+// schemas from running forever.
+const (
+	maxSchemaErrors   = 20
+	maxSchemaRefDepth = 64
+	maxSchemaRefs     = 200_000
+)
+
+// validateSchema checks v against a JSON Schema and returns the violations,
+// each prefixed with the JSON path of the offending value. It covers the
+// subset APIs usually rely on: type, enum, const, properties, required,
+// additionalProperties, items, length and range limits, pattern, multipleOf,
+// allOf / anyOf / oneOf / not and local $ref pointers.
+func validateSchema(schema, v any) []string {
+	s := &schemaValidator{root: schema, refs: new(int)}
+	s.validate(schema, v, "$")
+	if *s.refs > maxSchemaRefs {
+		s.fail("$", "gave up after %d $ref expansions", maxSchemaRefs)
+	}
+	return s.errs
+}
+
+type schemaValidator struct {
+	root  any
+	errs  []string
+	depth int  // $refs entered along the current path
+	refs  *int // $ref expansions of the whole validation
+}
+
+func (s *schemaValidator) fail(path, format string, args ...any) {
+	if len(s.errs) < maxSchemaErrors {
+		s.errs = append(s.errs, path+": "+fmt.Sprintf(format, args...))
+	}
+}
+
+// valid reports whether v satisfies schema without recording errors.
+func (s *schemaValidator) valid(schema, v any, path string) bool {
+	sub := &schemaValidator{root: s.root, depth: s.depth, refs: s.refs}
+	sub.validate(schema, v, path)
+	return len(sub.errs) == 0
+}
+
+func (s *schemaValidator) validate(schema, v any, path string) {
+	if b, ok := schema.(bool); ok {
+		if !b {
+			s.fail(path, "not allowed")
+		}
+		return
+	}
+	sc, ok := schema.(*jqObject)
+	if !ok {
+		return
+	}
+	get := func(k string) any { v, _ := sc.get(k); return v }
+
+	if ref, ok := get("$ref").(string); ok {
+		if *s.refs++; *s.refs > maxSchemaRefs {
+			return // reported once, by validateSchema
+		}
+		if s.depth >= maxSchemaRefDepth {
+			s.fail(path, "$ref nests too deep")
+			return
+		}
+		target, ok := schemaPointer(s.root, ref)
+		if !ok {
+			s.fail(path, "unresolved $ref %s", ref)
+			return
+		}
+		s.depth++
+		s.validate(target, v, path)
+		s.depth--
+		return
+	}
+
+	if types := schemaTypes(get("type")); len(types) > 0 && !typeMatches(types, v) {
+		s.fail(path, "expected %s, got %s", strings.Join(types, " or "), jqType(v))
+		return
+	}
+	if enum, ok := get("enum").([]any); ok {
+		found := false
+		for _, e := range enum {
+			if canonicalJSON(e) == canonicalJSON(v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			s.fail(path, "%s is not one of the allowed values", clip(jqScalarText(v)))
+		}
+	}
+	if c, ok := sc.get("const"); ok && canonicalJSON(c) != canonicalJSON(v) {
+		s.fail(path, "expected %s, got %s", clip(jqScalarText(c)), clip(jqScalarText(v)))
+	}
+
+	switch val := v.(type) {
+	case *jqObject:
+		s.object(sc, val, path)
+	case []any:
+		s.array(sc, val, path)
+	case string:
+		n := utf8.RuneCountInString(val)
+		if lim, ok := jqToFloat(get("minLength")); ok && float64(n) < lim {
+			s.fail(path, "shorter than %v characters", lim)
+		}
+		if lim, ok := jqToFloat(get("maxLength")); ok && float64(n) > lim {
+			s.fail(path, "longer than %v characters", lim)
+		}
+		if p, ok := get("pattern").(string); ok {
+			if re, err := regexp.Compile(p); err == nil && !re.MatchString(val) {
+				s.fail(path, "does not match %s", p)
+			}
+		}
+	default:
+		if f, ok := jqToFloat(v); ok {
+			s.number(sc, f, path)
+		}
+	}
+
+	if all, ok := get("allOf").([]any); ok {
+		for _, sub := range all {
+			s.validate(sub, v, path)
+		}
+	}
+	if anyOf, ok := get("anyOf").([]any); ok {
+		matched := false
+		for _, sub := range anyOf {
+			if s.valid(sub, v, path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			s.fail(path, "matches none of anyOf")
+		}
+	}
+	if one, ok := get("oneOf").([]any); ok {
+		n := 0
+		for _, sub := range one {
+			if s.valid(sub, v, path) {
+				n++
+			}
+		}
+		if n != 1 {
+			s.fail(path, "matches %d of oneOf, want exactly 1", n)
+		}
+	}
+	if not, ok := sc.get("not"); ok && s.valid(not, v, path) {
+		s.fail(path, "matches a schema it must not")
+	}
+}
+
+func (s *schemaValidator) object(sc *jqObject, obj *jqObject, path string) {
+	get := func(k string) any { v, _ := sc.get(k); return v }
+	if req, ok := get("required").([]any); ok {
+		for _, r := range req {
+			if k, ok := r.(string); ok {
+				if _, ok := obj.get(k); !ok {
+					s.fail(path, "missing required property %q", k)
+				}
+			}
+		}
+	}
+	if lim, ok := jqToFloat(get("minProperties")); ok && float64(len(obj.keys)) < lim {
+		s.fail(path, "fewer than %v properties", lim)
+	}
+	if lim, ok := jqToFloat(get("maxProperties")); ok && float64(len(obj.keys)) > lim {
+		s.fail(path, "more than %v properties", lim)
+	}
+	props, _ := get("properties").(*jqObject)
+	additional, hasAdditional := sc.get("additionalProperties")
+	for _, k := range obj.keys {
+		child := schemaChildPath(path, k)
+		if props != nil {
+			if ps, ok := props.get(k); ok {
+				s.validate(ps, obj.vals[k], child)
+				continue
+			}
+		}
+		if hasAdditional {
+			if b, ok := additional.(bool); ok && !b {
+				s.fail(path, "unexpected property %q", k)
+				continue
+			}
+			s.validate(additional, obj.vals[k], child)
+		}
+	}
+}
+
+func (s *schemaValidator) array(sc *jqObject, arr []any, path string) {
+	get := func(k string) any { v, _ := sc.get(k); return v }
+	if lim, ok := jqToFloat(get("minItems")); ok && float64(len(arr)) < lim {
+		s.fail(path, "fewer than %v items", lim)
+	}
+	if lim, ok := jqToFloat(get("maxItems")); ok && float64(len(arr)) > lim {
+		s.fail(path, "more than %v items", lim)
+	}
+	if u, ok := get("uniqueItems").(bool); ok && u {
+		seen := map[string]bool{}
+		for i, e := range arr {
+			k := canonicalJSON(e)
+			if seen[k] {
+				s.fail(path+"["+strconv.Itoa(i)+"]", "duplicate item")
+			}
+			seen[k] = true
+		}
+	}
+	if items, ok := sc.get("items"); ok {
+		for i, e := range arr {
+			s.validate(items, e, path+"["+strconv.Itoa(i)+"]")
+		}
+	}
+}
+
+func (s *schemaValidator) number(sc *jqObject, f float64, path string) {
+	get := func(k string) any { v, _ := sc.get(k); return v }
+	// draft 4 spells exclusive limits as booleans next to minimum / maximum
+	exMin, _ := get("exclusiveMinimum").(bool)
+	exMax, _ := get("exclusiveMaximum").(bool)
+	if lim, ok := jqToFloat(get("minimum")); ok && (f < lim || exMin && f == lim) {
+		s.fail(path, "%v is below the minimum %v", f, lim)
+	}
+	if lim, ok := jqToFloat(get("maximum")); ok && (f > lim || exMax && f == lim) {
+		s.fail(path, "%v is above the maximum %v", f, lim)
+	}
+	if lim, ok := jqToFloat(get("exclusiveMinimum")); ok && f <= lim {
+		s.fail(path, "%v is not above %v", f, lim)
+	}
+	if lim, ok := jqToFloat(get("exclusiveMaximum")); ok && f >= lim {
+		s.fail(path, "%v is not below %v", f, lim)
+	}
+	if m, ok := jqToFloat(get("multipleOf")); ok && m > 0 {
+		if q := f / m; math.Abs(q-math.Round(q)) > 1e-9 {
+			s.fail(path, "%v is not a multiple of %v", f, m)
+		}
+	}
+}
+
+func schemaTypes(v any) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var types []string
+		for _, e := range t {
+			if s, ok := e.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func typeMatches(types []string, v any) bool {
+	got := jqType(v)
+	for _, t := range types {
+		if t == got {
+			return true
+		}
+		if t == "integer" {
+			if f, ok := jqToFloat(v); ok && f == math.Trunc(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// schemaChildPath appends a property to a JSON path, quoting names that are
+// not plain identifiers.
+func schemaChildPath(path, k string) string {
+	for i, r := range k {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return path + "[" + strconv.Quote(k) + "]"
+		}
+	}
+	if k == "" {
+		return path + `[""]`
+	}
+	return path + "." + k
+}
+
+// schemaPointer resolves a local reference such as "#/$defs/user".
+func schemaPointer(root any, ref string) (any, bool) {
+	if ref == "#" {
+		return root, true
+	}
+	p, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil, false
+	}
+	cur := root
+	for _, tok := range strings.Split(p, "/") {
+		tok = strings.NewReplacer("~1", "/", "~0", "~").Replace(tok)
+		switch c := cur.(type) {
+		case *jqObject:
+			if cur, ok = c.get(tok); !ok {
+				return nil, false
+			}
+		case []any:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, false
+			}
+			cur = c[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
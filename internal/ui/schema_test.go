@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name, schema, doc string
+		want              []string
+	}{
+		{"type", `{"type": "object"}`, `[]`, []string{"$: expected object, got array"}},
+		{"type list", `{"type": ["string", "null"]}`, `null`, nil},
+		{"integer", `{"type": "integer"}`, `2.5`, []string{"$: expected integer, got number"}},
+		{"integer as float", `{"type": "integer"}`, `2.0`, nil},
+		{"enum", `{"enum": ["a", 1]}`, `"b"`, []string{`$: "b" is not one of the allowed values`}},
+		{"const", `{"const": {"a": [1]}}`, `{"a": [1]}`, nil},
+		{"required and properties",
+			`{"type": "object", "required": ["id", "name"], "properties": {"id": {"type": "integer"}, "odd key": {"type": "string"}}}`,
+			`{"id": "7", "odd key": 1}`,
+			[]string{`$: missing required property "name"`, "$.id: expected integer, got string", `$["odd key"]: expected string, got number`}},
+		{"additionalProperties false", `{"properties": {"a": {}}, "additionalProperties": false}`, `{"a": 1, "b": 2}`, []string{`$: unexpected property "b"`}},
+		{"additionalProperties schema", `{"additionalProperties": {"type": "number"}}`, `{"a": 1, "b": "x"}`, []string{"$.b: expected number, got string"}},
+		{"items and limits", `{"items": {"minimum": 0}, "minItems": 3, "uniqueItems": true}`, `[1, -1, 1]`,
+			[]string{"$[2]: duplicate item", "$[1]: -1 is below the minimum 0"}},
+		{"string limits", `{"minLength": 2, "maxLength": 3, "pattern": "^[a-z]+$"}`, `"héllo"`,
+			[]string{"$: longer than 3 characters", "$: does not match ^[a-z]+$"}},
+		{"exclusive limits", `{"exclusiveMinimum": 0, "exclusiveMaximum": 10}`, `10`, []string{"$: 10 is not below 10"}},
+		{"draft 4 exclusive", `{"minimum": 0, "exclusiveMinimum": true}`, `0`, []string{"$: 0 is below the minimum 0"}},
+		{"multipleOf", `{"multipleOf": 0.1}`, `0.3`, nil},
+		{"not a multiple", `{"multipleOf": 3}`, `10`, []string{"$: 10 is not a multiple of 3"}},
+		{"anyOf", `{"anyOf": [{"type": "string"}, {"type": "number"}]}`, `true`, []string{"$: matches none of anyOf"}},
+		{"oneOf", `{"oneOf": [{"type": "number"}, {"minimum": 0}]}`, `5`, []string{"$: matches 2 of oneOf, want exactly 1"}},
+		{"allOf", `{"allOf": [{"type": "number"}, {"maximum": 1}]}`, `2`, []string{"$: 2 is above the maximum 1"}},
+		{"not", `{"not": {"type": "null"}}`, `null`, []string{"$: matches a schema it must not"}},
+		{"false schema", `{"properties": {"a": false}}`, `{"a": 1}`, []string{"$.a: not allowed"}},
+		{"$ref", `{"$defs": {"id": {"type": "integer"}}, "properties": {"id": {"$ref": "#/$defs/id"}}}`, `{"id": "x"}`,
+			[]string{"$.id: expected integer, got string"}},
+		{"escaped $ref", `{"definitions": {"a/b": {"const": 1}}, "$ref": "#/definitions/a~1b"}`, `2`, []string{"$: expected 1, got 2"}},
+		{"unresolved $ref", `{"$ref": "#/nowhere"}`, `1`, []string{"$: unresolved $ref #/nowhere"}},
+		{"remote $ref", `{"$ref": "https://example.com/s.json"}`, `1`, []string{"$: unresolved $ref https://example.com/s.json"}},
+		{"recursive $ref", `{"type": "object", "properties": {"next": {"$ref": "#"}}}`, `{"next": {"next": {"next": 1}}}`,
+			[]string{"$.next.next.next: expected object, got number"}},
+		{"ignored keywords", `{"format": "email", "type": "string"}`, `"x"`, nil},
+	}
+	for _, tt := range tests {
+		schema, err := jqDecode([]byte(tt.schema))
+		if err != nil {
+			t.Fatalf("%s: schema: %v", tt.name, err)
+		}
+		doc, err := jqDecode([]byte(tt.doc))
+		if err != nil {
+			t.Fatalf("%s: doc: %v", tt.name, err)
+		}
+		if got := validateSchema(schema, doc); !slices.Equal(got, tt.want) {
+			t.Errorf("%s:\n got %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateSchemaBounds(t *testing.T) {
+	strs, _ := jqDecode([]byte(`{"items": {"type": "string"}}`))
+	if got := validateSchema(strs, slices.Repeat([]any{1.0}, 50)); len(got) != maxSchemaErrors {
+		t.Errorf("%d errors, want them capped at %d", len(got), maxSchemaErrors)
+	}
+
+	// Schemas that only ever refer to themselves must still finish.
+	for _, s := range []string{
+		`{"$ref": "#"}`,
+		`{"anyOf": [{"$ref": "#"}, {"$ref": "#"}]}`,
+		`{"oneOf": [{"$ref": "#"}, {"$ref": "#"}, {"not": {"$ref": "#"}}]}`,
+	} {
+		schema, _ := jqDecode([]byte(s))
+		done := make(chan []string, 1)
+		go func() { done <- validateSchema(schema, 1.0) }()
+		select {
+		case errs := <-done:
+			if len(errs) == 0 {
+				t.Errorf("%s: valid", s)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: validation does not finish", s)
+		}
+	}
+}
+
+func TestValidateSchemaRefsAlongAPath(t *testing.T) {
+	// A $ref per item is no deeper than one.
+	schema, _ := jqDecode([]byte(`{"$defs": {"id": {"type": "integer"}}, "items": {"$ref": "#/$defs/id"}}`))
+	ids := make([]any, 1500)
+	for i := range ids {
+		ids[i] = float64(i)
+	}
+	if errs := validateSchema(schema, ids); len(errs) != 0 {
+		t.Errorf("1500 items: %q", errs)
+	}
+	ids[1499] = "x"
+	if errs := validateSchema(schema, ids); !slices.Equal(errs, []string{"$[1499]: expected integer, got string"}) {
+		t.Errorf("1500 items, the last bad: %q", errs)
+	}
+
+	// A list nests a $ref per level.
+	list, _ := jqDecode([]byte(`{"type": "object", "properties": {"next": {"$ref": "#"}}}`))
+	nested := func(n int) any {
+		v, _ := jqDecode([]byte(strings.Repeat(`{"next": `, n) + "{}" + strings.Repeat("}", n)))
+		return v
+	}
+	if errs := validateSchema(list, nested(maxSchemaRefDepth)); len(errs) != 0 {
+		t.Errorf("%d levels: %q", maxSchemaRefDepth, errs)
+	}
+	errs := validateSchema(list, nested(maxSchemaRefDepth+1))
+	if len(errs) != 1 || !strings.HasSuffix(errs[0], ": $ref nests too deep") {
+		t.Errorf("%d levels: %q", maxSchemaRefDepth+1, errs)
+	}
+}
@@ -0,0 +1,526 @@
+package ui
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dop251/goja"
+)
+
+// scriptTimeout bounds one script, so a loop that never ends cannot hang a send.
+const scriptTimeout = 5 * time.Second
+
+// Script phases.
+const (
+	scriptPre  = "pre"
+	scriptPost = "post"
+)
+
+// consoleLine is one console.log (or .info, .warn, .error) call of a script.
+type consoleLine struct {
+	phase string // scriptPre or scriptPost
+	level string // log, info, warn, error, or debug
+	text  string
+}
+
+// envChange is a variable a script set or unset, applied to the active
+// environment when the send completes.
+type envChange struct {
+	key   string
+	value string
+	unset bool
+}
+
+// with returns a copy of e with key set to value.
+func (e environment) with(key, value string) environment {
+	vars := make([]variable, 0, len(e.vars)+1)
+	found := false
+	for _, v := range e.vars {
+		if v.key == key {
+			v.value, found = value, true
+		}
+		vars = append(vars, v)
+	}
+	if !found {
+		vars = append(vars, variable{key: key, value: value})
+	}
+	e.vars = vars
+	return e
+}
+
+// without returns a copy of e without key.
+func (e environment) without(key string) environment {
+	vars := make([]variable, 0, len(e.vars))
+	for _, v := range e.vars {
+		if v.key != key {
+			vars = append(vars, v)
+		}
+	}
+	e.vars = vars
+	return e
+}
+
+// apply returns e with changes made, in order.
+func (e environment) apply(changes []envChange) environment {
+	for _, c := range changes {
+		if c.unset {
+			e = e.without(c.key)
+		} else {
+			e = e.with(c.key, c.value)
+		}
+	}
+	return e
+}
+
+// applyEnvChanges records the variables set by scripts in the environment
+// they ran against.
+func (m Model) applyEnvChanges(name string, changes []envChange) Model {
+	if len(changes) == 0 {
+		return m
+	}
+	envs := append([]environment(nil), m.envs...)
+	for i := range envs {
+		if envs[i].name == name {
+			envs[i] = envs[i].apply(changes)
+		}
+	}
+	m.envs = envs
+	return m
+}
+
+// scriptRun carries the state the scripts of one send share: the environment
+// as they left it, what they logged and the tests they declared.
+type scriptRun struct {
+	env     environment
+	changes []envChange
+	console []consoleLine
+	tests   []testResult
+}
+
+// pre runs the pre-request scripts of the folder, then of the request, which
+// may change r before it is built.
+func (s *scriptRun) pre(ctx context.Context, f folder, r *request) error {
+	for _, sc := range []struct{ scope, src string }{{"folder", f.preScript}, {"request", r.preScript}} {
+		if strings.TrimSpace(sc.src) == "" {
+			continue
+		}
+		err := s.run(ctx, scriptPre, sc.src, func(vm *goja.Runtime) {
+			vm.Set("request", s.requestObject(vm, r, true))
+		})
+		if err != nil {
+			return fmt.Errorf("%s pre-request script: %w", sc.scope, err)
+		}
+	}
+	return nil
+}
+
+// post runs the post-response scripts of the folder, then of the request. A
+// script that throws is recorded as a failed test.
+func (s *scriptRun) post(ctx context.Context, f folder, r request, resp response) {
+	for _, sc := range []struct{ scope, src string }{{"folder", f.postScript}, {"request", r.postScript}} {
+		if strings.TrimSpace(sc.src) == "" {
+			continue
+		}
+		err := s.run(ctx, scriptPost, sc.src, func(vm *goja.Runtime) {
+			vm.Set("request", s.requestObject(vm, &r, false))
+			vm.Set("response", responseObject(vm, resp))
+		})
+		if err != nil {
+			s.tests = append(s.tests, testResult{spec: sc.scope + " post-response script", detail: err.Error()})
+		}
+	}
+}
+
+// run evaluates src in a fresh interpreter. Scripts see only the objects
+// bound here: there is no file, network or process access. A script is
+// interrupted when ctx is cancelled or after scriptTimeout.
+func (s *scriptRun) run(ctx context.Context, phase, src string, bind func(vm *goja.Runtime)) error {
+	vm := goja.New()
+	vm.SetMaxCallStackSize(1024)
+	stop := context.AfterFunc(ctx, func() { vm.Interrupt(ctx.Err()) })
+	defer stop()
+	timer := time.AfterFunc(scriptTimeout, func() {
+		vm.Interrupt(fmt.Errorf("timed out after %s", scriptTimeout))
+	})
+	defer timer.Stop()
+
+	s.bindGlobals(vm, phase)
+	bind(vm)
+	_, err := vm.RunString(src)
+	var interrupted *goja.InterruptedError
+	if errors.As(err, &interrupted) {
+		if cause, ok := interrupted.Value().(error); ok {
+			return cause
+		}
+	}
+	var exc *goja.Exception
+	if errors.As(err, &exc) {
+		return errors.New(exc.Error())
+	}
+	return err
+}
+
+// bindGlobals installs console, env, test, assert, crypto, btoa and atob.
+func (s *scriptRun) bindGlobals(vm *goja.Runtime, phase string) {
+	console := vm.NewObject()
+	for _, level := range []string{"log", "info", "warn", "error", "debug"} {
+		console.Set(level, func(call goja.FunctionCall) goja.Value {
+			parts := make([]string, len(call.Arguments))
+			for i, a := range call.Arguments {
+				parts[i] = scriptString(vm, a)
+			}
+			s.console = append(s.console, consoleLine{phase: phase, level: level, text: strings.Join(parts, " ")})
+			return goja.Undefined()
+		})
+	}
+	vm.Set("console", console)
+
+	env := vm.NewObject()
+	env.Set("get", func(key string) goja.Value {
+		if v, ok := s.env.lookup(key); ok {
+			return vm.ToValue(v)
+		}
+		return goja.Undefined()
+	})
+	env.Set("has", func(key string) bool {
+		_, ok := s.env.lookup(key)
+		return ok
+	})
+	env.Set("set", func(key string, value goja.Value) {
+		v := scriptString(vm, value)
+		s.env = s.env.with(key, v)
+		s.changes = append(s.changes, envChange{key: key, value: v})
+	})
+	env.Set("unset", func(key string) {
+		s.env = s.env.without(key)
+		s.changes = append(s.changes, envChange{key: key, unset: true})
+	})
+	env.Set("name", s.env.name)
+	vm.Set("env", env)
+
+	vm.Set("test", func(name string, fn goja.Callable) {
+		res := testResult{spec: name, pass: true}
+		if _, err := fn(goja.Undefined()); err != nil {
+			res.pass = false
+			res.detail = err.Error()
+			var exc *goja.Exception
+			if errors.As(err, &exc) {
+				res.detail = exc.Value().String()
+			}
+		}
+		s.tests = append(s.tests, res)
+	})
+	vm.Set("assert", func(call goja.FunctionCall) goja.Value {
+		if !call.Argument(0).ToBoolean() {
+			msg := "assertion failed"
+			if len(call.Arguments) > 1 {
+				msg = scriptString(vm, call.Argument(1))
+			}
+			panic(vm.NewGoError(errors.New(msg)))
+		}
+		return goja.Undefined()
+	})
+
+	crypto := vm.NewObject()
+	crypto.Set("hmac", func(alg, key, data string, enc goja.Value) string {
+		h, err := scriptHash(alg)
+		if err != nil {
+			panic(vm.NewGoError(err))
+		}
+		mac := hmac.New(h, []byte(key))
+		mac.Write([]byte(data))
+		return scriptEncode(vm, mac.Sum(nil), enc)
+	})
+	crypto.Set("hash", func(alg, data string, enc goja.Value) string {
+		h, err := scriptHash(alg)
+		if err != nil {
+			panic(vm.NewGoError(err))
+		}
+		d := h()
+		d.Write([]byte(data))
+		return scriptEncode(vm, d.Sum(nil), enc)
+	})
+	crypto.Set("randomBytes", func(n int, enc goja.Value) string {
+		if n < 0 || n > 1<<16 {
+			panic(vm.NewGoError(fmt.Errorf("randomBytes: %d is out of range", n)))
+		}
+		b := make([]byte, n)
+		rand.Read(b)
+		return scriptEncode(vm, b, enc)
+	})
+	crypto.Set("uuid", newUUID)
+	vm.Set("crypto", crypto)
+
+	vm.Set("btoa", func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) })
+	vm.Set("atob", func(s string) string {
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			panic(vm.NewGoError(err))
+		}
+		return string(b)
+	})
+}
+
+// requestObject exposes r to a script. Before the send, assignments to
+// method, url and body and the header and param setters change r; after it,
+// the object is a read-only view.
+func (s *scriptRun) requestObject(vm *goja.Runtime, r *request, writable bool) *goja.Object {
+	obj := vm.NewObject()
+	field := func(name string, p *string) {
+		obj.DefineAccessorProperty(name,
+			vm.ToValue(func() string { return *p }),
+			vm.ToValue(func(v goja.Value) {
+				if writable {
+					*p = scriptString(vm, v)
+				}
+			}), goja.FLAG_FALSE, goja.FLAG_TRUE)
+	}
+	field("method", &r.method)
+	field("url", &r.url)
+	field("body", &r.body)
+	obj.Set("name", r.name)
+
+	obj.Set("header", func(name string) goja.Value {
+		for _, h := range r.headers {
+			if strings.EqualFold(h.key, name) {
+				return vm.ToValue(h.value)
+			}
+		}
+		return goja.Undefined()
+	})
+	obj.Set("param", func(name string) goja.Value {
+		for _, p := range r.params {
+			if p.key == name {
+				return vm.ToValue(p.value)
+			}
+		}
+		return goja.Undefined()
+	})
+	if !writable {
+		return obj
+	}
+	obj.Set("setHeader", func(name, value string) {
+		headers := make([]header, 0, len(r.headers)+1)
+		for _, h := range r.headers {
+			if !strings.EqualFold(h.key, name) {
+				headers = append(headers, h)
+			}
+		}
+		r.headers = append(headers, header{key: name, value: value})
+	})
+	obj.Set("removeHeader", func(name string) {
+		headers := make([]header, 0, len(r.headers))
+		for _, h := range r.headers {
+			if !strings.EqualFold(h.key, name) {
+				headers = append(headers, h)
+			}
+		}
+		r.headers = headers
+	})
+	obj.Set("setParam", func(name, value string) {
+		params := make([]param, 0, len(r.params)+1)
+		for _, p := range r.params {
+			if p.key != name {
+				params = append(params, p)
+			}
+		}
+		r.params = append(params, param{key: name, value: value})
+	})
+	obj.Set("removeParam", func(name string) {
+		params := make([]param, 0, len(r.params))
+		for _, p := range r.params {
+			if p.key != name {
+				params = append(params, p)
+			}
+		}
+		r.params = params
+	})
+	return obj
+}
+
+// responseObject exposes a response to a post-response script.
+func responseObject(vm *goja.Runtime, resp response) *goja.Object {
+	obj := vm.NewObject()
+	obj.Set("code", resp.statusCode)
+	obj.Set("status", resp.status)
+	obj.Set("url", resp.url)
+	obj.Set("body", string(resp.body))
+	obj.Set("size", len(resp.body))
+	obj.Set("duration", millis(resp.duration))
+	obj.Set("header", func(name string) goja.Value {
+		if v := resp.header.Values(name); len(v) > 0 {
+			return vm.ToValue(strings.Join(v, ", "))
+		}
+		return goja.Undefined()
+	})
+	obj.Set("json", func() goja.Value {
+		var v any
+		if err := json.Unmarshal(resp.body, &v); err != nil {
+			panic(vm.NewGoError(fmt.Errorf("body is not JSON: %w", err)))
+		}
+		return vm.ToValue(v)
+	})
+	return obj
+}
+
+// scriptString renders a script value the way console.log shows it: strings
+// as they are, objects and arrays as JSON.
+func scriptString(vm *goja.Runtime, v goja.Value) string {
+	if v == nil || goja.IsUndefined(v) {
+		return "undefined"
+	}
+	if obj, ok := v.(*goja.Object); ok && obj.ClassName() != "Function" && obj.ClassName() != "Error" {
+		if b, err := json.Marshal(obj.Export()); err == nil {
+			return string(b)
+		}
+	}
+	return v.String()
+}
+
+func scriptHash(alg string) (func() hash.Hash, error) {
+	switch strings.ToLower(strings.ReplaceAll(alg, "-", "")) {
+	case "md5":
+		return md5.New, nil
+	case "sha1":
+		return sha1.New, nil
+	case "sha256":
+		return sha256.New, nil
+	case "sha512":
+		return sha512.New, nil
+	}
+	return nil, fmt.Errorf("unknown hash %q (md5, sha1, sha256 or sha512)", alg)
+}
+
+// scriptEncode encodes a digest as hex (the default), base64 or base64url.
+func scriptEncode(vm *goja.Runtime, b []byte, enc goja.Value) string {
+	name := "hex"
+	if enc != nil && !goja.IsUndefined(enc) {
+		name = enc.String()
+	}
+	switch name {
+	case "hex":
+		return hex.EncodeToString(b)
+	case "base64":
+		return base64.StdEncoding.EncodeToString(b)
+	case "base64url":
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	panic(vm.NewGoError(fmt.Errorf("unknown encoding %q (hex, base64 or base64url)", name)))
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// execScript handles ":script [folder] pre|post <file|code|off>". The script
+// is read from the file when one exists at that path and taken as code
+// otherwise. Alone, it lists the scripts in effect.
+func (m Model) execScript(spec string) Model {
+	if m.activeFolderIdx < 0 {
+		m.cmdError = "no request selected"
+		return m
+	}
+	f := &m.folders[m.activeFolderIdx]
+	r := &f.requests[m.activeReqIdx]
+	if spec == "" {
+		var set []string
+		for _, sc := range []struct{ name, src string }{
+			{"folder pre", f.preScript}, {"folder post", f.postScript},
+			{"pre", r.preScript}, {"post", r.postScript},
+		} {
+			if sc.src != "" {
+				set = append(set, sc.name+" ("+plural(strings.Count(strings.TrimRight(sc.src, "\n"), "\n")+1, "line")+")")
+			}
+		}
+		if len(set) == 0 {
+			m.cmdInfo = "no scripts"
+		} else {
+			m.cmdInfo = "scripts: " + strings.Join(set, ", ")
+		}
+		return m
+	}
+
+	scope, rest := cutWord(spec)
+	pre, post := &r.preScript, &r.postScript
+	if scope == "folder" {
+		pre, post = &f.preScript, &f.postScript
+	} else {
+		rest = spec
+		scope = "request"
+	}
+	phase, src := cutWord(rest)
+	var target *string
+	switch phase {
+	case scriptPre:
+		target = pre
+	case scriptPost:
+		target = post
+	default:
+		m.cmdError = "usage: script [folder] pre|post <file|code|off>"
+		return m
+	}
+	switch {
+	case src == "":
+		m.cmdError = "usage: script [folder] pre|post <file|code|off>"
+		return m
+	case src == "off":
+		*target = ""
+		m.cmdInfo = "removed the " + scope + " " + phase + " script"
+		return m
+	}
+	if data, err := os.ReadFile(src); err == nil {
+		src = string(data)
+	}
+	if _, err := goja.Compile("", src, false); err != nil {
+		m.cmdError = err.Error()
+		return m
+	}
+	*target = src
+	m.cmdInfo = "set the " + scope + " " + phase + " script"
+	return m
+}
+
+// renderConsole lists what the scripts of the last send logged.
+func (m Model) renderConsole(resp response, w int) string {
+	dim := m.theme.dim()
+	if len(resp.console) == 0 {
+		return dim.Render("  nothing logged — scripts write here with console.log")
+	}
+	var lines []string
+	for _, l := range resp.console {
+		style := m.theme.text()
+		switch l.level {
+		case "error":
+			style = m.theme.errStyle()
+		case "warn":
+			style = lipgloss.NewStyle().Foreground(m.theme.MethodPATCH)
+		case "debug":
+			style = m.theme.textMuted()
+		}
+		prefix := dim.Render(fmt.Sprintf("  %-4s ", l.phase))
+		for _, text := range strings.Split(m.presentBody(l.text), "\n") {
+			lines = append(lines, lipgloss.NewStyle().MaxWidth(w).Render(prefix+style.Render(printable(text))))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,299 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxBodyMatches bounds the match list for patterns that hit nearly every line.
+const maxBodyMatches = 100000
+
+// bodyMatch is one hit of the body search: byte offsets into the plain text
+// of a line. For JSON the line is a doc line, so hits inside folded nodes
+// are found too; otherwise it is a row of the formatted body.
+type bodyMatch struct {
+	line, start, end int
+}
+
+// piece is a styled run of a rendered line. Lines are built from pieces so
+// search hits can be highlighted across style boundaries.
+type piece struct {
+	style lipgloss.Style
+	text  string
+}
+
+// searchLines returns the searchable lines of the body on screen.
+func (m Model) searchLines() (n int, text func(i int) string, ok bool) {
+	if m.responseTab != respTabBody {
+		return 0, nil, false
+	}
+	if m.showingJSON() {
+		lines := m.jv.doc.lines
+		return len(lines), func(i int) string { return jsonLineText(lines[i]) }, true
+	}
+	if m.pretty != nil && m.pretty.format != formatImage {
+		p := m.pretty
+		return p.lineCount(), func(i int) string { return synLineText(p.line(i)) }, true
+	}
+	return 0, nil, false
+}
+
+// compileSearch turns the query into a regexp. Plain queries match literally;
+// both kinds are smart-case, like the collection search.
+func compileSearch(query string, regex bool) (*regexp.Regexp, error) {
+	pattern := query
+	if !regex {
+		pattern = regexp.QuoteMeta(query)
+	}
+	if !strings.ContainsFunc(query, unicode.IsUpper) {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// runSearch finds every hit of searchQuery and jumps to the first one at or
+// after the current position.
+func (m Model) runSearch() Model {
+	m.searchMatches = nil
+	m.searchErr = ""
+	n, text, ok := m.searchLines()
+	if !ok || m.searchQuery == "" {
+		return m
+	}
+	re, err := compileSearch(m.searchQuery, m.searchRegex)
+	if err != nil {
+		m.searchErr = "bad regex"
+		var se *syntax.Error
+		if errors.As(err, &se) {
+			m.searchErr += ": " + se.Code.String()
+		}
+		return m
+	}
+	for i := 0; i < n && len(m.searchMatches) < maxBodyMatches; i++ {
+		for _, loc := range re.FindAllStringIndex(text(i), -1) {
+			if loc[1] > loc[0] {
+				m.searchMatches = append(m.searchMatches, bodyMatch{i, loc[0], loc[1]})
+			}
+		}
+	}
+	if len(m.searchMatches) == 0 {
+		return m
+	}
+	from := m.respScroll
+	if m.showingJSON() && m.bodyCursor < len(m.jv.visible) {
+		from = m.jv.visible[m.bodyCursor]
+	}
+	m.searchIdx = sort.Search(len(m.searchMatches), func(i int) bool { return m.searchMatches[i].line >= from })
+	if m.searchIdx == len(m.searchMatches) {
+		m.searchIdx = 0
+	}
+	return m.showMatch()
+}
+
+// clearSearch drops the hits, which only stay valid while the lines do.
+func (m Model) clearSearch() Model {
+	m.searchQuery = ""
+	m.searchMatches = nil
+	m.searchErr = ""
+	return m
+}
+
+// showMatch scrolls the current hit into view, unfolding JSON nodes around it.
+func (m Model) showMatch() Model {
+	if len(m.searchMatches) == 0 {
+		return m
+	}
+	line := m.searchMatches[m.searchIdx].line
+	page := max(1, m.responseBodyHeight()-1)
+	if m.showingJSON() {
+		v := m.jv
+		changed := false
+		for open := range v.folded {
+			if open < line && line <= v.doc.lines[open].match {
+				delete(v.folded, open)
+				changed = true
+			}
+		}
+		if changed {
+			v.rebuild()
+		}
+		m.bodyCursor = v.rowOf(line)
+		if m.bodyCursor < m.respScroll || m.bodyCursor >= m.respScroll+page {
+			m.respScroll = max(0, m.bodyCursor-page/2)
+		}
+		return m
+	}
+	if line < m.respScroll || line >= m.respScroll+page {
+		m.respScroll = max(0, line-page/2)
+	}
+	return m
+}
+
+// updateSearchBar handles keys while the search pattern is being typed.
+// ctrl+r switches between literal and regex matching.
+func (m Model) updateSearchBar(msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.searching = false
+	case "enter":
+		m.searching = false
+		m.searchQuery = m.searchInput
+		m = m.runSearch()
+	case "ctrl+r":
+		m.searchRegex = !m.searchRegex
+	case "backspace":
+		runes := []rune(m.searchInput)
+		if len(runes) > 0 {
+			m.searchInput = string(runes[:len(runes)-1])
+		}
+	default:
+		if len([]rune(msg.String())) == 1 {
+			m.searchInput += msg.String()
+		}
+	}
+	return m, nil
+}
+
+// updateSearchKeys handles the search keys of the Body tab.
+func (m Model) updateSearchKeys(msg tea.KeyMsg) (Model, bool) {
+	if _, _, ok := m.searchLines(); !ok {
+		return m, false
+	}
+	switch msg.String() {
+	case "/":
+		m.searching = true
+		m.searchInput = ""
+	case "n", "N":
+		if len(m.searchMatches) == 0 {
+			return m, m.searchQuery != ""
+		}
+		step := 1
+		if msg.String() == "N" {
+			step = len(m.searchMatches) - 1
+		}
+		m.searchIdx = (m.searchIdx + step) % len(m.searchMatches)
+		m = m.showMatch()
+	case "esc":
+		if m.searchQuery == "" {
+			return m, false
+		}
+		m = m.clearSearch()
+	default:
+		return m, false
+	}
+	return m, true
+}
+
+// searchStatus is the match count shown in the status line.
+func (m Model) searchStatus() string {
+	switch {
+	case m.searchQuery == "":
+		return ""
+	case m.searchErr != "":
+		return m.theme.errStyle().Render(m.searchErr)
+	case len(m.searchMatches) == 0:
+		return m.theme.errStyle().Render("no matches")
+	}
+	count := fmt.Sprintf("%d/%d", m.searchIdx+1, len(m.searchMatches))
+	if len(m.searchMatches) == maxBodyMatches {
+		count += "+"
+	}
+	return m.theme.accent().Render(count + " matches")
+}
+
+func (m Model) renderSearchBar(w int) string {
+	mode := "text"
+	if m.searchRegex {
+		mode = "regex"
+	}
+	line := m.theme.accent().Bold(true).Render("  / ") +
+		m.theme.text().Render(m.searchInput) + m.theme.accent().Render("█") +
+		m.theme.dim().Render("  "+mode+"  ") + m.theme.keyHint("ctrl+r") + m.theme.dim().Render("mode")
+	return lipgloss.NewStyle().MaxWidth(w).Render(line)
+}
+
+// renderPieces renders a line, highlighting the search hits on it. The hit
+// that n / N last moved to stands out from the others.
+func (m Model) renderPieces(pieces []piece, line int) string {
+	var sb strings.Builder
+	hits := m.matchesOn(line)
+	if len(hits) == 0 {
+		for _, p := range pieces {
+			sb.WriteString(p.style.Render(p.text))
+		}
+		return sb.String()
+	}
+	var current bodyMatch
+	if m.searchIdx < len(m.searchMatches) {
+		current = m.searchMatches[m.searchIdx]
+	}
+	hit := m.theme.accent().Reverse(true)
+	currentHit := m.theme.highlight().Reverse(true).Bold(true)
+
+	pos := 0
+	for _, p := range pieces {
+		text := p.text
+		for text != "" {
+			seg, style := len(text), p.style
+			for _, h := range hits {
+				switch {
+				case h.start <= pos && pos < h.end:
+					seg, style = min(seg, h.end-pos), hit
+					if h == current {
+						style = currentHit
+					}
+				case pos < h.start:
+					seg = min(seg, h.start-pos)
+				default:
+					continue
+				}
+				break
+			}
+			sb.WriteString(style.Render(text[:seg]))
+			text = text[seg:]
+			pos += seg
+		}
+	}
+	return sb.String()
+}
+
+// matchesOn returns the hits on one line; searchMatches is sorted by line.
+func (m Model) matchesOn(line int) []bodyMatch {
+	ms := m.searchMatches
+	i := sort.Search(len(ms), func(i int) bool { return ms[i].line >= line })
+	j := i
+	for j < len(ms) && ms[j].line == line {
+		j++
+	}
+	return ms[i:j]
+}
+
+// jsonLineText is the searchable text of a doc line, as renderJSONLine
+// draws it when unfolded.
+func jsonLineText(l jsonLine) string {
+	var sb strings.Builder
+	if l.key != "" {
+		sb.WriteString(l.key + ": ")
+	}
+	sb.WriteString(l.value)
+	if l.comma && l.kind != jsonOpen {
+		sb.WriteString(",")
+	}
+	return sb.String()
+}
+
+func synLineText(l synLine) string {
+	var sb strings.Builder
+	for _, s := range l.spans {
+		sb.WriteString(s.text)
+	}
+	return sb.String()
+}
@@ -0,0 +1,459 @@
+package ui
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	vaultIterations    = 600_000
+	minVaultIterations = 100_000 // fewer in a vault file is refused
+	secretCmdTimeout   = 10 * time.Second
+	vaultPassphraseEnv = "TUIMAN_VAULT_PASSPHRASE"
+	vaultConfirmEnv    = "TUIMAN_VAULT_PASSPHRASE_CONFIRM"
+	vaultPathEnv       = "TUIMAN_VAULT"
+	minMaskedLen       = 4 // shorter values would mask unrelated text
+)
+
+var errVaultLocked = errors.New("the vault is locked (:vault unlock)")
+
+// secretEntry is a secret of the vault: a value, or a command whose output
+// is the value, such as `pass show github/token` or `op read op://…`.
+type secretEntry struct {
+	Value   string `json:"value,omitempty"`
+	Command string `json:"command,omitempty"`
+}
+
+// vaultFile is the on-disk form of the vault. The entries are sealed with
+// AES-256-GCM under a key derived from the passphrase with PBKDF2-SHA256.
+type vaultFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// vault holds the secrets that {{$secret name}} resolves. Requests and
+// environments only ever carry the reference, so secrets never end up in
+// collection files. It is shared by every copy of the Model and safe for
+// concurrent sends.
+type vault struct {
+	mu      sync.Mutex
+	path    string
+	key     []byte // nil while locked
+	salt    []byte
+	entries map[string]secretEntry
+	fetched map[string]string // command outputs of the session
+}
+
+func newVault(path string) *vault {
+	return &vault{path: path}
+}
+
+// defaultVaultPath is $TUIMAN_VAULT, or vault.json in the user's config
+// directory.
+func defaultVaultPath() string {
+	if p := os.Getenv(vaultPathEnv); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "tuiman", "vault.json")
+}
+
+func (v *vault) locked() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.key == nil
+}
+
+// exists reports whether the vault file has been created.
+func (v *vault) exists() bool {
+	_, err := os.Stat(v.path)
+	return err == nil
+}
+
+// unlock opens the vault with passphrase.
+func (v *vault) unlock(passphrase string) error {
+	if v.path == "" {
+		return errors.New("no vault path (set " + vaultPathEnv + ")")
+	}
+	if passphrase == "" {
+		return errors.New("empty passphrase")
+	}
+	data, err := os.ReadFile(v.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("no vault at %s", v.path)
+	}
+	if err != nil {
+		return err
+	}
+	var f vaultFile
+	if err := json.Unmarshal(data, &f); err != nil || f.Version != 1 || f.KDF != "pbkdf2-sha256" {
+		return fmt.Errorf("%s is not a tuiman vault", v.path)
+	}
+	if f.Iterations < minVaultIterations {
+		return fmt.Errorf("%s: %d PBKDF2 iterations is too few (at least %d)", v.path, f.Iterations, minVaultIterations)
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, f.Salt, f.Iterations, 32)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	plain, err := gcm.Open(nil, f.Nonce, f.Ciphertext, nil)
+	if err != nil {
+		return errors.New("wrong passphrase")
+	}
+	entries := map[string]secretEntry{}
+	if err := json.Unmarshal(plain, &entries); err != nil {
+		return fmt.Errorf("%s: %w", v.path, err)
+	}
+	v.mu.Lock()
+	v.key, v.salt, v.entries, v.fetched = key, f.Salt, entries, map[string]string{}
+	v.mu.Unlock()
+	return nil
+}
+
+// create starts a new, empty vault under passphrase, to be written with the
+// first secret. confirm must repeat the passphrase.
+func (v *vault) create(passphrase, confirm string) error {
+	if v.path == "" {
+		return errors.New("no vault path (set " + vaultPathEnv + ")")
+	}
+	if passphrase == "" {
+		return errors.New("empty passphrase")
+	}
+	if confirm != passphrase {
+		return errors.New("the passphrases do not match")
+	}
+	salt := make([]byte, 16)
+	rand.Read(salt)
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, vaultIterations, 32)
+	if err != nil {
+		return err
+	}
+	v.mu.Lock()
+	v.key, v.salt, v.entries, v.fetched = key, salt, map[string]secretEntry{}, map[string]string{}
+	v.mu.Unlock()
+	return nil
+}
+
+// lock forgets the key and every secret.
+func (v *vault) lock() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.key, v.entries, v.fetched = nil, nil, nil
+}
+
+// set stores a secret and writes the vault.
+func (v *vault) set(name string, e secretEntry) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.key == nil {
+		return errVaultLocked
+	}
+	v.entries[name] = e
+	delete(v.fetched, name)
+	return v.save()
+}
+
+// remove deletes a secret and writes the vault.
+func (v *vault) remove(name string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.key == nil {
+		return errVaultLocked
+	}
+	if _, ok := v.entries[name]; !ok {
+		return fmt.Errorf("no secret %q", name)
+	}
+	delete(v.entries, name)
+	delete(v.fetched, name)
+	return v.save()
+}
+
+// save seals the entries under a fresh nonce. The caller holds mu.
+func (v *vault) save() error {
+	plain, err := json.Marshal(v.entries)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(v.key)
+	if err != nil {
+		return err
+	}
+	f := vaultFile{Version: 1, KDF: "pbkdf2-sha256", Iterations: vaultIterations, Salt: v.salt,
+		Nonce: make([]byte, gcm.NonceSize())}
+	rand.Read(f.Nonce)
+	f.Ciphertext = gcm.Seal(nil, f.Nonce, plain, nil)
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(v.path), 0o700); err != nil {
+		return err
+	}
+	tmp := v.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, v.path)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// get returns the value of the named secret, running its command the first
+// time it is needed in the session.
+func (v *vault) get(name string) (string, error) {
+	v.mu.Lock()
+	if v.key == nil {
+		v.mu.Unlock()
+		return "", errVaultLocked
+	}
+	e, ok := v.entries[name]
+	out, fetched := v.fetched[name]
+	v.mu.Unlock()
+	switch {
+	case !ok:
+		return "", fmt.Errorf("no secret %q", name)
+	case e.Command == "":
+		return e.Value, nil
+	case fetched:
+		return out, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretCmdTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", e.Command)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	b, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = errors.New(msg)
+		}
+		return "", fmt.Errorf("secret %q: %w", name, err)
+	}
+	out = strings.TrimRight(string(b), "\r\n")
+	v.mu.Lock()
+	if v.fetched != nil {
+		v.fetched[name] = out
+	}
+	v.mu.Unlock()
+	return out, nil
+}
+
+// list returns the names of the secrets with where their value comes from.
+func (v *vault) list() []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	var out []string
+	for name, e := range v.entries {
+		if e.Command != "" {
+			out = append(out, name+" (command)")
+		} else {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// mask replaces every secret value the session knows of in s with bullets
+// of the same width, so masked text keeps its layout.
+func (v *vault) mask(s string) string {
+	if v == nil {
+		return s
+	}
+	v.mu.Lock()
+	var values []string
+	for _, e := range v.entries {
+		if e.Command == "" {
+			values = append(values, e.Value)
+		}
+	}
+	for _, out := range v.fetched {
+		values = append(values, out)
+	}
+	v.mu.Unlock()
+	// Longest first, so a secret that contains another is masked whole.
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	for _, val := range values {
+		if len(val) >= minMaskedLen && strings.Contains(s, val) {
+			s = strings.ReplaceAll(s, val, strings.Repeat("•", utf8.RuneCountInString(val)))
+		}
+	}
+	return s
+}
+
+// requestSecrets reports whether r references a secret.
+func requestSecrets(r request) bool {
+	for _, f := range requestFields(r) {
+		for _, sub := range varPattern.FindAllStringSubmatch(f, -1) {
+			if sub[1] == "$secret" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// maskLiteral hides a credential typed into a field in plain text. A
+// {{reference}} is shown as is, since it holds no secret itself.
+func (m Model) maskLiteral(s string) string {
+	if (m.showSecrets && !m.presenting) || s == "" || varPattern.MatchString(s) {
+		return s
+	}
+	return strings.Repeat("●", utf8.RuneCountInString(s))
+}
+
+// askPassphrase unlocks the vault with a passphrase typed into the prompt
+// overlay and runs then once it is open; a wrong passphrase leaves a notice
+// instead. A new vault asks for the passphrase twice.
+func (m Model) askPassphrase(then func(Model) (Model, tea.Cmd)) (Model, tea.Cmd) {
+	const label, confirm = "Passphrase", "Confirm passphrase"
+	p := &promptState{
+		title:   "Unlock the vault",
+		labels:  []string{label},
+		answers: map[string]string{},
+		masked:  true,
+	}
+	open := func(v *vault, answers map[string]string) error { return v.unlock(answers[label]) }
+	if !m.vault.exists() {
+		p.title = "New vault · choose a passphrase"
+		p.labels = append(p.labels, confirm)
+		open = func(v *vault, answers map[string]string) error { return v.create(answers[label], answers[confirm]) }
+	}
+	p.then = func(m Model) (Model, tea.Cmd) {
+		err := open(m.vault, m.promptAnswers)
+		m.promptAnswers = nil
+		if err != nil {
+			m.notice = "vault: " + err.Error()
+			return m, nil
+		}
+		return then(m)
+	}
+	m.prompt = p
+	return m, nil
+}
+
+// execVault handles ":vault unlock|lock".
+func (m Model) execVault(args []string) Model {
+	if len(args) != 1 || (args[0] != "unlock" && args[0] != "lock") {
+		m.cmdError = "usage: vault unlock|lock"
+		return m
+	}
+	m = m.closeCmdPalette()
+	if args[0] == "lock" {
+		m.vault.lock()
+		m.notice = "vault locked"
+		return m
+	}
+	m, _ = m.askPassphrase(func(m Model) (Model, tea.Cmd) {
+		m.notice = "vault unlocked · " + plural(len(m.vault.list()), "secret")
+		return m, nil
+	})
+	return m
+}
+
+// execSecret handles ":secret [set <name>|cmd <name> <command>|rm <name>|
+// show|hide]". A value is typed into a masked prompt rather than the
+// palette; alone, it lists the secrets by name.
+func (m Model) execSecret(spec string) Model {
+	const usage = "usage: secret [set <name>|cmd <name> <command>|rm <name>|show|hide]"
+	verb, rest := cutWord(spec)
+	name, command := cutWord(rest)
+	switch verb {
+	case "show", "hide":
+		if m.presenting && verb == "show" {
+			m.cmdError = "presentation mode is on (:present off)"
+			return m
+		}
+		m.showSecrets = verb == "show"
+		m.notice = "secrets " + map[bool]string{true: "shown", false: "masked"}[m.showSecrets]
+		return m.closeCmdPalette()
+	case "", "set", "cmd", "rm":
+	default:
+		m.cmdError = usage
+		return m
+	}
+	if verb != "" && (name == "" || strings.ContainsAny(name, "{}") ||
+		(verb == "cmd") != (command != "")) {
+		m.cmdError = usage
+		return m
+	}
+	if m.vault.locked() {
+		m.cmdError = errVaultLocked.Error()
+		return m
+	}
+	switch verb {
+	case "":
+		names := m.vault.list()
+		if len(names) == 0 {
+			m.cmdInfo = "no secrets · :secret set <name>"
+			return m
+		}
+		m.cmdInfo = strings.Join(names, " · ")
+	case "set":
+		m = m.closeCmdPalette()
+		m.prompt = &promptState{
+			title:   "Secret " + name,
+			labels:  []string{"Value"},
+			answers: map[string]string{},
+			masked:  true,
+			action:  "save",
+			then: func(m Model) (Model, tea.Cmd) {
+				if err := m.vault.set(name, secretEntry{Value: m.promptAnswers["Value"]}); err != nil {
+					m.notice = "vault: " + err.Error()
+					return m, nil
+				}
+				m.notice = "saved secret " + name + " · use {{$secret " + name + "}}"
+				return m, nil
+			},
+		}
+	case "cmd":
+		if err := m.vault.set(name, secretEntry{Command: command}); err != nil {
+			m.cmdError = err.Error()
+			return m
+		}
+		m.cmdInfo = "saved secret " + name + " (command) · use {{$secret " + name + "}}"
+	case "rm":
+		if err := m.vault.remove(name); err != nil {
+			m.cmdError = err.Error()
+			return m
+		}
+		m.cmdInfo = "removed secret " + name
+	}
+	return m
+}
@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRequestSecrets(t *testing.T) {
+	tests := []struct {
+		name string
+		r    request
+		want bool
+	}{
+		{name: "in a field", r: request{url: "https://x/?k={{$secret key}}"}, want: true},
+		{name: "in a header", r: request{headers: []header{{key: "X-Token", value: "{{$secret api}}"}}}, want: true},
+		{name: "in the arguments of a function", r: request{auth: requestAuth{token: "{{$base64 {{$secret api}}}}"}}, want: true},
+		{name: "none", r: request{url: "https://{{host}}/", body: "{{$uuid}}"}},
+	}
+	for _, tt := range tests {
+		if got := requestSecrets(tt.r); got != tt.want {
+			t.Errorf("%s: %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestVaultCreateAndUnlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.json")
+	v := newVault(path)
+	if err := v.unlock("pass phrase"); err == nil || !strings.Contains(err.Error(), "no vault") {
+		t.Fatalf("unlock of a missing vault: %v", err)
+	}
+	for _, tt := range []struct{ pass, confirm, want string }{
+		{"", "", "empty passphrase"},
+		{"pass phrase", "pass prase", "do not match"},
+	} {
+		if err := v.create(tt.pass, tt.confirm); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("create(%q, %q): %v, want %q", tt.pass, tt.confirm, err, tt.want)
+		}
+	}
+	if !v.locked() {
+		t.Fatal("a refused passphrase unlocked the vault")
+	}
+	if err := v.create("pass phrase", "pass phrase"); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.set("token", secretEntry{Value: "abc123"}); err != nil {
+		t.Fatal(err)
+	}
+
+	v = newVault(path)
+	if err := v.unlock(""); err == nil {
+		t.Error("an empty passphrase was accepted")
+	}
+	if err := v.unlock("wrong"); err == nil {
+		t.Error("a wrong passphrase was accepted")
+	}
+	if err := v.unlock("pass phrase"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := v.get("token"); err != nil || got != "abc123" {
+		t.Errorf("token %q, %v", got, err)
+	}
+}
+
+func TestVaultRefusesFewIterations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.json")
+	v := newVault(path)
+	if err := v.create("pass phrase", "pass phrase"); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.set("token", secretEntry{Value: "abc123"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f vaultFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		t.Fatal(err)
+	}
+	f.Iterations = 1
+	if data, err = json.Marshal(f); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := newVault(path).unlock("pass phrase"); err == nil || !strings.Contains(err.Error(), "iterations") {
+		t.Errorf("unlock with 1 iteration: %v", err)
+	}
+}
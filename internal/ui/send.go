@@ -0,0 +1,309 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// response is the outcome of the last send, kept in memory only.
+type response struct {
+	status         string // e.g. "200 OK"
+	statusCode     int
+	proto          string
+	header         http.Header
+	url            string // final URL, after redirects
+	body           []byte
+	duration       time.Duration
+	err            error
+	cookies        []cookieChange // jar changes caused by this send
+	jarOff         bool           // the cookie jar was disabled for this send
+	proxy          string         // proxy the request went through, if any
+	socket         string         // Unix socket the request was sent over, if any
+	redirects      []redirectHop  // hops followed before the final response
+	attempts       int            // 1 unless the send was retried
+	timing         timing         // connection breakdown of the final hop
+	json           *jsonDoc       // parsed body when it is JSON
+	jsonValue      any            // the same body decoded for the jq filter
+	format         bodyFormat     // detected from the Content-Type
+	pretty         *prettyBody    // formatted body when it is not JSON
+	request        *http.Request  // final hop as sent, for :save exchange
+	tests          []testResult   // outcome of the request's assertions and script tests
+	scriptTests    []testResult   // declared by post-response scripts, part of tests
+	console        []consoleLine  // what the scripts logged
+	envName        string         // environment the send resolved variables from
+	envChanges     []envChange    // variables the scripts and extractors set, for envName
+	extracted      []extractResult
+	runtimeChanges []envChange // runtime variables the extractors set
+	stream         *sseLink    // reads the body when it is an event stream
+
+	// folderIdx and reqIdx locate the request that was sent, so its
+	// assertions can be re-evaluated when they change
+	folderIdx, reqIdx int
+}
+
+// redirectHop is one redirect response followed on the way to the final URL.
+type redirectHop struct {
+	method string
+	url    string
+	status string
+}
+
+// responseMsg is delivered to Update when a send finishes.
+type responseMsg struct {
+	resp   response
+	filter string // jq filter of the request that was sent
+}
+
+// buildHTTPRequest turns a stored request into an *http.Request, substituting
+// environment variables and template functions into every field.
+func buildHTTPRequest(r request, env environment) (*http.Request, error) {
+	rv := &resolver{env: env}
+	rawURL := strings.TrimSpace(rv.resolve(r.url))
+	if rawURL == "" {
+		return nil, fmt.Errorf("no URL")
+	}
+	if _, path, ok := splitUnixURL(rawURL); ok {
+		// The socket is dialed by the transport; the URL only carries the path.
+		rawURL = "http://localhost" + path
+	} else if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.params) > 0 {
+		q := u.Query()
+		for _, p := range r.params {
+			q.Add(rv.resolve(p.key), rv.resolve(p.value))
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	method := r.method
+	var body io.Reader
+	switch {
+	case r.graphql != nil:
+		envelope, err := r.graphql.envelope(rv)
+		if err != nil {
+			return nil, err
+		}
+		method, body = http.MethodPost, strings.NewReader(envelope)
+	case r.body != "":
+		body = strings.NewReader(rv.resolve(r.body))
+	}
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range r.headers {
+		req.Header.Add(rv.resolve(h.key), rv.resolve(h.value))
+	}
+	if r.graphql != nil {
+		if req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if req.Header.Get("Accept") == "" {
+			req.Header.Set("Accept", "application/graphql-response+json, application/json")
+		}
+	}
+
+	switch r.auth.kind {
+	case authBearer:
+		req.Header.Set("Authorization", "Bearer "+rv.resolve(r.auth.token))
+	case authBasic:
+		req.SetBasicAuth(rv.resolve(r.auth.username), rv.resolve(r.auth.password))
+	case authAPIKey:
+		req.Header.Set(rv.resolve(r.auth.apiKey), rv.resolve(r.auth.apiValue))
+	}
+	if rv.err != nil {
+		return nil, rv.err
+	}
+	return req, nil
+}
+
+// sendCmd builds the active request and performs it off the UI goroutine.
+// Cancelling ctx aborts the send, including any pending retry.
+func (m Model) sendCmd(ctx context.Context) tea.Cmd {
+	f := m.folders[m.activeFolderIdx]
+	r := f.requests[m.activeReqIdx]
+	env := m.env()
+	jar := m.jar()
+	ts := m.transportSettings()
+	opts, optsErr := resolveOptions(f, r)
+	fi, ri := m.activeFolderIdx, m.activeReqIdx
+
+	return func() tea.Msg {
+		resp := response{err: optsErr}
+		if optsErr == nil {
+			resp = execute(withSSE(ctx), f, r, env, jar, ts, opts)
+		}
+		resp.folderIdx, resp.reqIdx = fi, ri
+		return responseMsg{resp: resp, filter: r.filter}
+	}
+}
+
+// execute runs the pre-request scripts of f and r, performs the request and
+// checks its assertions and post-response scripts. It is shared by single
+// sends and collection runs, and is safe to call concurrently.
+func execute(ctx context.Context, f folder, r request, env environment, jar *cookieJar, ts transportSettings, opts sendOptions) response {
+	if isWebSocketURL(resolveVars(r.url, env)) {
+		return response{err: errors.New("WebSocket requests connect from the TUI"), envName: env.name}
+	}
+	sc := &scriptRun{env: env}
+	if err := sc.pre(ctx, f, &r); err != nil {
+		return response{err: err, console: sc.console, envName: env.name, envChanges: sc.changes}
+	}
+	env = sc.env
+	req, err := buildHTTPRequest(r, env)
+	if err != nil {
+		return response{err: err, console: sc.console, envName: env.name, envChanges: sc.changes}
+	}
+	req = req.WithContext(ctx)
+	if socket, _, ok := splitUnixURL(strings.TrimSpace(resolveVars(r.url, env))); ok {
+		ts.socket = socket
+	}
+	ts.connectTimeout = opts.connectTimeout
+	ts.tlsTimeout = opts.tlsTimeout
+	transport := newTransport(ts)
+	defer transport.CloseIdleConnections()
+
+	var hops []redirectHop
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   opts.totalTimeout,
+		CheckRedirect: func(next *http.Request, via []*http.Request) error {
+			if !opts.followRedirects {
+				return http.ErrUseLastResponse
+			}
+			// the hop is recorded even when it is refused, so the error
+			// response shows where the chain went
+			hops = append(hops, redirectHop{
+				method: via[len(via)-1].Method,
+				url:    via[len(via)-1].URL.String(),
+				status: next.Response.Status,
+			})
+			if len(via) > opts.maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", opts.maxRedirects)
+			}
+			return nil
+		},
+	}
+	if !r.noCookies {
+		client.Jar = jar
+	}
+
+	before := jar.snapshot()
+	resp := doRequest(ctx, client, req, opts, func() { hops = nil })
+	resp.redirects = hops
+	resp.cookies = diffCookies(before, jar.snapshot())
+	resp.jarOff = r.noCookies
+	resp.proxy = proxyFor(transport, req)
+	resp.socket = ts.socket
+	resp.format = detectFormat(resp.header, resp.body)
+	if resp.format == formatJSON {
+		resp.json = parseJSONDoc(resp.body)
+		resp.jsonValue, _ = jqDecode(resp.body)
+	} else {
+		resp.pretty = formatBody(resp.format, resp.body)
+	}
+	resp.extracted = runExtractors(r.extractors, resp)
+	envCh, runtimeCh := extractedChanges(resp.extracted)
+	sc.env = sc.env.apply(envCh).apply(runtimeCh)
+	sc.changes = append(sc.changes, envCh...)
+	resp.runtimeChanges = runtimeCh
+	resp.tests = runAssertions(r.assertions, resp)
+	if resp.err == nil {
+		sc.post(ctx, f, r, resp)
+	}
+	resp.scriptTests = sc.tests
+	resp.tests = append(resp.tests, sc.tests...)
+	resp.console = sc.console
+	resp.envName = env.name
+	resp.envChanges = sc.changes
+	return resp
+}
+
+// doRequest performs req, retrying with exponential backoff while the status
+// is one of opts.retryOn. reset is called before every retry.
+func doRequest(ctx context.Context, client *http.Client, req *http.Request, opts sendOptions, reset func()) response {
+	start := time.Now()
+	tr := &tracer{}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), tr.clientTrace()))
+	var resp response
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			delay := retryDelay(resp.header, opts.backoff(attempt))
+			select {
+			case <-ctx.Done():
+				resp.err = ctx.Err()
+				resp.duration = time.Since(start)
+				return resp
+			case <-time.After(delay):
+			}
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					resp.err = err
+					return resp
+				}
+				req = req.Clone(req.Context())
+				req.Body = body
+			}
+			reset()
+		}
+
+		resp = doOnce(client, req, tr)
+		resp.attempts = attempt + 1
+		if resp.err != nil || attempt >= opts.retries || !opts.retryable(resp.statusCode) {
+			resp.duration = time.Since(start)
+			return resp
+		}
+	}
+}
+
+func doOnce(client *http.Client, req *http.Request, tr *tracer) response {
+	if l := sseLinkFrom(req.Context()); l != nil {
+		return l.doOnce(client, req, tr)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return response{err: err, timing: tr.result()}
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	tr.record(func(t *timing) { t.bodyDone = time.Now() })
+	return responseOf(res, body, err, tr)
+}
+
+func responseOf(res *http.Response, body []byte, err error, tr *tracer) response {
+	return response{
+		timing:     tr.result(),
+		status:     res.Status,
+		statusCode: res.StatusCode,
+		proto:      res.Proto,
+		header:     res.Header,
+		url:        res.Request.URL.String(),
+		request:    res.Request,
+		body:       body,
+		err:        err,
+	}
+}
+
+// retryDelay honors a Retry-After header given in seconds, falling back to backoff.
+func retryDelay(h http.Header, backoff time.Duration) time.Duration {
+	if secs, err := strconv.Atoi(h.Get("Retry-After")); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return backoff
+}
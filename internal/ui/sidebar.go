@@ -5,6 +5,11 @@ import "strings"
 type folder struct {
 	name     string
 	requests []request
+	proxy    *proxyConfig      // nil inherits the global proxy
+	options  map[string]string // :set overrides for every request in the folder
+
+	preScript  string // JavaScript run before each request of the folder
+	postScript string // JavaScript run after each response in the folder
 }
 
 type header struct {
@@ -42,7 +47,20 @@ type request struct {
 	headers    []header
 	params     []param
 	body       string
+	graphql    *graphQL // nil unless the body is in GraphQL mode
+	frameType  string   // WebSocket message type: text (default), json or binary
 	auth       requestAuth
+	noCookies  bool              // bypass the environment cookie jar when sending
+	proxy      *proxyConfig      // nil inherits the folder proxy
+	socket     string            // Unix domain socket to dial instead of the URL host
+	options    map[string]string // :set overrides, layered over the folder's
+	filter     string            // last jq filter applied to the JSON response body
+	examples   []example         // responses pinned with :pin
+	history    []snapshot        // responses of this session, oldest first
+	assertions []string          // checks run after every send, see parseAssertion
+	extractors []string          // values captured into variables, see parseExtractor
+	preScript  string            // JavaScript run before the send, after the folder's
+	postScript string            // JavaScript run after the response, after the folder's
 	searchable string
 }
 
@@ -57,6 +75,9 @@ func (r request) searchText() string {
 	for _, p := range r.params {
 		parts = append(parts, p.key, p.value)
 	}
+	if r.graphql != nil {
+		parts = append(parts, r.graphql.query, r.graphql.operation)
+	}
 	return strings.Join(parts, " ")
 }
 
@@ -78,6 +99,12 @@ var mockFolders = []folder{
 					{key: "page", value: "1"},
 				},
 				auth: requestAuth{kind: authNone},
+				assertions: []string{
+					"status == 200",
+					"header Content-Type matches ^application/json",
+					`json .args.foo == "bar"`,
+					"time < 2s",
+				},
 			},
 			{
 				method: "POST",
@@ -93,7 +120,7 @@ var mockFolders = []folder{
 }`,
 				auth: requestAuth{
 					kind:  authBearer,
-					token: "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9",
+					token: "{{$secret httpbin_token}}",
 				},
 			},
 		},
@@ -122,7 +149,7 @@ var mockFolders = []folder{
 					{key: "per_page", value: "30"},
 					{key: "sort", value: "updated"},
 				},
-				auth: requestAuth{kind: authBearer, token: "ghp_xxxxxxxxxxxxxxxxxxxx"},
+				auth: requestAuth{kind: authBearer, token: "{{$secret github_token}}"},
 			},
 			{
 				method: "POST",
@@ -137,7 +164,7 @@ var mockFolders = []folder{
   "body": "Something is broken.",
   "labels": ["bug"]
 }`,
-				auth: requestAuth{kind: authBearer, token: "ghp_xxxxxxxxxxxxxxxxxxxx"},
+				auth: requestAuth{kind: authBearer, token: "{{$secret github_token}}"},
 			},
 		},
 	},
@@ -154,7 +181,7 @@ var mockFolders = []folder{
 				params: []param{
 					{key: "limit", value: "10"},
 				},
-				auth: requestAuth{kind: authBearer, token: "sk_test_xxxxxxxxxxxxxxxxxxxx"},
+				auth: requestAuth{kind: authBearer, token: "{{$secret stripe_key}}"},
 			},
 			{
 				method: "POST",
@@ -164,7 +191,7 @@ var mockFolders = []folder{
 					{key: "Content-Type", value: "application/x-www-form-urlencoded"},
 				},
 				body: `amount=2000&currency=usd&payment_method_types[]=card`,
-				auth: requestAuth{kind: authBearer, token: "sk_test_xxxxxxxxxxxxxxxxxxxx"},
+				auth: requestAuth{kind: authBearer, token: "{{$secret stripe_key}}"},
 			},
 			{
 				method: "DELETE",
@@ -173,7 +200,7 @@ var mockFolders = []folder{
 				headers: []header{
 					{key: "Content-Type", value: "application/x-www-form-urlencoded"},
 				},
-				auth: requestAuth{kind: authBearer, token: "sk_test_xxxxxxxxxxxxxxxxxxxx"},
+				auth: requestAuth{kind: authBearer, token: "{{$secret stripe_key}}"},
 			},
 		},
 	},
@@ -0,0 +1,534 @@
+package ui
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxSSEEvents bounds the log of a stream; the oldest events go first.
+const maxSSEEvents = 2000
+
+// defaultSSERetry is the reconnection delay until the server sets one with
+// a retry field.
+const defaultSSERetry = 3 * time.Second
+
+type sseState int
+
+const (
+	sseLive    sseState = iota
+	sseWaiting          // to reconnect
+	sseStopped
+)
+
+// sseEvent is one entry of the stream log: an event dispatched by the
+// server, or a connection note.
+type sseEvent struct {
+	at   time.Time
+	typ  string // event type; empty for notes
+	id   string // last event ID when it was dispatched
+	data string
+	note string
+}
+
+// sseParser turns the lines of an event stream into events, following the
+// WHATWG event stream format.
+type sseParser struct {
+	typ     string
+	data    strings.Builder
+	hasData bool
+	lastID  string
+	retry   time.Duration
+}
+
+// line takes one line, without its terminator, and returns the event it
+// dispatches, if any.
+func (p *sseParser) line(s string) (sseEvent, bool) {
+	if s == "" {
+		defer p.reset()
+		if !p.hasData {
+			return sseEvent{}, false
+		}
+		return sseEvent{
+			at:   time.Now(),
+			typ:  cmp.Or(p.typ, "message"),
+			id:   p.lastID,
+			data: strings.TrimSuffix(p.data.String(), "\n"),
+		}, true
+	}
+	if s[0] == ':' {
+		return sseEvent{}, false // a comment, often a keep-alive
+	}
+	field, value, _ := strings.Cut(s, ":")
+	value = strings.TrimPrefix(value, " ")
+	switch field {
+	case "event":
+		p.typ = value
+	case "data":
+		p.data.WriteString(value + "\n")
+		p.hasData = true
+	case "id":
+		if !strings.Contains(value, "\x00") {
+			p.lastID = value
+		}
+	case "retry":
+		if n, err := strconv.ParseUint(value, 10, 32); err == nil {
+			p.retry = time.Duration(n) * time.Millisecond
+		}
+	}
+	return sseEvent{}, false
+}
+
+// reset drops the event being read, keeping the last event ID.
+func (p *sseParser) reset() {
+	p.typ, p.hasData = "", false
+	p.data.Reset()
+}
+
+// isEventStream reports whether res is a successful text/event-stream
+// response.
+func isEventStream(res *http.Response) bool {
+	mt, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	return res.StatusCode >= 200 && res.StatusCode < 300 && mt == "text/event-stream"
+}
+
+// sseLink reads an event stream for the TUI, reconnecting when it ends.
+// A send whose context carries one hands its response over to it when the
+// response is an event stream, instead of reading the body to the end.
+type sseLink struct {
+	ctx    context.Context // the send's; cancelling it stops the stream
+	events chan sseMsg
+	kick   chan struct{} // reconnect now
+
+	mu   sync.Mutex
+	body io.Closer // of the response being read
+}
+
+// sseMsg is an event or a state change of a stream.
+type sseMsg struct {
+	link   *sseLink
+	event  sseEvent
+	state  sseState
+	lastID string
+	retry  time.Duration
+}
+
+// sseDoneMsg is delivered once a stream is over.
+type sseDoneMsg struct{ link *sseLink }
+
+type sseKey struct{}
+
+// withSSE returns ctx carrying a new sseLink for the send it belongs to.
+func withSSE(ctx context.Context) context.Context {
+	l := &sseLink{ctx: ctx, events: make(chan sseMsg, 64), kick: make(chan struct{}, 1)}
+	return context.WithValue(ctx, sseKey{}, l)
+}
+
+func sseLinkFrom(ctx context.Context) *sseLink {
+	l, _ := ctx.Value(sseKey{}).(*sseLink)
+	return l
+}
+
+// doOnce is doOnce for a send that may turn into a stream. The client's
+// timeout would cut a stream short, so it covers the exchange up to the
+// response headers of a stream, and the whole exchange otherwise.
+func (l *sseLink) doOnce(client *http.Client, req *http.Request, tr *tracer) response {
+	timeout := client.Timeout
+	c := *client
+	c.Timeout = 0
+	ctx, cancel := context.WithCancelCause(req.Context())
+	errTimeout := fmt.Errorf("no complete response within %s", timeout)
+	stop := func() bool { return false }
+	if timeout > 0 {
+		stop = time.AfterFunc(timeout, func() { cancel(errTimeout) }).Stop
+	}
+	res, err := c.Do(req.WithContext(ctx))
+	// A stream whose headers came in as the timeout fired is read as a
+	// body, which fails with the timeout.
+	if err == nil && isEventStream(res) && (timeout == 0 || stop()) {
+		go l.run(&c, req, res.Body, cancel)
+		resp := responseOf(res, nil, nil, tr)
+		resp.stream = l
+		return resp
+	}
+	defer cancel(nil)
+	defer stop()
+	if err != nil {
+		if errors.Is(context.Cause(ctx), errTimeout) {
+			err = errTimeout
+		}
+		return response{err: err, timing: tr.result()}
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	tr.record(func(t *timing) { t.bodyDone = time.Now() })
+	if err != nil && errors.Is(context.Cause(ctx), errTimeout) {
+		err = errTimeout
+	}
+	return responseOf(res, body, err, tr)
+}
+
+func (l *sseLink) emit(msg sseMsg) {
+	msg.link = l
+	select {
+	case l.events <- msg:
+	case <-l.ctx.Done():
+	}
+}
+
+// run reads the stream whose first response body is body, then reconnects
+// after the retry delay whenever it ends, sending the last event ID. It
+// stops when the send's context is cancelled, on a 204 or on a response
+// that is not an event stream.
+func (l *sseLink) run(client *http.Client, req *http.Request, body io.ReadCloser, cancel context.CancelCauseFunc) {
+	defer close(l.events)
+	defer cancel(nil)
+	p := &sseParser{retry: defaultSSERetry}
+	stopped := func() {
+		// Not through emit, as the send's context is done already, and
+		// without waiting: nobody reads a stream that was replaced.
+		select {
+		case l.events <- sseMsg{link: l, event: sseEvent{at: time.Now(), note: "stopped"}, state: sseStopped}:
+		default:
+		}
+	}
+	for {
+		err := l.read(body, p)
+		if l.ctx.Err() != nil {
+			stopped()
+			return
+		}
+		note := "stream ended"
+		if err != nil {
+			note = "connection lost: " + err.Error()
+		}
+		wait := p.retry
+		select {
+		case <-l.kick:
+			note, wait = "reconnecting", 0
+		default:
+		}
+		for {
+			text := note
+			if wait > 0 {
+				text += " · retrying in " + wait.String()
+			}
+			l.emit(sseMsg{event: sseEvent{at: time.Now(), note: text}, state: sseWaiting, lastID: p.lastID, retry: p.retry})
+			select {
+			case <-l.ctx.Done():
+				stopped()
+				return
+			case <-l.kick:
+			case <-time.After(wait):
+			}
+			wait = p.retry
+			next := req.Clone(l.ctx)
+			if req.GetBody != nil {
+				if b, err := req.GetBody(); err == nil {
+					next.Body = b
+				}
+			}
+			if p.lastID != "" {
+				next.Header.Set("Last-Event-ID", p.lastID)
+			}
+			res, err := client.Do(next)
+			if err != nil {
+				if l.ctx.Err() != nil {
+					stopped()
+					return
+				}
+				note = "reconnect failed: " + err.Error()
+				continue
+			}
+			if !isEventStream(res) {
+				res.Body.Close()
+				note = "reconnect refused: " + res.Status
+				if res.StatusCode == http.StatusNoContent {
+					note = "the server ended the stream (204)"
+				}
+				l.emit(sseMsg{event: sseEvent{at: time.Now(), note: note}, state: sseStopped})
+				return
+			}
+			note = "reconnected · " + res.Status
+			if p.lastID != "" {
+				note += " · Last-Event-ID " + p.lastID
+			}
+			l.emit(sseMsg{event: sseEvent{at: time.Now(), note: note}, state: sseLive})
+			body = res.Body
+			break
+		}
+	}
+}
+
+// read dispatches the events of body until it ends. An event cut short by
+// the end of the stream is dropped, as the format requires.
+func (l *sseLink) read(body io.ReadCloser, p *sseParser) error {
+	l.mu.Lock()
+	l.body = body
+	l.mu.Unlock()
+	defer body.Close()
+	p.reset()
+	r := bufio.NewReader(body)
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if ev, ok := p.line(strings.TrimRight(line, "\r\n")); ok {
+			l.emit(sseMsg{event: ev, state: sseLive, lastID: p.lastID, retry: p.retry})
+		}
+	}
+}
+
+// reconnect drops the current response, so the stream reconnects without
+// waiting for the retry delay.
+func (l *sseLink) reconnect() {
+	select {
+	case l.kick <- struct{}{}:
+	default:
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.body != nil {
+		l.body.Close()
+	}
+}
+
+// waitSSE delivers the next event of a stream, or sseDoneMsg once it is over.
+func waitSSE(l *sseLink) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-l.events
+		if !ok {
+			return sseDoneMsg{link: l}
+		}
+		return msg
+	}
+}
+
+// sseStream is the event stream of the last response and its log. It is
+// replaced, not modified, as events arrive.
+type sseStream struct {
+	link   *sseLink
+	cancel context.CancelFunc
+	state  sseState
+	events []sseEvent
+	total  int // events received, including those trimmed from the log
+	lastID string
+	retry  time.Duration
+	paused bool
+	shown  int // events on screen while paused
+}
+
+// watchSSE starts showing the stream of resp, whose send cancel stops.
+func (m Model) watchSSE(resp response, cancel context.CancelFunc) (Model, tea.Cmd) {
+	m.sse = &sseStream{
+		link:   resp.stream,
+		cancel: cancel,
+		retry:  defaultSSERetry,
+		events: []sseEvent{{at: time.Now(), note: "streaming · " + resp.status}},
+	}
+	m.sseCursor = -1
+	return m, waitSSE(resp.stream)
+}
+
+// stopSSE stops the stream on screen, if any.
+func (m Model) stopSSE() Model {
+	if m.sse != nil {
+		m.sse.cancel()
+		m.sse = nil
+	}
+	return m
+}
+
+// showingSSE reports whether the Body tab shows the stream log.
+func (m Model) showingSSE() bool {
+	return m.sse != nil && m.resp != nil && m.resp.stream == m.sse.link && m.responseTab == respTabBody
+}
+
+func (m Model) handleSSEMsg(msg sseMsg) (Model, tea.Cmd) {
+	if m.sse == nil || msg.link != m.sse.link {
+		return m, nil
+	}
+	s := *m.sse
+	s.events = append(slices.Clip(s.events), msg.event)
+	if msg.event.typ != "" {
+		s.total++
+	}
+	if over := len(s.events) - maxSSEEvents; over > 0 {
+		s.events = s.events[over:]
+		s.shown = max(0, s.shown-over)
+		if m.sseCursor >= 0 {
+			m.sseCursor = max(0, m.sseCursor-over)
+		}
+	}
+	s.state = msg.state
+	if msg.retry > 0 { // connection notes leave them out
+		s.lastID, s.retry = msg.lastID, msg.retry
+	}
+	m.sse = &s
+	return m, waitSSE(s.link)
+}
+
+func (m Model) handleSSEDone(msg sseDoneMsg) Model {
+	if m.sse != nil && msg.link == m.sse.link && m.sse.state != sseStopped {
+		s := *m.sse
+		s.state = sseStopped
+		m.sse = &s
+	}
+	return m
+}
+
+// visible is the part of the log on screen: all of it, or what had
+// arrived when it was paused.
+func (s *sseStream) visible() []sseEvent {
+	if s.paused {
+		return s.events[:s.shown]
+	}
+	return s.events
+}
+
+// updateSSELog handles the keys of the Body tab while it shows a stream.
+func (m Model) updateSSELog(msg tea.KeyMsg) (Model, bool) {
+	s := *m.sse
+	n := len(s.visible())
+	cur := m.sseCursor
+	if cur < 0 {
+		cur = n - 1
+	}
+	switch msg.String() {
+	case "j", "down":
+		if cur < n-1 {
+			m.sseCursor = cur + 1
+		}
+		if m.sseCursor == n-1 {
+			m.sseCursor = -1
+		}
+	case "k", "up":
+		m.sseCursor = max(0, cur-1)
+	case "g":
+		m.sseCursor = 0
+	case "G":
+		m.sseCursor = -1
+	case "p", " ":
+		s.paused = !s.paused
+		s.shown = len(s.events)
+		if !s.paused {
+			m.sseCursor = -1
+		}
+	case "r":
+		if s.state == sseStopped {
+			m.notice = "the stream is over (s sends again)"
+			return m, true
+		}
+		s.link.reconnect()
+	case "x":
+		if s.state != sseStopped {
+			s.cancel()
+		}
+	default:
+		return m, false
+	}
+	m.sse = &s
+	return m, true
+}
+
+// renderSSELog renders the Body tab of a stream: a status bar, then the
+// events, the newest at the bottom.
+func (m Model) renderSSELog(w, h int) string {
+	s := m.sse
+	dim := m.theme.dim()
+	var state string
+	switch s.state {
+	case sseLive:
+		state = m.statusStyle(200).Render("● live")
+	case sseWaiting:
+		state = m.theme.accent().Render("● reconnecting")
+	case sseStopped:
+		state = m.theme.errStyle().Render("● stopped")
+	}
+	parts := []string{state, m.theme.textMuted().Render(plural(s.total, "event"))}
+	if s.paused {
+		parts[0] = m.theme.highlight().Render("❚❚ paused")
+		if n := len(s.events) - s.shown; n > 0 {
+			parts = append(parts, m.theme.highlight().Render(fmt.Sprintf("%d new", n)))
+		}
+	}
+	if s.lastID != "" {
+		parts = append(parts, dim.Render("id "+s.lastID))
+	}
+	parts = append(parts, dim.Render("retry "+s.retry.String()))
+	pause := "pause"
+	if s.paused {
+		pause = "resume"
+	}
+	bar := " " + strings.Join(parts, dim.Render(" · ")) +
+		"  " + m.theme.keyHint("p") + dim.Render(pause) +
+		"  " + m.theme.keyHint("r") + dim.Render("reconnect") +
+		"  " + m.theme.keyHint("x") + dim.Render("stop")
+
+	events := s.visible()
+	cur := m.sseCursor
+	if cur < 0 || cur >= len(events) {
+		cur = len(events) - 1
+	}
+	var lines []string
+	curLine := 0
+	for i, ev := range events {
+		if i == cur {
+			curLine = len(lines)
+		}
+		lines = append(lines, m.renderSSEEvent(ev, i == cur && m.focused == 1, w)...)
+	}
+	// Keep the selected event on screen, following the newest by default.
+	contentH := h - 1
+	offset := max(0, len(lines)-contentH)
+	if m.sseCursor >= 0 {
+		offset = min(offset, max(0, curLine-contentH/2))
+	}
+	return lipgloss.NewStyle().MaxWidth(w).Render(bar) + "\n" +
+		scrollLines(strings.Join(lines, "\n"), offset, contentH)
+}
+
+// renderSSEEvent renders an event as a line with its arrival time, type
+// and ID, followed by its data, indented when it is JSON.
+func (m Model) renderSSEEvent(ev sseEvent, selected bool, w int) []string {
+	dim := m.theme.dim()
+	clip := lipgloss.NewStyle().MaxWidth(w)
+	prefix := "  "
+	if selected {
+		prefix = m.theme.accent().Bold(true).Render("> ")
+	}
+	stamp := dim.Render(ev.at.Format("15:04:05.000") + " ")
+	if ev.typ == "" {
+		return []string{clip.Render(prefix + stamp + dim.Render("• "+ev.note))}
+	}
+	head := prefix + stamp + m.theme.highlight().Render(ev.typ)
+	if ev.id != "" {
+		head += dim.Render("  #" + ev.id)
+	}
+	data := m.presentBody(ev.data)
+	if s, err := reindentJSON([]byte(data)); err == nil {
+		data = s
+	}
+	lines := []string{clip.Render(head)}
+	for _, l := range strings.Split(data, "\n") {
+		lines = append(lines, clip.Render("    "+m.theme.text().Render(printable(l))))
+	}
+	return lines
+}
@@ -83,3 +83,62 @@ func (t Theme) keyHint(key string) string {
 	hi := lipgloss.NewStyle().Foreground(t.Highlight)
 	return dim.Render("(") + hi.Render(key) + dim.Render(")")
 }
+
+// jsonKeyStyle colors object keys in the JSON body viewer.
+func (t Theme) jsonKeyStyle() lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(t.MethodPOST)
+}
+
+// jsonValueStyle colors a JSON scalar by its type.
+func (t Theme) jsonValueStyle(k jsonScalarKind) lipgloss.Style {
+	switch k {
+	case jsonString:
+		return lipgloss.NewStyle().Foreground(t.MethodGET)
+	case jsonNumber:
+		return lipgloss.NewStyle().Foreground(t.Highlight)
+	case jsonBool:
+		return lipgloss.NewStyle().Foreground(t.MethodPATCH)
+	case jsonNull:
+		return lipgloss.NewStyle().Foreground(t.MethodDELETE).Italic(true)
+	}
+	return lipgloss.NewStyle().Foreground(t.Dimmed)
+}
+
+// syntaxStyle colors a span of a formatted XML, HTML or YAML body, matching
+// the JSON viewer: keys and element names like JSON keys, scalars by type.
+func (t Theme) syntaxStyle(k synKind) lipgloss.Style {
+	switch k {
+	case synKey:
+		return t.jsonKeyStyle()
+	case synAttr:
+		return lipgloss.NewStyle().Foreground(t.MethodPUT)
+	case synString:
+		return t.jsonValueStyle(jsonString)
+	case synNumber:
+		return t.jsonValueStyle(jsonNumber)
+	case synBool:
+		return t.jsonValueStyle(jsonBool)
+	case synNull:
+		return t.jsonValueStyle(jsonNull)
+	case synComment:
+		return lipgloss.NewStyle().Foreground(t.Dimmed).Italic(true)
+	case synPunct:
+		return lipgloss.NewStyle().Foreground(t.Dimmed)
+	case synHeading:
+		return lipgloss.NewStyle().Foreground(t.Highlight).Bold(true)
+	}
+	return t.textMuted()
+}
+
+// diffStyle colors a changed, added or removed row of the response diff.
+func (t Theme) diffStyle(k diffKind) lipgloss.Style {
+	switch k {
+	case diffRemoved:
+		return lipgloss.NewStyle().Foreground(t.Error)
+	case diffAdded:
+		return lipgloss.NewStyle().Foreground(t.MethodGET)
+	case diffChanged:
+		return lipgloss.NewStyle().Foreground(t.MethodPATCH)
+	}
+	return t.textMuted()
+}
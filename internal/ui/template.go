@@ -0,0 +1,292 @@
+package ui
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// varPattern matches a {{name}} variable or a {{$function args}} call whose
+// arguments hold no other template. It finds the templates a field uses;
+// resolver parses them itself.
+var varPattern = regexp.MustCompile(`{{\s*([^{}\s]+)((?:\s+[^{}]*?)?)\s*}}`)
+
+// maxTemplateDepth bounds how deep templates nest in the arguments of a
+// function, as in {{$base64 {{user}}:{{password}}}}.
+const maxTemplateDepth = 4
+
+// resolver substitutes variables and template functions into request fields.
+// The first error, such as a missing {{$file}}, is kept in err; the failing
+// template is left as written.
+type resolver struct {
+	env     environment
+	preview bool // leave generated values and prompts unresolved
+	err     error
+}
+
+// resolveVars replaces every {{name}} in s with its value from env and every
+// {{$function}} with its result. Unknown variables and failing functions are
+// left untouched so they remain visible in the UI.
+func resolveVars(s string, env environment) string {
+	rv := resolver{env: env}
+	return rv.resolve(s)
+}
+
+// resolve substitutes the templates of s in one pass. Values are inserted as
+// they are and never scanned again: a variable may hold text a server sent,
+// such as an extracted token, and a template in it must not read local
+// files or secrets. Only the arguments of a function, as written in s, are
+// resolved before the function runs.
+func (rv *resolver) resolve(s string) string {
+	out, _ := rv.resolveDepth(s, 0)
+	return out
+}
+
+// resolveDepth resolves s, which is nested depth levels deep in function
+// arguments. ok is false when a template in it was left as written.
+func (rv *resolver) resolveDepth(s string, depth int) (string, bool) {
+	var b strings.Builder
+	ok := true
+	for {
+		start := strings.Index(s, "{{")
+		end := templateEnd(s, start)
+		if start < 0 || end < 0 {
+			b.WriteString(s)
+			return b.String(), ok
+		}
+		b.WriteString(s[:start])
+		v, valid, done := rv.template(s[start+2:end-2], depth)
+		switch {
+		case !valid:
+			// Not a template, though one may start inside it.
+			b.WriteString("{")
+			s = s[start+1:]
+			continue
+		case done:
+			b.WriteString(v)
+		default:
+			b.WriteString(s[start:end])
+			ok = false
+		}
+		s = s[end:]
+	}
+}
+
+// templateEnd returns the index just past the }} that closes the {{ at
+// start, or -1.
+func templateEnd(s string, start int) int {
+	if start < 0 {
+		return -1
+	}
+	open := 0
+	for i := start; i+1 < len(s); i++ {
+		switch s[i : i+2] {
+		case "{{":
+			open++
+			i++
+		case "}}":
+			if open--; open == 0 {
+				return i + 2
+			}
+			i++
+		}
+	}
+	return -1
+}
+
+// template resolves the text between {{ and }}. valid is false when it is not
+// a template at all, and done when it resolved.
+func (rv *resolver) template(inner string, depth int) (v string, valid, done bool) {
+	inner = strings.TrimSpace(inner)
+	name, args := inner, ""
+	if i := strings.IndexFunc(inner, unicode.IsSpace); i >= 0 {
+		name, args = inner[:i], strings.TrimSpace(inner[i:])
+	}
+	if name == "" || strings.ContainsAny(name, "{}") {
+		return "", false, false
+	}
+	if strings.Contains(args, "{{") {
+		if !strings.HasPrefix(name, "$") || depth+1 >= maxTemplateDepth {
+			return "", true, false
+		}
+		var ok bool
+		if args, ok = rv.resolveDepth(args, depth+1); !ok {
+			return "", true, false
+		}
+	}
+	v, done = rv.expand(name, args, inner)
+	return v, true, done
+}
+
+// expand resolves one template: a variable when name is plain, a function
+// when it starts with $. written is the template as it appears in the field,
+// for errors.
+func (rv *resolver) expand(name, args, written string) (string, bool) {
+	if !strings.HasPrefix(name, "$") {
+		if args != "" {
+			return "", false
+		}
+		return rv.env.lookup(name)
+	}
+	if rv.preview {
+		switch name {
+		case "$uuid", "$timestamp", "$isoTimestamp", "$randomInt", "$prompt", "$secret":
+			// Fresh on every send, asked for or secret: not for display.
+			return "", false
+		}
+	}
+	v, err := templateFunc(name, args, rv.env)
+	if err != nil {
+		if rv.err == nil {
+			rv.err = fmt.Errorf("{{%s}}: %w", written, err)
+		}
+		return "", false
+	}
+	return v, true
+}
+
+// templateFunc runs the built-in function name, e.g. $randomInt with "1 100".
+func templateFunc(name, args string, env environment) (string, error) {
+	argv, err := templateArgs(args)
+	if err != nil {
+		return "", err
+	}
+	switch name {
+	case "$uuid":
+		return newUUID(), nil
+	case "$timestamp":
+		return strconv.FormatInt(time.Now().Unix(), 10), nil
+	case "$isoTimestamp":
+		return time.Now().UTC().Format("2006-01-02T15:04:05.000Z"), nil
+	case "$randomInt":
+		lo, hi := 0, 1000
+		if len(argv) == 2 {
+			lo, err = strconv.Atoi(argv[0])
+			if err == nil {
+				hi, err = strconv.Atoi(argv[1])
+			}
+		}
+		if (len(argv) != 0 && len(argv) != 2) || err != nil || hi < lo {
+			return "", errors.New("usage: $randomInt [min max]")
+		}
+		return strconv.Itoa(lo + rand.IntN(hi-lo+1)), nil
+	case "$base64":
+		return base64.StdEncoding.EncodeToString([]byte(strings.Join(argv, " "))), nil
+	case "$env":
+		if len(argv) != 1 {
+			return "", errors.New("usage: $env <name>")
+		}
+		v, ok := os.LookupEnv(argv[0])
+		if !ok {
+			return "", fmt.Errorf("%s is not set", argv[0])
+		}
+		return v, nil
+	case "$file":
+		if len(argv) != 1 {
+			return "", errors.New("usage: $file <path>")
+		}
+		data, err := os.ReadFile(expandHome(argv[0]))
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	case "$prompt":
+		label := strings.Join(argv, " ")
+		if v, ok := env.lookup(promptKey(label)); ok {
+			return v, nil
+		}
+		return "", errors.New("not answered")
+	case "$secret":
+		if len(argv) != 1 {
+			return "", errors.New("usage: $secret <name>")
+		}
+		if env.secrets == nil {
+			return "", errVaultLocked
+		}
+		return env.secrets.get(argv[0])
+	}
+	return "", errors.New("unknown function")
+}
+
+// templateArgs splits the arguments of a template function on spaces;
+// "double quoted" arguments may contain spaces and Go escapes.
+func templateArgs(s string) ([]string, error) {
+	var args []string
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		if s[0] != '"' {
+			i := strings.IndexFunc(s, unicode.IsSpace)
+			if i < 0 {
+				i = len(s)
+			}
+			args = append(args, s[:i])
+			s = s[i:]
+			continue
+		}
+		q, err := strconv.QuotedPrefix(s)
+		if err != nil {
+			return nil, errors.New("unterminated quote")
+		}
+		arg, _ := strconv.Unquote(q)
+		args = append(args, arg)
+		s = s[len(q):]
+	}
+	return args, nil
+}
+
+// expandHome replaces a leading ~/ with the home directory.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return home + "/" + rest
+		}
+	}
+	return path
+}
+
+// promptKey is the variable an answer to {{$prompt label}} is stored under
+// for the send that asked it.
+func promptKey(label string) string {
+	return "$prompt " + label
+}
+
+// requestFields lists the fields of r that templates are resolved in.
+func requestFields(r request) []string {
+	fields := []string{r.url, r.body, r.auth.token, r.auth.username, r.auth.password, r.auth.apiKey, r.auth.apiValue}
+	for _, p := range r.params {
+		fields = append(fields, p.key, p.value)
+	}
+	for _, h := range r.headers {
+		fields = append(fields, h.key, h.value)
+	}
+	if r.graphql != nil {
+		fields = append(fields, r.graphql.query, r.graphql.variables)
+	}
+	return fields
+}
+
+// requestPrompts lists the distinct labels of the {{$prompt}} templates in
+// r, in the order they appear.
+func requestPrompts(r request) []string {
+	var labels []string
+	for _, f := range requestFields(r) {
+		for _, sub := range varPattern.FindAllStringSubmatch(f, -1) {
+			if sub[1] != "$prompt" {
+				continue
+			}
+			argv, err := templateArgs(sub[2])
+			label := strings.Join(argv, " ")
+			if err == nil && !slices.Contains(labels, label) {
+				labels = append(labels, label)
+			}
+		}
+	}
+	return labels
+}
@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveVars(t *testing.T) {
+	t.Setenv("TUIMAN_TEST_HOME", "/home/ann")
+	dir := t.TempDir()
+	payload := filepath.Join(dir, "payload")
+	if err := os.WriteFile(payload, []byte("id={{$env TUIMAN_TEST_HOME}}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	env := environment{vars: []variable{
+		{key: "user", value: "ann"},
+		{key: "pass", value: "p w"},
+		{key: "tok", value: "{{$env TUIMAN_TEST_HOME}}"},
+		{key: "ref", value: "{{user}}"},
+	}}
+	// A token a server sent, stored by an extractor or a script.
+	env = env.with("access_token", "{{$file "+payload+"}}")
+
+	tests := []struct{ in, want string }{
+		{"{{user}}", "ann"},
+		{"{{ user }}/x", "ann/x"},
+		{"{{missing}} {{user}}", "{{missing}} ann"},
+		{"{{$env TUIMAN_TEST_HOME}}", "/home/ann"},
+		{`{{$base64 {{user}}:{{pass}}}}`, "YW5uOnAgdw=="},
+		{`{{$base64 "{{user}} x"}}`, "YW5uIHg="},
+		// Values are inserted as they are, never resolved again.
+		{"x={{tok}}", "x={{$env TUIMAN_TEST_HOME}}"},
+		{"{{ref}}", "{{user}}"},
+		{"Bearer {{access_token}}", "Bearer {{$file " + payload + "}}"},
+		{"{{$base64 {{tok}}}}", "e3skZW52IFRVSU1BTl9URVNUX0hPTUV9fQ=="},
+		{"{{$file " + payload + "}}", "id={{$env TUIMAN_TEST_HOME}}"},
+		// Nesting only in function arguments, and only so deep.
+		{"{{user {{pass}}}}", "{{user {{pass}}}}"},
+		{"{{$base64 {{$base64 {{$base64 {{user}}}}}}}}", "V1ZjMWRRPT0="},
+		{"{{$base64 {{$base64 {{$base64 {{$base64 {{user}}}}}}}}}}", "{{$base64 {{$base64 {{$base64 {{$base64 {{user}}}}}}}}}}"},
+		// Text that only looks like a template.
+		{"{{{user}}}", "{ann}"},
+		{`{"a":{"b":{{user}}}}`, `{"a":{"b":ann}}`},
+		{"{{ }} {{user", "{{ }} {{user"},
+	}
+	for _, tt := range tests {
+		if got := resolveVars(tt.in, env); got != tt.want {
+			t.Errorf("%s: %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestResolveErrorsKeepTheTemplateAsWritten(t *testing.T) {
+	env := environment{vars: []variable{{key: "name", value: "s3cret"}}}
+	rv := &resolver{env: env}
+	got := rv.resolve("{{$file /no/such/{{name}}}}")
+	if got != "{{$file /no/such/{{name}}}}" {
+		t.Errorf("resolved to %q", got)
+	}
+	if rv.err == nil || !strings.HasPrefix(rv.err.Error(), "{{$file /no/such/{{name}}}}: ") {
+		t.Errorf("error %v, want it to quote the template as written", rv.err)
+	}
+}
+
+func TestResolvePreview(t *testing.T) {
+	rv := &resolver{env: environment{vars: []variable{{key: "user", value: "ann"}}}, preview: true}
+	for in, want := range map[string]string{
+		"{{user}}/{{$uuid}}":            "ann/{{$uuid}}",
+		"{{$base64 {{$prompt Order}}}}": "{{$base64 {{$prompt Order}}}}",
+		"{{$base64 {{user}}}}":          "YW5u",
+	} {
+		if got := rv.resolve(in); got != want {
+			t.Errorf("%s: %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package ui
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// textArea is multi-line text being edited, with the cursor as a byte
+// offset into it. Edits return a new value.
+type textArea struct {
+	text string
+	pos  int
+}
+
+func newTextArea(text string) textArea {
+	return textArea{text: text, pos: len(text)}
+}
+
+func (t textArea) insert(s string) textArea {
+	t.text = t.text[:t.pos] + s + t.text[t.pos:]
+	t.pos += len(s)
+	return t
+}
+
+// newline breaks the line at the cursor, keeping its indentation and
+// indenting one more level after an opening brace or parenthesis.
+func (t textArea) newline() textArea {
+	line := t.text[t.lineStart():t.pos]
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	if trimmed := strings.TrimRight(line, " "); strings.HasSuffix(trimmed, "{") || strings.HasSuffix(trimmed, "(") || strings.HasSuffix(trimmed, "[") {
+		indent += "  "
+	}
+	return t.insert("\n" + indent)
+}
+
+func (t textArea) backspace() textArea {
+	if t.pos == 0 {
+		return t
+	}
+	_, n := utf8.DecodeLastRuneInString(t.text[:t.pos])
+	t.text = t.text[:t.pos-n] + t.text[t.pos:]
+	t.pos -= n
+	return t
+}
+
+func (t textArea) del() textArea {
+	if t.pos == len(t.text) {
+		return t
+	}
+	_, n := utf8.DecodeRuneInString(t.text[t.pos:])
+	t.text = t.text[:t.pos] + t.text[t.pos+n:]
+	return t
+}
+
+func (t textArea) left() textArea {
+	if t.pos > 0 {
+		_, n := utf8.DecodeLastRuneInString(t.text[:t.pos])
+		t.pos -= n
+	}
+	return t
+}
+
+func (t textArea) right() textArea {
+	if t.pos < len(t.text) {
+		_, n := utf8.DecodeRuneInString(t.text[t.pos:])
+		t.pos += n
+	}
+	return t
+}
+
+func (t textArea) lineStart() int {
+	return strings.LastIndexByte(t.text[:t.pos], '\n') + 1
+}
+
+func (t textArea) lineEnd() int {
+	if i := strings.IndexByte(t.text[t.pos:], '\n'); i >= 0 {
+		return t.pos + i
+	}
+	return len(t.text)
+}
+
+func (t textArea) home() textArea { t.pos = t.lineStart(); return t }
+func (t textArea) end() textArea  { t.pos = t.lineEnd(); return t }
+
+// cursor returns the line and the column, in runes, of the cursor.
+func (t textArea) cursor() (line, col int) {
+	line = strings.Count(t.text[:t.pos], "\n")
+	col = utf8.RuneCountInString(t.text[t.lineStart():t.pos])
+	return line, col
+}
+
+// up and down move to the previous or next line, keeping the column when
+// the line is long enough.
+func (t textArea) up() textArea {
+	start := t.lineStart()
+	if start == 0 {
+		return t.home()
+	}
+	_, col := t.cursor()
+	t.pos = start - 1
+	return t.home().column(col)
+}
+
+func (t textArea) down() textArea {
+	end := t.lineEnd()
+	if end == len(t.text) {
+		return t.end()
+	}
+	_, col := t.cursor()
+	t.pos = end + 1
+	return t.column(col)
+}
+
+// column moves the cursor to col of its line, or to the end of the line.
+func (t textArea) column(col int) textArea {
+	line := t.text[t.pos:t.lineEnd()]
+	for i := range line {
+		if col == 0 {
+			t.pos += i
+			return t
+		}
+		col--
+	}
+	return t.end()
+}
+
+// word returns the identifier that ends at the cursor.
+func (t textArea) word() string {
+	i := strings.LastIndexFunc(t.text[:t.pos], func(r rune) bool {
+		return r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return t.text[i+1 : t.pos]
+}
+
+// edit applies an editing key to t and reports whether it was one.
+func (t textArea) edit(key string) (textArea, bool) {
+	switch key {
+	case "enter":
+		return t.newline(), true
+	case "backspace":
+		return t.backspace(), true
+	case "delete", "ctrl+d":
+		return t.del(), true
+	case "left":
+		return t.left(), true
+	case "right":
+		return t.right(), true
+	case "up":
+		return t.up(), true
+	case "down":
+		return t.down(), true
+	case "home", "ctrl+a":
+		return t.home(), true
+	case "end", "ctrl+e":
+		return t.end(), true
+	case "}", ")", "]":
+		// A closer typed first on a line goes back one level.
+		if line := t.text[t.lineStart():t.pos]; strings.HasSuffix(line, "  ") && strings.TrimSpace(line) == "" {
+			t.text = t.text[:t.pos-2] + t.text[t.pos:]
+			t.pos -= 2
+		}
+		return t.insert(key), true
+	case "ctrl+u":
+		start := t.lineStart()
+		t.text = t.text[:start] + t.text[t.pos:]
+		t.pos = start
+		return t, true
+	}
+	if utf8.RuneCountInString(key) == 1 {
+		return t.insert(key), true
+	}
+	return t, false
+}
@@ -0,0 +1,194 @@
+package ui
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// timing is the connection-level breakdown of the final hop of a send,
+// collected through net/http/httptrace.
+type timing struct {
+	start                    time.Time
+	dnsStart, dnsDone        time.Time
+	connectStart, connectEnd time.Time
+	tlsStart, tlsDone        time.Time
+	gotConn                  time.Time
+	wroteRequest             time.Time
+	firstByte                time.Time
+	bodyDone                 time.Time
+
+	remoteAddr string
+	reused     bool
+	tlsVersion string
+}
+
+// tracer collects a timing; hooks may fire on several goroutines.
+type tracer struct {
+	mu sync.Mutex
+	t  timing
+}
+
+func (tr *tracer) record(f func(t *timing)) {
+	tr.mu.Lock()
+	f(&tr.t)
+	tr.mu.Unlock()
+}
+
+func (tr *tracer) result() timing {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return tr.t
+}
+
+// clientTrace returns hooks that restart on every GetConn, so after redirects
+// the result describes the request that produced the final response.
+func (tr *tracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			tr.record(func(t *timing) { *t = timing{start: time.Now()} })
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			tr.record(func(t *timing) { t.dnsStart = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			tr.record(func(t *timing) { t.dnsDone = time.Now() })
+		},
+		ConnectStart: func(string, string) {
+			tr.record(func(t *timing) {
+				// With several addresses the dialer races them; keep the first start.
+				if t.connectStart.IsZero() {
+					t.connectStart = time.Now()
+				}
+			})
+		},
+		ConnectDone: func(_, _ string, err error) {
+			tr.record(func(t *timing) {
+				if err == nil && t.connectEnd.IsZero() {
+					t.connectEnd = time.Now()
+				}
+			})
+		},
+		TLSHandshakeStart: func() {
+			tr.record(func(t *timing) { t.tlsStart = time.Now() })
+		},
+		TLSHandshakeDone: func(cs tls.ConnectionState, _ error) {
+			tr.record(func(t *timing) {
+				t.tlsDone = time.Now()
+				if cs.Version != 0 {
+					t.tlsVersion = tls.VersionName(cs.Version)
+				}
+			})
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			tr.record(func(t *timing) {
+				t.gotConn = time.Now()
+				t.reused = info.Reused
+				if info.Conn != nil {
+					t.remoteAddr = info.Conn.RemoteAddr().String()
+				}
+			})
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			tr.record(func(t *timing) { t.wroteRequest = time.Now() })
+		},
+		GotFirstResponseByte: func() {
+			tr.record(func(t *timing) { t.firstByte = time.Now() })
+		},
+	}
+}
+
+// phase is one bar of the waterfall.
+type phase struct {
+	label      string
+	start, end time.Time
+	color      lipgloss.Color
+}
+
+func (t timing) phases(th Theme) []phase {
+	all := []phase{
+		{"DNS lookup", t.dnsStart, t.dnsDone, th.MethodGET},
+		{"TCP connect", t.connectStart, t.connectEnd, th.MethodPOST},
+		{"TLS handshake", t.tlsStart, t.tlsDone, th.MethodPATCH},
+		{"Request sent", t.gotConn, t.wroteRequest, th.TextMuted},
+		{"Waiting (TTFB)", t.wroteRequest, t.firstByte, th.Highlight},
+		{"Content transfer", t.firstByte, t.bodyDone, th.Accent},
+	}
+	var out []phase
+	for _, p := range all {
+		if !p.start.IsZero() && !p.end.IsZero() {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// renderTiming renders the Timing tab: connection facts plus a waterfall chart
+// where each bar is offset by when its phase started.
+func (m Model) renderTiming(resp response, w int) string {
+	t := resp.timing
+	dim := m.theme.dim()
+	val := m.theme.textMuted()
+	if t.start.IsZero() {
+		return dim.Render("  no timing recorded")
+	}
+
+	conn := "new connection"
+	if t.reused {
+		conn = "reused connection"
+	}
+	proto := resp.proto
+	if t.tlsVersion != "" {
+		proto += " · " + t.tlsVersion
+	}
+	remote := t.remoteAddr
+	if remote == "" {
+		remote = "unknown"
+	}
+	lines := []string{
+		dim.Render("  Remote    ") + val.Render(remote),
+		dim.Render("  Protocol  ") + val.Render(proto),
+		dim.Render("  Conn      ") + val.Render(conn),
+		"",
+	}
+
+	const labelW = 18
+	const durW = 10
+	barW := max(w-labelW-durW-4, 10)
+	total := t.bodyDone.Sub(t.start)
+
+	for _, p := range t.phases(m.theme) {
+		d := p.end.Sub(p.start)
+		offset, length := 0, 0
+		if total > 0 {
+			offset = int(float64(barW) * float64(p.start.Sub(t.start)) / float64(total))
+			length = int(float64(barW)*float64(d)/float64(total) + 0.5)
+		}
+		offset = min(offset, barW-1)
+		length = max(1, min(length, barW-offset))
+		bar := strings.Repeat(" ", offset) +
+			lipgloss.NewStyle().Foreground(p.color).Render(strings.Repeat("█", length)) +
+			strings.Repeat(" ", barW-offset-length)
+		lines = append(lines, dim.Render(fmt.Sprintf("  %-*s", labelW, p.label))+bar+
+			val.Render(fmt.Sprintf(" %*s", durW, formatDuration(d))))
+	}
+	lines = append(lines, dim.Render("  "+strings.Repeat("─", labelW+barW+durW+1)))
+	lines = append(lines, dim.Render(fmt.Sprintf("  %-*s", labelW+barW, "Total"))+
+		m.theme.text().Bold(true).Render(fmt.Sprintf(" %*s", durW, formatDuration(total))))
+	return strings.Join(lines, "\n")
+}
+
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	case d >= time.Millisecond:
+		return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+	}
+	return fmt.Sprintf("%dµs", d.Microseconds())
+}
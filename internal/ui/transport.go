@@ -0,0 +1,194 @@
+package ui
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+type proxyMode string
+
+const (
+	proxyEnv      proxyMode = "env"      // HTTP_PROXY / HTTPS_PROXY / NO_PROXY
+	proxyExplicit proxyMode = "explicit" // proxyConfig.url
+	proxyOff      proxyMode = "off"      // connect directly
+)
+
+// proxyConfig selects how requests reach the network. Folders and requests
+// carry an optional override; nil means inherit from the level above.
+type proxyConfig struct {
+	mode proxyMode
+	url  *url.URL // http, https or socks5; credentials in the userinfo
+}
+
+// parseProxy parses the argument of :proxy — "env", "off" or a proxy URL.
+func parseProxy(arg string) (proxyConfig, error) {
+	switch arg {
+	case "env":
+		return proxyConfig{mode: proxyEnv}, nil
+	case "off", "direct":
+		return proxyConfig{mode: proxyOff}, nil
+	}
+	u, err := url.Parse(arg)
+	if err != nil {
+		return proxyConfig{}, err
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return proxyConfig{}, fmt.Errorf("unsupported proxy scheme %q (http, https, socks5)", u.Scheme)
+	}
+	if u.Host == "" {
+		return proxyConfig{}, fmt.Errorf("proxy URL has no host")
+	}
+	return proxyConfig{mode: proxyExplicit, url: u}, nil
+}
+
+func (p proxyConfig) String() string {
+	if p.mode == proxyExplicit {
+		return p.url.Redacted()
+	}
+	return string(p.mode)
+}
+
+// proxyFunc returns the http.Transport.Proxy hook for p.
+func (p proxyConfig) proxyFunc() func(*http.Request) (*url.URL, error) {
+	switch p.mode {
+	case proxyExplicit:
+		return http.ProxyURL(p.url)
+	case proxyOff:
+		return nil
+	}
+	// Read when the transport is built rather than once per process, so
+	// changes to the environment apply to the next send.
+	env := httpproxy.FromEnvironment().ProxyFunc()
+	return func(r *http.Request) (*url.URL, error) { return env(r.URL) }
+}
+
+// effectiveProxy resolves the proxy for a request: request override, then
+// folder override, then the global setting.
+func effectiveProxy(global proxyConfig, f folder, r request) proxyConfig {
+	switch {
+	case r.proxy != nil:
+		return *r.proxy
+	case f.proxy != nil:
+		return *f.proxy
+	}
+	return global
+}
+
+// transportSettings is everything needed to build the transport for one send.
+type transportSettings struct {
+	proxy    proxyConfig
+	socket   string // Unix domain socket; replaces TCP dialing when set
+	resolves []resolveEntry
+
+	connectTimeout time.Duration
+	tlsTimeout     time.Duration
+}
+
+func (m Model) transportSettings() transportSettings {
+	f := m.folders[m.activeFolderIdx]
+	return m.transportSettingsFor(f, f.requests[m.activeReqIdx])
+}
+
+// transportSettingsFor is transportSettings for any request of folder f.
+func (m Model) transportSettingsFor(f folder, r request) transportSettings {
+	return transportSettings{
+		proxy:    effectiveProxy(m.proxy, f, r),
+		socket:   r.socket,
+		resolves: m.resolves,
+	}
+}
+
+// newTransport builds a fresh transport for one send so per-request settings
+// never leak between requests.
+func newTransport(ts transportSettings) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = ts.proxy.proxyFunc()
+	t.DialContext = dialContext(ts.socket, ts.resolves, ts.connectTimeout)
+	t.TLSHandshakeTimeout = ts.tlsTimeout
+	if ts.socket != "" {
+		// A socket is a local endpoint; a proxy would never reach it.
+		t.Proxy = nil
+	}
+	return t
+}
+
+// proxyFor reports which proxy, if any, t will use for req.
+func proxyFor(t *http.Transport, req *http.Request) string {
+	if t.Proxy == nil {
+		return ""
+	}
+	u, err := t.Proxy(req)
+	if err != nil || u == nil {
+		return ""
+	}
+	return u.Redacted()
+}
+
+// execProxy handles ":proxy [folder|request] <env|off|URL|inherit>".
+// With no arguments it reports the settings that apply to the current request.
+func (m Model) execProxy(args []string) Model {
+	if len(args) == 0 {
+		info := "proxy " + m.proxy.String()
+		if m.activeFolderIdx >= 0 {
+			f := m.folders[m.activeFolderIdx]
+			r := f.requests[m.activeReqIdx]
+			info += " · folder " + overrideString(f.proxy) +
+				" · request " + overrideString(r.proxy) +
+				" → " + effectiveProxy(m.proxy, f, r).String()
+		}
+		m.cmdInfo = info
+		return m
+	}
+
+	scope := "global"
+	if args[0] == "folder" || args[0] == "request" {
+		scope, args = args[0], args[1:]
+	}
+	if len(args) != 1 {
+		m.cmdError = "usage: proxy [folder|request] <env|off|URL|inherit>"
+		return m
+	}
+
+	var override *proxyConfig
+	if args[0] != "inherit" {
+		p, err := parseProxy(args[0])
+		if err != nil {
+			m.cmdError = err.Error()
+			return m
+		}
+		override = &p
+	}
+
+	switch scope {
+	case "global":
+		if override == nil {
+			m.cmdError = "the global proxy cannot inherit"
+			return m
+		}
+		m.proxy = *override
+	case "folder", "request":
+		if m.activeFolderIdx < 0 {
+			m.cmdError = "no request selected"
+			return m
+		}
+		if scope == "folder" {
+			m.folders[m.activeFolderIdx].proxy = override
+		} else {
+			m.folders[m.activeFolderIdx].requests[m.activeReqIdx].proxy = override
+		}
+	}
+	return m.closeCmdPalette()
+}
+
+func overrideString(p *proxyConfig) string {
+	if p == nil {
+		return "inherit"
+	}
+	return p.String()
+}
@@ -0,0 +1,274 @@
+package ui
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// hits records the targets a proxy stand-in was asked for.
+type hits struct {
+	mu      sync.Mutex
+	targets []string
+}
+
+func (h *hits) add(target string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.targets = append(h.targets, target)
+}
+
+func (h *hits) list() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.targets...)
+}
+
+// newHTTPProxy starts a forward HTTP proxy stand-in that requires basic auth
+// as user:pass and answers "proxied <host>" instead of forwarding.
+func newHTTPProxy(t *testing.T, user, pass string) (*httptest.Server, *hits) {
+	t.Helper()
+	h := &hits{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !r.URL.IsAbs() {
+			http.Error(w, "not a proxy request", http.StatusBadRequest)
+			return
+		}
+		probe := &http.Request{Header: http.Header{"Authorization": r.Header.Values("Proxy-Authorization")}}
+		if u, p, ok := probe.BasicAuth(); !ok || u != user || p != pass {
+			w.Header().Set("Proxy-Authenticate", `Basic realm="stand-in"`)
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		h.add(r.URL.Host)
+		io.WriteString(w, "proxied "+r.URL.Host)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, h
+}
+
+// newSOCKS5Proxy starts a SOCKS5 stand-in that requires username/password
+// auth and connects every CONNECT to upstream, whatever its target.
+func newSOCKS5Proxy(t *testing.T, user, pass, upstream string) (string, *hits) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	h := &hits{}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSOCKS5(conn, user, pass, upstream, h)
+		}
+	}()
+	return ln.Addr().String(), h
+}
+
+func serveSOCKS5(conn net.Conn, user, pass, upstream string, h *hits) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	read := func(n int) []byte {
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil
+		}
+		return b
+	}
+	// Greeting: offer username/password only.
+	head := read(2)
+	if head == nil || head[0] != 5 || read(int(head[1])) == nil {
+		return
+	}
+	conn.Write([]byte{5, 2})
+	// RFC 1929 sub-negotiation.
+	ver := read(2)
+	if ver == nil {
+		return
+	}
+	u := read(int(ver[1]))
+	plen := read(1)
+	if u == nil || plen == nil {
+		return
+	}
+	p := read(int(plen[0]))
+	if string(u) != user || string(p) != pass {
+		conn.Write([]byte{1, 1})
+		return
+	}
+	conn.Write([]byte{1, 0})
+	// CONNECT request.
+	req := read(4)
+	if req == nil || req[1] != 1 {
+		return
+	}
+	var host string
+	switch req[3] {
+	case 1:
+		host = net.IP(read(4)).String()
+	case 3:
+		if n := read(1); n != nil {
+			host = string(read(int(n[0])))
+		}
+	case 4:
+		host = net.IP(read(16)).String()
+	}
+	port := read(2)
+	if host == "" || port == nil {
+		return
+	}
+	h.add(net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+	up, err := net.Dial("tcp", upstream)
+	if err != nil {
+		conn.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer up.Close()
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	go io.Copy(up, r)
+	io.Copy(conn, up)
+}
+
+// sendWith sends request ri of folder fi the way the TUI does.
+func sendWith(t *testing.T, m Model, fi, ri int) response {
+	t.Helper()
+	f := m.folders[fi]
+	r := f.requests[ri]
+	opts, err := resolveOptions(f, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return execute(context.Background(), f, r, m.env(), m.jar(), m.transportSettingsFor(f, r), opts)
+}
+
+func mustProxy(t *testing.T, arg string) *proxyConfig {
+	t.Helper()
+	p, err := parseProxy(arg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &p
+}
+
+func TestProxyRouting(t *testing.T) {
+	direct := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "direct")
+	}))
+	defer direct.Close()
+	_, port, _ := net.SplitHostPort(direct.Listener.Addr().String())
+	proxy, proxied := newHTTPProxy(t, "alice", "s3cret")
+	socks, socksHits := newSOCKS5Proxy(t, "bob", "hunter2", direct.Listener.Addr().String())
+
+	httpURL := strings.Replace(proxy.URL, "http://", "http://alice:s3cret@", 1)
+	badURL := strings.Replace(proxy.URL, "http://", "http://alice:wrong@", 1)
+	socksURL := "socks5://bob:hunter2@" + socks
+	// direct.test resolves to the direct server; through.test to nothing,
+	// so it only answers through a proxy.
+	directTarget := "http://direct.test:" + port + "/"
+	throughTarget := "http://through.test/"
+
+	tests := []struct {
+		name      string
+		global    string
+		folder    string // "" inherits
+		request   string // "" inherits
+		url       string
+		want      string
+		wantSOCKS string
+		wantCode  int
+	}{
+		{name: "http proxy with auth", global: httpURL, url: throughTarget, want: "proxied through.test"},
+		{name: "http proxy rejects bad auth", global: badURL, url: throughTarget, wantCode: http.StatusProxyAuthRequired},
+		{name: "folder bypass", global: httpURL, folder: "off", url: directTarget, want: "direct"},
+		{name: "request bypass", global: "off", folder: httpURL, request: "off", url: directTarget, want: "direct"},
+		{name: "request proxy over folder bypass", global: "off", folder: "off", request: httpURL, url: throughTarget, want: "proxied through.test"},
+		{name: "socks5 with auth", global: socksURL, url: throughTarget, want: "direct", wantSOCKS: "through.test:80"},
+		{name: "folder socks5 over global http", global: httpURL, folder: socksURL, url: throughTarget, want: "direct", wantSOCKS: "through.test:80"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New()
+			m.proxy = *mustProxy(t, tt.global)
+			m.resolves = []resolveEntry{{host: "direct.test", port: "*", addr: "127.0.0.1"}}
+			f := folder{name: "F", requests: []request{{method: "GET", name: "r", url: tt.url}}}
+			if tt.folder != "" {
+				f.proxy = mustProxy(t, tt.folder)
+			}
+			if tt.request != "" {
+				f.requests[0].proxy = mustProxy(t, tt.request)
+			}
+			m.folders = []folder{f}
+			before := len(socksHits.list())
+
+			resp := sendWith(t, m, 0, 0)
+			if resp.err != nil {
+				t.Fatalf("send: %v", resp.err)
+			}
+			if tt.wantCode != 0 {
+				if resp.statusCode != tt.wantCode {
+					t.Fatalf("status %d, want %d", resp.statusCode, tt.wantCode)
+				}
+				return
+			}
+			if string(resp.body) != tt.want {
+				t.Errorf("body %q, want %q", resp.body, tt.want)
+			}
+			if got := socksHits.list()[before:]; tt.wantSOCKS != "" && (len(got) != 1 || got[0] != tt.wantSOCKS) {
+				t.Errorf("SOCKS5 targets %v, want [%s]", got, tt.wantSOCKS)
+			}
+			if tt.want == "direct" && tt.wantSOCKS == "" && resp.proxy != "" {
+				t.Errorf("went through %s, want a direct connection", resp.proxy)
+			}
+			if strings.Contains(resp.proxy, "s3cret") || strings.Contains(resp.proxy, "hunter2") {
+				t.Errorf("proxy %q shows its password", resp.proxy)
+			}
+		})
+	}
+	if got := proxied.list(); len(got) != 2 {
+		t.Errorf("HTTP proxy saw %v, want two requests", got)
+	}
+}
+
+func TestProxyFromEnvironment(t *testing.T) {
+	direct := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "direct")
+	}))
+	defer direct.Close()
+	_, port, _ := net.SplitHostPort(direct.Listener.Addr().String())
+	proxy, proxied := newHTTPProxy(t, "alice", "s3cret")
+
+	t.Setenv("HTTP_PROXY", strings.Replace(proxy.URL, "http://", "http://alice:s3cret@", 1))
+	t.Setenv("NO_PROXY", "direct.test")
+
+	m := New()
+	m.proxy = proxyConfig{mode: proxyEnv}
+	m.resolves = []resolveEntry{{host: "direct.test", port: "*", addr: "127.0.0.1"}}
+	m.folders = []folder{{name: "F", requests: []request{
+		{method: "GET", name: "through", url: "http://through.test/"},
+		{method: "GET", name: "skipped", url: "http://direct.test:" + port + "/"},
+	}}}
+
+	resp := sendWith(t, m, 0, 0)
+	if resp.err != nil || string(resp.body) != "proxied through.test" {
+		t.Fatalf("HTTP_PROXY: body %q, err %v", resp.body, resp.err)
+	}
+	resp = sendWith(t, m, 0, 1)
+	if resp.err != nil || string(resp.body) != "direct" || resp.proxy != "" {
+		t.Fatalf("NO_PROXY: body %q, proxy %q, err %v", resp.body, resp.proxy, resp.err)
+	}
+	if got := proxied.list(); len(got) != 1 {
+		t.Errorf("proxy saw %v, want one request", got)
+	}
+}
@@ -8,7 +8,20 @@ import (
 	"github.com/charmbracelet/x/ansi"
 )
 
+// View renders the screen with the secret values it shows masked, unless
+// :secret show is on. Presentation mode also masks the values of sensitive
+// variables wherever they appear.
 func (m Model) View() string {
+	switch {
+	case m.presenting:
+		return m.maskValues(m.view())
+	case m.showSecrets:
+		return m.view()
+	}
+	return m.vault.mask(m.view())
+}
+
+func (m Model) view() string {
 	if m.width == 0 {
 		return ""
 	}
@@ -25,6 +38,15 @@ func (m Model) View() string {
 	if m.showFolderPicker {
 		return placeOverlay(bg, m.renderFolderPicker(), m.width)
 	}
+	if m.showCookies {
+		return placeOverlay(bg, m.renderCookies(), m.width)
+	}
+	if m.showSchema {
+		return placeOverlay(bg, m.renderSchemaBrowser(), m.width)
+	}
+	if m.prompt != nil {
+		return placeOverlay(bg, m.renderPrompt(), m.width)
+	}
 	return bg
 }
 
@@ -33,7 +55,11 @@ func (m Model) renderMain() string {
 	mainH := m.height - footerH
 
 	var mainArea string
-	if m.splitVertical {
+	if m.run != nil {
+		mainArea = m.renderRun(mainH)
+	} else if m.diff != nil {
+		mainArea = m.renderDiff(mainH)
+	} else if m.splitVertical {
 		// Side-by-side: request 60% left, response 40% right
 		reqOuterW := m.width * 6 / 10
 		respOuterW := m.width - reqOuterW
@@ -49,7 +75,7 @@ func (m Model) renderMain() string {
 		responseBox := m.theme.paneStyle(m.focused == 1).
 			Width(respInnerW).
 			Height(innerH).
-			Render(m.theme.paneTitle(" Response ", m.focused == 1))
+			Render(m.renderResponse(respInnerW, innerH))
 
 		mainArea = lipgloss.JoinHorizontal(lipgloss.Top, requestBox, responseBox)
 	} else {
@@ -68,7 +94,7 @@ func (m Model) renderMain() string {
 		responseBox := m.theme.paneStyle(m.focused == 1).
 			Width(innerW).
 			Height(respInnerH).
-			Render(m.theme.paneTitle(" Response ", m.focused == 1))
+			Render(m.renderResponse(innerW, respInnerH))
 
 		mainArea = lipgloss.JoinVertical(lipgloss.Left, requestBox, responseBox)
 	}
@@ -77,6 +103,8 @@ func (m Model) renderMain() string {
 	switch {
 	case m.showCmdPalette:
 		bottomBar = m.renderCmdPalette()
+	case m.saving:
+		bottomBar = m.renderSavePrompt()
 	}
 	return lipgloss.JoinVertical(lipgloss.Left, mainArea, bottomBar)
 }
@@ -104,6 +132,9 @@ func (m Model) renderURLBar(w int) string {
 	}
 	mLabel := m.theme.keyHint("m")
 	badge := mStyle.Bold(true).Render(m.methodInput) + dim.Render(" ▾")
+	if isWebSocketURL(m.urlInput) {
+		badge = accent.Bold(true).Render("WS")
+	}
 
 	urlHint := m.theme.keyHint("e")
 	if m.editingURL {
@@ -112,6 +143,12 @@ func (m Model) renderURLBar(w int) string {
 
 	sendLabel := m.theme.keyHint("s")
 	sendBtn := accent.Bold(true).Render("Send ▶")
+	if isWebSocketURL(m.urlInput) {
+		sendBtn = accent.Bold(true).Render("Connect ▶")
+		if m.activeWS().live() {
+			sendBtn = m.theme.errStyle().Bold(true).Render("Disconnect ■")
+		}
+	}
 
 	// Fixed-width elements
 	mLabelW := lipgloss.Width(mLabel)
@@ -129,10 +166,12 @@ func (m Model) renderURLBar(w int) string {
 	var urlRendered string
 	if m.editingURL {
 		cursor := accent.Render("█")
-		text := m.theme.text().MaxWidth(urlAvail - 1).Render(m.urlInput)
+		text := m.theme.text().MaxWidth(urlAvail - 1).Render(m.presentURL(m.urlInput))
 		urlRendered = text + cursor
+	} else if m.urlPreview && m.urlInput != "" {
+		urlRendered = lipgloss.NewStyle().MaxWidth(urlAvail).Render(m.renderURLPreview())
 	} else if m.urlInput != "" {
-		urlRendered = m.theme.textMuted().MaxWidth(urlAvail).Render(m.urlInput)
+		urlRendered = m.theme.textMuted().MaxWidth(urlAvail).Render(m.presentURL(m.urlInput))
 	} else {
 		urlRendered = dim.MaxWidth(urlAvail).Render("Enter a URL...")
 	}
@@ -148,6 +187,34 @@ func (m Model) renderURLBar(w int) string {
 	return left + strings.Repeat(" ", gap) + right
 }
 
+// renderURLPreview shows the URL as the next send would build it, query
+// parameters included. What cannot be known before the send, such as
+// {{$uuid}}, prompts and unknown variables, stays as written, highlighted.
+func (m Model) renderURLPreview() string {
+	rv := &resolver{env: m.env(), preview: true}
+	u := rv.resolve(strings.TrimSpace(m.urlInput))
+	if m.activeFolderIdx >= 0 {
+		sep := "?"
+		if strings.Contains(u, "?") {
+			sep = "&"
+		}
+		for _, p := range m.folders[m.activeFolderIdx].requests[m.activeReqIdx].params {
+			u += sep + rv.resolve(p.key) + "=" + rv.resolve(p.value)
+			sep = "&"
+		}
+	}
+	u = m.presentURL(u)
+	var b strings.Builder
+	last := 0
+	for _, loc := range varPattern.FindAllStringIndex(u, -1) {
+		b.WriteString(m.theme.text().Render(u[last:loc[0]]))
+		b.WriteString(m.theme.highlight().Render(u[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	b.WriteString(m.theme.text().Render(u[last:]))
+	return b.String()
+}
+
 func (m Model) renderRequestTabs(w int) string {
 	type tabDef struct {
 		key   string
@@ -189,12 +256,18 @@ func (m Model) renderRequestTabContent(w, h int) string {
 	req := m.folders[m.activeFolderIdx].requests[m.activeReqIdx]
 	switch m.requestTab {
 	case 0:
-		return m.renderKVTable(paramsToKV(req.params), "Key", "Value", w)
+		return m.renderKVTable(m.redactPairs(paramsToKV(req.params), m.redactions.key), "Key", "Value", w)
 	case 1:
 		return m.renderAuthContent(req.auth)
 	case 2:
-		return m.renderKVTable(headersToKV(req.headers), "Key", "Value", w)
+		return m.renderKVTable(m.redactPairs(headersToKV(req.headers), m.redactions.header), "Key", "Value", w)
 	case 3:
+		if req.graphql != nil {
+			return m.renderGraphQLBody(*req.graphql, w, h)
+		}
+		if isWebSocketURL(req.url) {
+			return m.renderWSComposer(req, w, h)
+		}
 		return m.renderBodyContent(req.body)
 	}
 	return ""
@@ -259,19 +332,19 @@ func (m Model) renderAuthContent(auth requestAuth) string {
 		lines = append(lines, dim.Render("  No authentication configured."))
 	case authBearer:
 		lines = append(lines, label.Render("  Token"))
-		lines = append(lines, "  "+val.Render(auth.token))
+		lines = append(lines, "  "+val.Render(m.maskLiteral(auth.token)))
 	case authBasic:
 		lines = append(lines, label.Render("  Username"))
 		lines = append(lines, "  "+val.Render(auth.username))
 		lines = append(lines, "")
 		lines = append(lines, label.Render("  Password"))
-		lines = append(lines, "  "+val.Render(strings.Repeat("●", len(auth.password))))
+		lines = append(lines, "  "+val.Render(m.maskLiteral(auth.password)))
 	case authAPIKey:
 		lines = append(lines, label.Render("  Key"))
 		lines = append(lines, "  "+val.Render(auth.apiKey))
 		lines = append(lines, "")
 		lines = append(lines, label.Render("  Value"))
-		lines = append(lines, "  "+val.Render(auth.apiValue))
+		lines = append(lines, "  "+val.Render(m.maskLiteral(auth.apiValue)))
 	}
 
 	return strings.Join(lines, "\n")
@@ -283,7 +356,7 @@ func (m Model) renderBodyContent(body string) string {
 	}
 	val := m.theme.textMuted()
 	var lines []string
-	for _, l := range strings.Split(body, "\n") {
+	for _, l := range strings.Split(m.presentBody(body), "\n") {
 		lines = append(lines, val.Render("  "+l))
 	}
 	return strings.Join(lines, "\n")
@@ -301,6 +374,8 @@ func (m Model) renderCmdPalette() string {
 	var right string
 	if m.cmdError != "" {
 		right = m.theme.errStyle().Render("  " + m.cmdError)
+	} else if m.cmdInfo != "" {
+		right = m.theme.textMuted().Render("  " + m.cmdInfo)
 	} else {
 		right = dim.Render("  esc to close")
 	}
@@ -332,7 +407,19 @@ func (m Model) renderFooter() string {
 		parts = append(parts, "  "+k+d)
 	}
 
-	return m.theme.footerDescStyle().Render(strings.Join(parts, ""))
+	left := m.theme.footerDescStyle().Render(strings.Join(parts, ""))
+	if m.notice != "" {
+		left = m.theme.textMuted().Render("  " + m.notice)
+	}
+	right := m.theme.dim().Render("env ") + m.theme.highlight().Render(m.env().name) + " "
+	if m.presenting {
+		right = m.theme.accent().Bold(true).Render("● presenting") + "  " + right
+	}
+	gap := m.width - lipgloss.Width(left) - lipgloss.Width(right)
+	if gap < 1 {
+		return left
+	}
+	return left + strings.Repeat(" ", gap) + right
 }
 
 // renderFolderPicker renders the two-level floating folder picker.
@@ -477,6 +564,7 @@ func (m Model) renderFolderPicker() string {
 	} else {
 		previewContent = dim.Render("  nothing selected")
 	}
+	previewContent = scrollLines(previewContent, 0, contentH)
 
 	previewPane := lipgloss.NewStyle().
 		Width(previewW).
@@ -575,15 +663,15 @@ func (m Model) renderRequestPreview(r request, width int) string {
 
 	var lines []string
 
-	lines = append(lines, st.Bold(true).Render(r.method)+"  "+valStyle.Render(r.url))
+	lines = append(lines, st.Bold(true).Render(r.method)+"  "+valStyle.Render(m.presentURL(r.url)))
 	lines = append(lines, dimStyle.Render(strings.Repeat("─", width-2)))
 
 	lines = append(lines, labelStyle.Render("Headers"))
 	if len(r.headers) == 0 {
 		lines = append(lines, dimStyle.Render("  (none)"))
 	} else {
-		for _, h := range r.headers {
-			lines = append(lines, "  "+dimStyle.Render(h.key+": ")+valStyle.Render(h.value))
+		for _, h := range m.redactPairs(headersToKV(r.headers), m.redactions.header) {
+			lines = append(lines, "  "+dimStyle.Render(h[0]+": ")+valStyle.Render(h[1]))
 		}
 	}
 	lines = append(lines, "")
@@ -592,8 +680,8 @@ func (m Model) renderRequestPreview(r request, width int) string {
 	if len(r.params) == 0 {
 		lines = append(lines, dimStyle.Render("  (none)"))
 	} else {
-		for _, p := range r.params {
-			lines = append(lines, "  "+dimStyle.Render(p.key+": ")+valStyle.Render(p.value))
+		for _, p := range m.redactPairs(paramsToKV(r.params), m.redactions.key) {
+			lines = append(lines, "  "+dimStyle.Render(p[0]+": ")+valStyle.Render(p[1]))
 		}
 	}
 	lines = append(lines, "")
@@ -602,10 +690,12 @@ func (m Model) renderRequestPreview(r request, width int) string {
 	if r.body == "" {
 		lines = append(lines, dimStyle.Render("  (none)"))
 	} else {
-		for _, l := range strings.Split(r.body, "\n") {
+		for _, l := range strings.Split(m.presentBody(r.body), "\n") {
 			lines = append(lines, "  "+valStyle.Render(l))
 		}
 	}
+	lines = append(lines, "")
+	lines = append(lines, m.renderExamples(r, width)...)
 
 	return strings.Join(lines, "\n")
 }
@@ -732,6 +822,56 @@ func (m Model) renderCmdHelp() string {
 		{":orient", "toggle split direction (left/right ↔ top/bottom)"},
 		{":theme <name>", "switch color theme"},
 		{"", "rosepine · xcode · catppuccin · tokyonight · sonokai"},
+		{":env <name>", "switch environment"},
+		{":cookies", "view / edit the environment cookie jar"},
+		{":jar on|off", "enable / disable the cookie jar for this request"},
+		{":proxy", "show the proxy used by this request"},
+		{":proxy <p>", "set the global proxy: env · off · http://… · socks5://user:pass@…"},
+		{":proxy folder <p>", "override for the folder (inherit to clear)"},
+		{":proxy request <p>", "override for the request (inherit to clear)"},
+		{":socket <path|off>", "send this request over a Unix domain socket"},
+		{":resolve h:p:addr", "pin host:port to an address (:resolve clear)"},
+		{":set [scope] <k> <v>", "override a send setting for the request or folder"},
+		{"", "timeout · connect-timeout · tls-timeout · follow-redirects"},
+		{"", "max-redirects · retries · retry-on · retry-backoff"},
+		{":unset [scope] <k>", "drop an override (:set alone lists them)"},
+		{":format <name>", "show the body as auto · json · xml · html · text · yaml · image · hex · raw"},
+		{":save [body] [path]", "write the response body to a file (prompts for the path)"},
+		{":save exchange [path]", "write the request and response as raw HTTP text"},
+		{":pin [name]", "keep the response as a named example (alone: list them)"},
+		{":unpin <name>", "drop a pinned example"},
+		{":history", "list the earlier responses of the request (#1 is the previous)"},
+		{":diff [name|#n]", "compare the response with an example or response #n"},
+		{":diff off", "close the diff (esc works too)"},
+		{":import <file>", "add a Postman v2.1 collection or OpenAPI 3 document"},
+		{":assert [check]", "add a check run after every send (alone: list them)"},
+		{"", "status == 200 · header <name> exists|matches <re>|== <v>"},
+		{"", "json <path> exists|type <t>|matches <re>|== <json>|< <n>"},
+		{"", "time < 500ms · body matches <re>|contains <text>"},
+		{"", "schema <file>|<inline JSON Schema>"},
+		{":unassert <n>", "remove check n"},
+		{":run [folder]", "send every request of the folder and check its assertions"},
+		{"", "-c <n> sends n at a time · --bail stops at the first failure"},
+		{":report junit|json <p>", "write the finished run as a JUnit XML or JSON report"},
+		{":script [folder] pre|post", "set a JavaScript file or snippet run before / after sends"},
+		{"", "<file|code|off> · alone, lists the scripts in effect"},
+		{":extract [spec]", "capture a response value into a variable after each send"},
+		{"", "[runtime] <var> json <path>|header <n>|regex <re>|cookie <n>"},
+		{":unextract <n>", "remove extractor n"},
+		{":vars clear", "drop the runtime variables"},
+		{":vault unlock|lock", "open the encrypted secrets vault, or forget its key"},
+		{":secret [set <name>]", "store a secret (asks for the value); alone, lists them"},
+		{"", "cmd <name> <command> · rm <name> · show|hide on screen"},
+		{":redact [kind <pattern>]", "treat a header, JSON key or variable as sensitive"},
+		{"", "kind is header, key or var · alone, lists the patterns"},
+		{":unredact <kind> <pat>", "remove a redaction pattern"},
+		{":present [on|off]", "presentation mode: hide sensitive values on screen"},
+		{":graphql [on|off]", "edit the body as a GraphQL query and variables"},
+		{":operation [name]", "pick the operation to run (auto lets a lone one run)"},
+		{":schema [refresh]", "browse the endpoint's introspected schema"},
+		{":ws ping [payload]", "ping the request's WebSocket session"},
+		{":ws close [code] [why]", "close the session with a close code and reason"},
+		{":ws type <type>", "message type: text, json or binary (as hex)"},
 		{":help", "show this commands list"},
 	}
 
@@ -742,7 +882,7 @@ func (m Model) renderCmdHelp() string {
 		if r.cmd == "" {
 			lines = append(lines, "       "+m.theme.dim().Render(r.desc))
 		} else {
-			k := m.theme.helpKeyStyle().Render(fmt.Sprintf("   %-16s", r.cmd))
+			k := m.theme.helpKeyStyle().Render(fmt.Sprintf("   %-22s", r.cmd))
 			lines = append(lines, k+r.desc)
 		}
 	}
@@ -770,17 +910,67 @@ func (m Model) renderHelp() string {
 			{"esc (insert)", "return to normal mode"},
 			{"n", "new folder or request (normal mode)"},
 			{"d", "delete selected (normal mode)"},
+			{"[ / ]", "previous / next example in the request preview"},
 			{"esc (normal)", "back / close picker"},
 		}},
+		{"GraphQL Body (:graphql)", []row{
+			{"i / v", "edit the query / the variables (esc to stop)"},
+			{"tab", "accept the completion (ctrl+n / ctrl+p to choose)"},
+			{"ctrl+space", "suggest fields or arguments at the cursor"},
+			{"o", "next operation of the query"},
+			{"g", "browse the schema (enter on a field goes to its type)"},
+		}},
+		{"Event Stream (text/event-stream)", []row{
+			{"j / k · g / G", "move through the events · follow the newest"},
+			{"p / space", "pause / resume the view (events keep arriving)"},
+			{"r", "reconnect now, sending Last-Event-ID"},
+			{"x", "stop the stream"},
+		}},
+		{"WebSocket (ws:// URL)", []row{
+			{"s", "connect / disconnect"},
+			{"i", "edit the message in the Body tab (ctrl+s sends, esc stops)"},
+			{"enter", "send the message"},
+			{"t", "cycle the message type (text / json / binary)"},
+			{"/ · d", "filter the frame log by text · by direction"},
+			{"enter (log)", "show the selected frame in full"},
+			{"p · c", "ping · clear the log"},
+		}},
 		{"Pane Navigation", []row{
 			{"tab / shift+tab", "cycle pane"},
 		}},
+		{"Response Pane", []row{
+			{"h / l", "prev / next tab (Body / Headers / Cookies / Timing / Tests / Vars / Console)"},
+			{"j / k", "scroll / move the JSON cursor"},
+			{"enter / space", "fold / unfold the JSON node under the cursor"},
+			{"- / +", "collapse / expand all JSON nodes"},
+			{"/", "search the body (ctrl+r toggles regex)"},
+			{"n / N", "next / previous match"},
+			{"esc", "clear the search highlights"},
+			{"|", "filter the JSON body with a jq expression"},
+			{"v", "cycle the body format (auto / json / xml / html / text / yaml / image / hex / raw)"},
+			{"i", "show / hide the image preview"},
+			{"w", "save the body to a file (asks for the path)"},
+			{"g / G", "jump to top / bottom"},
+		}},
+		{"Diff (:diff)", []row{
+			{"j / k", "scroll both sides"},
+			{"n / N", "next / previous change"},
+			{"g / G", "jump to top / bottom"},
+			{"esc", "close the diff"},
+		}},
+		{"Run (:run)", []row{
+			{"j / k", "scroll the results"},
+			{"x", "stop the run"},
+			{"esc", "stop and close the run"},
+		}},
 		{"Request Pane", []row{
 			{"h / l", "prev / next tab"},
 			{"p / a / r / b", "jump to Params / Auth / Headers / Body"},
 			{"m", "change method"},
 			{"e", "edit URL"},
-			{"s", "send request"},
+			{"u", "show / hide the URL with variables resolved"},
+			{"s", "send request (asks for any {{$prompt}} values first)"},
+			{"x / esc", "cancel the in-flight request"},
 			{"esc / enter", "stop editing"},
 		}},
 		{"Global", []row{
@@ -0,0 +1,829 @@
+package ui
+
+import (
+	"cmp"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/gorilla/websocket"
+)
+
+// Message types of the WebSocket composer. JSON is sent as a text frame
+// once it is checked; binary is written as hex.
+const (
+	frameText   = "text"
+	frameJSON   = "json"
+	frameBinary = "binary"
+)
+
+var frameTypes = []string{frameText, frameJSON, frameBinary}
+
+// maxWSFrames bounds the log of a session; the oldest frames go first.
+const maxWSFrames = 2000
+
+// wsCloseTimeout is how long a close handshake may take before the
+// connection is dropped.
+const wsCloseTimeout = 3 * time.Second
+
+// isWebSocketURL reports whether u is a ws:// or wss:// URL, which makes a
+// request a WebSocket session.
+func isWebSocketURL(u string) bool {
+	u = strings.ToLower(strings.TrimSpace(u))
+	return strings.HasPrefix(u, "ws://") || strings.HasPrefix(u, "wss://")
+}
+
+// wsDir tells where a logged frame went.
+type wsDir int
+
+const (
+	wsIn wsDir = iota
+	wsOut
+	wsInfo // connection events
+)
+
+// Direction filters of the log.
+const (
+	wsShowAll = iota
+	wsShowIn
+	wsShowOut
+	wsShowCount
+)
+
+type wsState int
+
+const (
+	wsConnecting wsState = iota
+	wsOpen
+	wsClosing
+	wsClosed
+)
+
+// wsFrame is one line of the session log.
+type wsFrame struct {
+	at   time.Time
+	dir  wsDir
+	kind string // text, binary, ping, pong or close; empty for events
+	data []byte
+	note string // the event, or the close code and reason
+}
+
+// wsLink is the connection of a session, shared with the goroutines that
+// dial, read and write it. Frames to send are queued on out; what was
+// read and sent comes back on events, in order.
+type wsLink struct {
+	mu      sync.Mutex
+	conn    *websocket.Conn
+	out     chan wsFrame
+	events  chan wsEvent
+	cancel  context.CancelFunc
+	closing atomic.Bool // a close frame was sent
+}
+
+// wsSession is the WebSocket connection of a request and its log. It is
+// replaced, not modified, as events arrive.
+type wsSession struct {
+	id        int // tells events of this session from those of a dropped one
+	folderIdx int
+	reqIdx    int
+	url       string
+	link      *wsLink
+	state     wsState
+	status    string // handshake response status
+	closeCode int
+	frames    []wsFrame
+	opened    time.Time
+}
+
+// wsEvent is a frame or a state change read from the connection.
+type wsEvent struct {
+	session int
+	frame   wsFrame
+	open    bool
+	status  string
+	closed  bool
+	code    int
+}
+
+// wsDoneMsg is delivered once the connection of a session is gone.
+type wsDoneMsg struct{ session int }
+
+// closeCodeNames are the registered close codes of RFC 6455.
+var closeCodeNames = map[int]string{
+	websocket.CloseNormalClosure:           "normal closure",
+	websocket.CloseGoingAway:               "going away",
+	websocket.CloseProtocolError:           "protocol error",
+	websocket.CloseUnsupportedData:         "unsupported data",
+	websocket.CloseNoStatusReceived:        "no status",
+	websocket.CloseAbnormalClosure:         "abnormal closure",
+	websocket.CloseInvalidFramePayloadData: "invalid payload",
+	websocket.ClosePolicyViolation:         "policy violation",
+	websocket.CloseMessageTooBig:           "message too big",
+	websocket.CloseMandatoryExtension:      "mandatory extension",
+	websocket.CloseInternalServerErr:       "internal error",
+	websocket.CloseServiceRestart:          "service restart",
+	websocket.CloseTryAgainLater:           "try again later",
+	websocket.CloseTLSHandshake:            "TLS handshake",
+}
+
+func closeNote(code int, reason string) string {
+	note := strconv.Itoa(code)
+	if name, ok := closeCodeNames[code]; ok {
+		note += " " + name
+	}
+	if reason != "" {
+		note += " · " + reason
+	}
+	return note
+}
+
+func wsEventFrame(note string) wsFrame {
+	return wsFrame{at: time.Now(), dir: wsInfo, note: note}
+}
+
+// run dials the session and reads frames until the connection is gone,
+// answering pings on the way. Events stop once ctx is cancelled.
+func (l *wsLink) run(ctx context.Context, id int, dialer websocket.Dialer, url string, header http.Header) {
+	defer close(l.events)
+	emit := func(ev wsEvent) {
+		ev.session = id
+		select {
+		case l.events <- ev:
+		case <-ctx.Done():
+		}
+	}
+	conn, resp, err := dialer.DialContext(ctx, url, header)
+	if err != nil {
+		note := err.Error()
+		if resp != nil {
+			note = "handshake failed: " + resp.Status
+		}
+		emit(wsEvent{frame: wsEventFrame(note), closed: true, code: websocket.CloseAbnormalClosure})
+		return
+	}
+	l.mu.Lock()
+	l.conn = conn
+	l.mu.Unlock()
+	defer conn.Close()
+
+	note := "connected · " + resp.Status
+	if p := conn.Subprotocol(); p != "" {
+		note += " · subprotocol " + p
+	}
+	emit(wsEvent{frame: wsEventFrame(note), open: true, status: resp.Status})
+	go l.writeLoop(ctx, conn, emit)
+	conn.SetPingHandler(func(data string) error {
+		emit(wsEvent{frame: wsFrame{at: time.Now(), dir: wsIn, kind: "ping", data: []byte(data)}})
+		err := conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		if err == nil {
+			emit(wsEvent{frame: wsFrame{at: time.Now(), dir: wsOut, kind: "pong", data: []byte(data), note: "automatic"}})
+		}
+		return nil
+	})
+	conn.SetPongHandler(func(data string) error {
+		emit(wsEvent{frame: wsFrame{at: time.Now(), dir: wsIn, kind: "pong", data: []byte(data)}})
+		return nil
+	})
+	for {
+		typ, data, err := conn.ReadMessage()
+		if err != nil {
+			var ce *websocket.CloseError
+			switch {
+			case errors.As(err, &ce):
+				emit(wsEvent{frame: wsFrame{at: time.Now(), dir: wsIn, kind: "close", note: closeNote(ce.Code, ce.Text)}, closed: true, code: ce.Code})
+			case l.closing.Load() || ctx.Err() != nil:
+				emit(wsEvent{frame: wsEventFrame("disconnected"), closed: true, code: websocket.CloseNoStatusReceived})
+			default:
+				emit(wsEvent{frame: wsEventFrame("connection lost: " + err.Error()), closed: true, code: websocket.CloseAbnormalClosure})
+			}
+			return
+		}
+		kind := frameText
+		if typ == websocket.BinaryMessage {
+			kind = frameBinary
+		}
+		emit(wsEvent{frame: wsFrame{at: time.Now(), dir: wsIn, kind: kind, data: data}})
+	}
+}
+
+// writeLoop writes the queued frames until ctx is cancelled. A close frame
+// starts the close handshake, which the peer has wsCloseTimeout to answer.
+func (l *wsLink) writeLoop(ctx context.Context, conn *websocket.Conn, emit func(wsEvent)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case f := <-l.out:
+			var err error
+			switch f.kind {
+			case "close", "ping":
+				typ := websocket.PingMessage
+				if f.kind == "close" {
+					typ = websocket.CloseMessage
+					l.closing.Store(true)
+					time.AfterFunc(wsCloseTimeout, l.drop)
+				}
+				err = conn.WriteControl(typ, f.data, time.Now().Add(time.Second))
+			case frameBinary:
+				err = conn.WriteMessage(websocket.BinaryMessage, f.data)
+			default:
+				err = conn.WriteMessage(websocket.TextMessage, f.data)
+			}
+			if err != nil {
+				emit(wsEvent{frame: wsEventFrame("send failed: " + err.Error())})
+				continue
+			}
+			f.at = time.Now()
+			emit(wsEvent{frame: f})
+		}
+	}
+}
+
+// send queues f for the writer, failing when the queue is full.
+func (l *wsLink) send(f wsFrame) error {
+	select {
+	case l.out <- f:
+		return nil
+	default:
+		return errors.New("the send queue is full")
+	}
+}
+
+// drop closes the connection without a handshake.
+func (l *wsLink) drop() {
+	l.cancel()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn != nil {
+		l.conn.Close()
+	}
+}
+
+// waitWS delivers the next event of a session, or wsDoneMsg once it is over.
+func waitWS(l *wsLink, id int) tea.Cmd {
+	return func() tea.Msg {
+		ev, ok := <-l.events
+		if !ok {
+			return wsDoneMsg{session: id}
+		}
+		return ev
+	}
+}
+
+// activeWS is the session of the active request, or nil.
+func (m Model) activeWS() *wsSession {
+	if m.ws == nil || m.ws.folderIdx != m.activeFolderIdx || m.ws.reqIdx != m.activeReqIdx {
+		return nil
+	}
+	return m.ws
+}
+
+// showingWS reports whether the response pane shows the session log.
+func (m Model) showingWS() bool {
+	return m.activeWS() != nil && isWebSocketURL(m.urlInput)
+}
+
+func (s *wsSession) live() bool {
+	return s != nil && (s.state == wsConnecting || s.state == wsOpen)
+}
+
+// toggleWS connects the active request, asking for the vault passphrase
+// and its prompts first, or closes its session when it is connected.
+func (m Model) toggleWS() (Model, tea.Cmd) {
+	if s := m.activeWS(); s.live() {
+		return m.closeWS(websocket.CloseNormalClosure, ""), nil
+	}
+	handshake := m.folders[m.activeFolderIdx].requests[m.activeReqIdx]
+	handshake.body = ""
+	if requestSecrets(handshake) && m.vault.locked() {
+		return m.askPassphrase(Model.toggleWS)
+	}
+	return m.askPrompts(requestPrompts(handshake), Model.connectWS)
+}
+
+// sendWS queues f on the active session, which must be open.
+func (m Model) sendWS(f wsFrame) Model {
+	s := m.activeWS()
+	if s == nil || s.state != wsOpen {
+		m.notice = "not connected (s connects)"
+		return m
+	}
+	f.dir = wsOut
+	if err := s.link.send(f); err != nil {
+		m.notice = "websocket: " + err.Error()
+	}
+	return m
+}
+
+// connectWS opens a session for the active request, dropping the one in
+// progress. The handshake carries the request's headers, auth and cookies
+// and goes through its proxy and resolve overrides.
+func (m Model) connectWS() (Model, tea.Cmd) {
+	f := m.folders[m.activeFolderIdx]
+	r := f.requests[m.activeReqIdx]
+	handshake := r
+	handshake.body = "" // the composer's message, sent later
+	req, err := buildHTTPRequest(handshake, m.env())
+	if err == nil {
+		var opts sendOptions
+		if opts, err = resolveOptions(f, r); err == nil {
+			m = m.dropWS()
+			ts := m.transportSettings()
+			ts.connectTimeout = opts.connectTimeout
+			ts.tlsTimeout = opts.tlsTimeout
+			t := newTransport(ts)
+			dialer := websocket.Dialer{
+				NetDialContext:   t.DialContext,
+				Proxy:            t.Proxy,
+				HandshakeTimeout: opts.totalTimeout,
+			}
+			if !r.noCookies {
+				dialer.Jar = m.jar()
+			}
+			return m.openWS(dialer, req)
+		}
+	}
+	m.notice = "websocket: " + err.Error()
+	return m, nil
+}
+
+func (m Model) openWS(dialer websocket.Dialer, req *http.Request) (Model, tea.Cmd) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.wsSeq++
+	s := &wsSession{
+		id:        m.wsSeq,
+		folderIdx: m.activeFolderIdx,
+		reqIdx:    m.activeReqIdx,
+		url:       req.URL.String(),
+		link:      &wsLink{out: make(chan wsFrame, 64), events: make(chan wsEvent, 64), cancel: cancel},
+	}
+	s.frames = []wsFrame{wsEventFrame("connecting to " + m.redactions.redactURL(s.url))}
+	go s.link.run(ctx, s.id, dialer, s.url, req.Header)
+	m.ws = s
+	m.wsCursor = -1
+	m.wsExpand = false
+	return m, waitWS(s.link, s.id)
+}
+
+// dropWS ends the current session without a close handshake, as when
+// another request connects.
+func (m Model) dropWS() Model {
+	if m.ws != nil {
+		m.ws.link.drop()
+		m.ws = nil
+	}
+	return m
+}
+
+// closeWS starts the close handshake with code and reason. The session
+// ends when the peer answers, or after wsCloseTimeout.
+func (m Model) closeWS(code int, reason string) Model {
+	s := m.activeWS()
+	if s == nil || s.state != wsOpen {
+		if s.live() {
+			// Still dialing: nothing to close politely.
+			m = m.dropWS()
+		}
+		return m
+	}
+	m = m.sendWS(wsFrame{kind: "close", data: websocket.FormatCloseMessage(code, reason), note: closeNote(code, reason)})
+	next := *m.ws
+	next.state = wsClosing
+	m.ws = &next
+	return m
+}
+
+// startWSMessage sends the composer's message, asking for the vault
+// passphrase and its prompts first.
+func (m Model) startWSMessage() (Model, tea.Cmd) {
+	msg := request{body: m.folders[m.activeFolderIdx].requests[m.activeReqIdx].body}
+	if requestSecrets(msg) && m.vault.locked() {
+		return m.askPassphrase(Model.startWSMessage)
+	}
+	return m.askPrompts(requestPrompts(msg), func(m Model) (Model, tea.Cmd) {
+		return m.sendWSMessage(), nil
+	})
+}
+
+// sendWSMessage resolves the composer's message and writes it as a frame
+// of the request's message type.
+func (m Model) sendWSMessage() Model {
+	r := m.folders[m.activeFolderIdx].requests[m.activeReqIdx]
+	rv := &resolver{env: m.env()}
+	text := rv.resolve(r.body)
+	if rv.err != nil {
+		m.notice = "message: " + rv.err.Error()
+		return m
+	}
+	f := wsFrame{kind: frameText, data: []byte(text)}
+	switch r.frameType {
+	case frameJSON:
+		if !json.Valid(f.data) {
+			m.notice = "message: not valid JSON"
+			return m
+		}
+	case frameBinary:
+		data, err := hex.DecodeString(strings.Join(strings.Fields(text), ""))
+		if err != nil {
+			m.notice = "message: binary frames are written as hex"
+			return m
+		}
+		f = wsFrame{kind: frameBinary, data: data}
+	}
+	return m.sendWS(f)
+}
+
+// logWS appends frames to the session log and moves it to state.
+func (m Model) logWS(ev wsEvent) Model {
+	s := *m.ws
+	s.frames = append(slices.Clip(s.frames), ev.frame)
+	if over := len(s.frames) - maxWSFrames; over > 0 {
+		s.frames = s.frames[over:]
+		if m.wsCursor >= 0 {
+			m.wsCursor = max(0, m.wsCursor-over)
+		}
+	}
+	switch {
+	case ev.open:
+		s.state = wsOpen
+		s.status = ev.status
+		s.opened = ev.frame.at
+	case ev.closed:
+		s.state = wsClosed
+		s.closeCode = ev.code
+	}
+	m.ws = &s
+	return m
+}
+
+func (m Model) handleWSEvent(ev wsEvent) (Model, tea.Cmd) {
+	if m.ws == nil || ev.session != m.ws.id {
+		return m, nil
+	}
+	m = m.logWS(ev)
+	return m, waitWS(m.ws.link, m.ws.id)
+}
+
+func (m Model) handleWSDone(msg wsDoneMsg) Model {
+	if m.ws != nil && msg.session == m.ws.id && m.ws.state != wsClosed {
+		m = m.logWS(wsEvent{frame: wsEventFrame("disconnected"), closed: true})
+	}
+	return m
+}
+
+// execWS handles ":ws ping [payload]", ":ws close [code] [reason]" and
+// ":ws type text|json|binary".
+func (m Model) execWS(args []string) Model {
+	usage := "usage: ws ping [payload] | close [code] [reason] | type text|json|binary"
+	if m.activeFolderIdx < 0 || !isWebSocketURL(m.urlInput) {
+		m.cmdError = "not a WebSocket request (ws:// or wss:// URL)"
+		return m
+	}
+	if len(args) == 0 {
+		m.cmdError = usage
+		return m
+	}
+	switch args[0] {
+	case "ping":
+		m = m.closeCmdPalette()
+		return m.sendWS(wsFrame{kind: "ping", data: []byte(strings.Join(args[1:], " "))})
+	case "close":
+		code := websocket.CloseNormalClosure
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1000 || n > 4999 || n == websocket.CloseNoStatusReceived || n == websocket.CloseAbnormalClosure || n == websocket.CloseTLSHandshake {
+				m.cmdError = "close code must be 1000-4999 and one that can be sent"
+				return m
+			}
+			code = n
+		}
+		var reason string
+		if len(args) > 2 {
+			reason = strings.Join(args[2:], " ")
+		}
+		if len(reason) > 123 {
+			m.cmdError = "the close reason is limited to 123 bytes"
+			return m
+		}
+		if !m.activeWS().live() {
+			m.cmdError = "not connected"
+			return m
+		}
+		m = m.closeCmdPalette()
+		return m.closeWS(code, reason)
+	case "type":
+		if len(args) != 2 || !slices.Contains(frameTypes, args[1]) {
+			m.cmdError = usage
+			return m
+		}
+		r := &m.folders[m.activeFolderIdx].requests[m.activeReqIdx]
+		r.frameType = args[1]
+		return m.closeCmdPalette()
+	}
+	m.cmdError = usage
+	return m
+}
+
+// wsVisible lists the indices of the frames that pass the log filters.
+func (m Model) wsVisible() []int {
+	var out []int
+	q := strings.ToLower(m.wsFilter)
+	for i, f := range m.ws.frames {
+		switch {
+		case m.wsDirFilter == wsShowIn && f.dir != wsIn,
+			m.wsDirFilter == wsShowOut && f.dir != wsOut:
+			continue
+		case q != "" && !strings.Contains(strings.ToLower(f.kind+" "+f.note+" "+string(f.data)), q):
+			continue
+		}
+		out = append(out, i)
+	}
+	return out
+}
+
+// updateWSLog handles the keys of the response pane while it shows a
+// session log.
+func (m Model) updateWSLog(msg tea.KeyMsg) (Model, bool) {
+	if m.wsFiltering {
+		switch msg.String() {
+		case "esc":
+			m.wsFiltering = false
+			m.wsFilter = ""
+		case "enter":
+			m.wsFiltering = false
+		case "backspace":
+			if runes := []rune(m.wsFilter); len(runes) > 0 {
+				m.wsFilter = string(runes[:len(runes)-1])
+			}
+		default:
+			if len([]rune(msg.String())) == 1 {
+				m.wsFilter += msg.String()
+			}
+		}
+		m.wsCursor = -1
+		return m, true
+	}
+	n := len(m.wsVisible())
+	cur := m.wsCursor
+	if cur < 0 {
+		cur = n - 1
+	}
+	switch msg.String() {
+	case "j", "down":
+		if cur < n-1 {
+			m.wsCursor = cur + 1
+		}
+		if m.wsCursor == n-1 {
+			m.wsCursor = -1
+		}
+	case "k", "up":
+		m.wsCursor = max(0, cur-1)
+	case "g":
+		m.wsCursor = 0
+	case "G":
+		m.wsCursor = -1
+	case "enter", " ":
+		m.wsExpand = !m.wsExpand
+	case "/":
+		m.wsFiltering = true
+	case "esc":
+		m.wsFilter = ""
+		m.wsDirFilter = wsShowAll
+	case "d":
+		m.wsDirFilter = (m.wsDirFilter + 1) % wsShowCount
+		m.wsCursor = -1
+	case "c":
+		s := *m.ws
+		s.frames = nil
+		m.ws = &s
+		m.wsCursor = -1
+	case "p":
+		m = m.sendWS(wsFrame{kind: "ping"})
+	default:
+		return m, false
+	}
+	return m, true
+}
+
+// renderWSStatus is the status line of a session: its state, URL and
+// traffic.
+func (m Model) renderWSStatus(s *wsSession, w int) string {
+	dim := m.theme.dim()
+	var state string
+	switch s.state {
+	case wsConnecting:
+		state = m.theme.accent().Render("● connecting")
+	case wsOpen:
+		state = m.statusStyle(200).Render("● open") + dim.Render(" "+time.Since(s.opened).Round(time.Second).String())
+	case wsClosing:
+		state = m.theme.accent().Render("● closing")
+	case wsClosed:
+		state = m.theme.errStyle().Render("● closed")
+		if s.closeCode != 0 {
+			state += dim.Render(" " + closeNote(s.closeCode, ""))
+		}
+	}
+	var in, out int
+	for _, f := range s.frames {
+		switch f.dir {
+		case wsIn:
+			in++
+		case wsOut:
+			out++
+		}
+	}
+	parts := []string{
+		state,
+		m.theme.textMuted().Render(fmt.Sprintf("↓ %d  ↑ %d", in, out)),
+		dim.Render(m.redactions.redactURL(s.url)),
+	}
+	return lipgloss.NewStyle().MaxWidth(w).Render(" " + strings.Join(parts, dim.Render(" · ")))
+}
+
+// renderWSLog renders the response pane as the log of the session.
+func (m Model) renderWSLog(w, h int) string {
+	s := m.ws
+	dim := m.theme.dim()
+	div := dim.Render(strings.Repeat("─", w))
+
+	dirs := []string{"all", "in", "out"}
+	bar := dim.Render(" filter ") + m.theme.textMuted().Render(m.wsFilter)
+	if m.wsFiltering {
+		bar += m.theme.accent().Render("█") + "  " + m.theme.keyHint("enter") + dim.Render("apply") + "  " + m.theme.keyHint("esc") + dim.Render("clear")
+	} else {
+		bar += "  " + m.theme.keyHint("/") + dim.Render("filter") +
+			"  " + m.theme.keyHint("d") + dim.Render(dirs[m.wsDirFilter]) +
+			"  " + m.theme.keyHint("enter") + dim.Render("expand") +
+			"  " + m.theme.keyHint("p") + dim.Render("ping") +
+			"  " + m.theme.keyHint("c") + dim.Render("clear")
+	}
+
+	contentH := h - 4 // status line + divider + filter bar + divider
+	visible := m.wsVisible()
+	cur := m.wsCursor
+	if cur < 0 || cur >= len(visible) {
+		cur = len(visible) - 1
+	}
+	var lines []string
+	curLine := 0
+	for vi, i := range visible {
+		f := s.frames[i]
+		if vi == cur {
+			curLine = len(lines)
+		}
+		lines = append(lines, m.renderWSFrame(f, vi == cur && m.focused == 1, w))
+		if vi == cur && m.wsExpand {
+			for _, l := range strings.Split(m.presentBody(wsFrameDetail(f)), "\n") {
+				lines = append(lines, lipgloss.NewStyle().MaxWidth(w).Render("      "+m.theme.text().Render(printable(l))))
+			}
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, dim.Render("  no frames"))
+	}
+	// Keep the selected frame on screen, following the newest by default.
+	offset := max(0, len(lines)-contentH)
+	if m.wsCursor >= 0 {
+		offset = min(offset, max(0, curLine-contentH/2))
+	}
+	return strings.Join([]string{
+		m.renderWSStatus(s, w),
+		div,
+		lipgloss.NewStyle().MaxWidth(w).Render(bar),
+		div,
+		scrollLines(strings.Join(lines, "\n"), offset, contentH),
+	}, "\n")
+}
+
+// renderWSFrame renders one line of the log: arrival time, direction, kind
+// and the start of the payload.
+func (m Model) renderWSFrame(f wsFrame, selected bool, w int) string {
+	dim := m.theme.dim()
+	prefix := "  "
+	if selected {
+		prefix = m.theme.accent().Bold(true).Render("> ")
+	}
+	stamp := dim.Render(f.at.Format("15:04:05.000") + " ")
+	if f.dir == wsInfo {
+		return lipgloss.NewStyle().MaxWidth(w).Render(prefix + stamp + dim.Render("• "+f.note))
+	}
+	arrow := m.statusStyle(200).Render("↓ ")
+	if f.dir == wsOut {
+		arrow = m.theme.accent().Render("↑ ")
+	}
+	kind := m.theme.highlight().Render(fmt.Sprintf("%-7s", f.kind))
+	var payload string
+	switch {
+	case f.kind == "close":
+		payload = f.note
+	case f.kind == frameBinary:
+		payload = formatBytes(len(f.data)) + "  " + hex.EncodeToString(f.data[:min(len(f.data), 32)])
+	default:
+		payload = strings.Join(strings.Fields(m.presentBody(string(f.data))), " ")
+		if f.note != "" {
+			payload = strings.TrimSpace(payload + " " + dim.Render("("+f.note+")"))
+		}
+	}
+	return lipgloss.NewStyle().MaxWidth(w).Render(prefix + stamp + arrow + kind + m.theme.text().Render(printable(payload)))
+}
+
+// wsFrameDetail is the full payload of f: indented when it is JSON, a hex
+// dump when it is binary.
+func wsFrameDetail(f wsFrame) string {
+	switch {
+	case f.kind == frameBinary || !utf8.Valid(f.data):
+		return strings.TrimRight(hex.Dump(f.data), "\n")
+	case json.Valid(f.data):
+		if s, err := reindentJSON(f.data); err == nil {
+			return s
+		}
+	case len(f.data) == 0:
+		return cmp.Or(f.note, "(empty)")
+	}
+	return string(f.data)
+}
+
+// updateWSKeys handles the keys of the Body tab of a WebSocket request,
+// which is the message composer.
+func (m Model) updateWSKeys(msg tea.KeyMsg) (Model, tea.Cmd, bool) {
+	switch msg.String() {
+	case "i":
+		m.wsEditing = true
+		m.wsEdit = newTextArea(m.folders[m.activeFolderIdx].requests[m.activeReqIdx].body)
+	case "enter":
+		next, cmd := m.startWSMessage()
+		return next, cmd, true
+	case "t":
+		r := &m.folders[m.activeFolderIdx].requests[m.activeReqIdx]
+		i := slices.Index(frameTypes, cmp.Or(r.frameType, frameText))
+		r.frameType = frameTypes[(i+1)%len(frameTypes)]
+	default:
+		return m, nil, false
+	}
+	return m, nil, true
+}
+
+// updateWSEditor handles typing into the message composer; ctrl+s sends
+// without leaving it.
+func (m Model) updateWSEditor(msg tea.KeyMsg) (Model, tea.Cmd) {
+	t := m.wsEdit
+	switch key := msg.String(); {
+	case key == "esc":
+		m.wsEditing = false
+		return m, nil
+	case key == "ctrl+s":
+		return m.startWSMessage()
+	case msg.Paste:
+		t = t.insert(string(msg.Runes))
+	case key == "tab":
+		t = t.insert("  ")
+	default:
+		t, _ = t.edit(key)
+	}
+	m.wsEdit = t
+	r := &m.folders[m.activeFolderIdx].requests[m.activeReqIdx]
+	r.body = t.text
+	r.searchable = r.searchText()
+	return m, nil
+}
+
+// renderWSComposer renders the Body tab of a WebSocket request.
+func (m Model) renderWSComposer(r request, w, h int) string {
+	dim := m.theme.dim()
+	var ed *textArea
+	hint := m.theme.keyHint("i") + dim.Render("edit") + "  " + m.theme.keyHint("enter") + dim.Render("send")
+	if m.wsEditing {
+		ed = &m.wsEdit
+		hint = m.theme.keyHint("ctrl+s") + dim.Render("send") + "  " + m.theme.keyHint("esc") + dim.Render("done")
+	}
+	left := m.theme.highlight().Bold(true).Render("  Message") + "  " + hint
+	right := dim.Render("type ") + m.theme.textMuted().Render(cmp.Or(r.frameType, frameText)) + " " + m.theme.keyHint("t")
+	state := dim.Render("not connected")
+	if s := m.activeWS(); s != nil {
+		switch s.state {
+		case wsOpen:
+			state = m.statusStyle(200).Render("open")
+		case wsConnecting:
+			state = m.theme.accent().Render("connecting")
+		case wsClosing:
+			state = m.theme.accent().Render("closing")
+		default:
+			state = dim.Render("closed")
+		}
+	}
+	right += "  " + state
+	gap := max(1, w-lipgloss.Width(left)-lipgloss.Width(right)-1)
+	return left + strings.Repeat(" ", gap) + right + "\n" + m.renderEditor(r.body, ed, w, max(1, h-1))
+}
@@ -0,0 +1,280 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gorilla/websocket"
+)
+
+// newEchoServer starts a WebSocket server that echoes every message. A text
+// message "ping:<payload>" makes it ping instead, and "close:<code>:<reason>"
+// makes it close the connection.
+func newEchoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			typ, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			msg := string(data)
+			switch {
+			case typ == websocket.TextMessage && strings.HasPrefix(msg, "ping:"):
+				err = conn.WriteControl(websocket.PingMessage, []byte(strings.TrimPrefix(msg, "ping:")), time.Now().Add(time.Second))
+			case typ == websocket.TextMessage && strings.HasPrefix(msg, "close:"):
+				code, reason, _ := strings.Cut(strings.TrimPrefix(msg, "close:"), ":")
+				n, _ := strconv.Atoi(code)
+				conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(n, reason), time.Now().Add(time.Second))
+				conn.ReadMessage() // the close reply
+				return
+			default:
+				err = conn.WriteMessage(typ, data)
+			}
+			if err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// wsDriver runs a Model against a live session, feeding the messages of
+// its commands back into Update.
+type wsDriver struct {
+	t    *testing.T
+	m    Model
+	cmds []tea.Cmd
+}
+
+// connect opens a session for a request with body and frameType, as s does.
+func connect(t *testing.T, srv *httptest.Server, body, frameType string) *wsDriver {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+	m := New()
+	m.folders = []folder{{name: "WS", requests: []request{{method: "GET", name: "echo", url: url, body: body, frameType: frameType}}}}
+	m.activeFolderIdx, m.activeReqIdx = 0, 0
+	m.urlInput = url
+	d := &wsDriver{t: t, m: m}
+	t.Cleanup(func() { d.m.dropWS() })
+	d.key("s")
+	d.until("open", func(m Model) bool { return m.ws != nil && m.ws.state == wsOpen })
+	return d
+}
+
+func (d *wsDriver) key(k string) {
+	next, cmd := d.m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	d.m = next.(Model)
+	d.cmds = append(d.cmds, cmd)
+}
+
+// until runs commands until cond holds, failing after a few seconds.
+func (d *wsDriver) until(what string, cond func(Model) bool) {
+	d.t.Helper()
+	deadline := time.After(5 * time.Second)
+	for !cond(d.m) {
+		if len(d.cmds) == 0 {
+			d.t.Fatalf("waiting for %s: no more commands", what)
+		}
+		cmd := d.cmds[0]
+		d.cmds = d.cmds[1:]
+		if cmd == nil {
+			continue
+		}
+		done := make(chan tea.Msg, 1)
+		go func() { done <- cmd() }()
+		var msg tea.Msg
+		select {
+		case msg = <-done:
+		case <-deadline:
+			d.t.Fatalf("timed out waiting for %s", what)
+		}
+		if batch, ok := msg.(tea.BatchMsg); ok {
+			d.cmds = append(d.cmds, batch...)
+			continue
+		}
+		next, cmd := d.m.Update(msg)
+		d.m = next.(Model)
+		d.cmds = append(d.cmds, cmd)
+	}
+}
+
+// last is the newest frame logged in direction dir with kind, if any.
+func last(m Model, dir wsDir, kind string) (wsFrame, bool) {
+	for i := len(m.ws.frames) - 1; i >= 0; i-- {
+		if f := m.ws.frames[i]; f.dir == dir && f.kind == kind {
+			return f, true
+		}
+	}
+	return wsFrame{}, false
+}
+
+func received(dir wsDir, kind string) func(Model) bool {
+	return func(m Model) bool {
+		_, ok := last(m, dir, kind)
+		return ok
+	}
+}
+
+func TestWebSocketMessages(t *testing.T) {
+	srv := newEchoServer(t)
+	tests := []struct {
+		name      string
+		frameType string
+		body      string
+		wantKind  string
+		want      string
+	}{
+		{name: "text", frameType: frameText, body: "hello {{$timestamp}}x", wantKind: frameText},
+		{name: "default is text", body: "plain", wantKind: frameText, want: "plain"},
+		{name: "json", frameType: frameJSON, body: `{"a": [1, 2]}`, wantKind: frameText, want: `{"a": [1, 2]}`},
+		{name: "binary as hex", frameType: frameBinary, body: "de ad\nbe ef", wantKind: frameBinary, want: "\xde\xad\xbe\xef"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := connect(t, srv, tt.body, tt.frameType)
+			d.m = d.m.sendWSMessage()
+			if d.m.notice != "" {
+				t.Fatalf("send: %s", d.m.notice)
+			}
+			d.until("the echo", received(wsIn, tt.wantKind))
+			out, _ := last(d.m, wsOut, tt.wantKind)
+			in, _ := last(d.m, wsIn, tt.wantKind)
+			if string(in.data) != string(out.data) {
+				t.Errorf("echo %q, sent %q", in.data, out.data)
+			}
+			if tt.want != "" && string(out.data) != tt.want {
+				t.Errorf("sent %q, want %q", out.data, tt.want)
+			}
+			if strings.Contains(string(out.data), "{{") {
+				t.Errorf("sent %q unresolved", out.data)
+			}
+		})
+	}
+}
+
+func TestWebSocketRejectsBadMessages(t *testing.T) {
+	srv := newEchoServer(t)
+	for _, tt := range []struct{ frameType, body, want string }{
+		{frameJSON, `{"a":`, "not valid JSON"},
+		{frameBinary, "zz", "written as hex"},
+	} {
+		d := connect(t, srv, tt.body, tt.frameType)
+		n := len(d.m.ws.frames)
+		d.m = d.m.sendWSMessage()
+		if !strings.Contains(d.m.notice, tt.want) {
+			t.Errorf("%s %q: notice %q, want %q", tt.frameType, tt.body, d.m.notice, tt.want)
+		}
+		if len(d.m.ws.frames) != n {
+			t.Errorf("%s %q was logged", tt.frameType, tt.body)
+		}
+	}
+}
+
+func TestWebSocketPingPong(t *testing.T) {
+	srv := newEchoServer(t)
+	d := connect(t, srv, "ping:from-server", frameText)
+
+	d.m = d.m.execWS([]string{"ping", "from", "client"})
+	d.until("the pong", received(wsIn, "pong"))
+	if f, _ := last(d.m, wsIn, "pong"); string(f.data) != "from client" {
+		t.Errorf("pong %q, want the ping's payload", f.data)
+	}
+
+	d.m = d.m.sendWSMessage()
+	d.until("the automatic pong", received(wsOut, "pong"))
+	if f, _ := last(d.m, wsIn, "ping"); string(f.data) != "from-server" {
+		t.Errorf("server ping %q", f.data)
+	}
+	if f, _ := last(d.m, wsOut, "pong"); string(f.data) != "from-server" || f.note != "automatic" {
+		t.Errorf("pong %q (%s), want an automatic answer", f.data, f.note)
+	}
+}
+
+func TestWebSocketClose(t *testing.T) {
+	srv := newEchoServer(t)
+	closed := func(m Model) bool { return m.ws.state == wsClosed }
+
+	t.Run("by the client", func(t *testing.T) {
+		d := connect(t, srv, "", frameText)
+		d.m = d.m.execWS([]string{"close", "4000", "bye", "now"})
+		if d.m.ws.state != wsClosing {
+			t.Fatalf("state %v, want closing", d.m.ws.state)
+		}
+		d.until("the close", closed)
+		if f, _ := last(d.m, wsOut, "close"); f.note != "4000 · bye now" {
+			t.Errorf("close frame sent %q", f.note)
+		}
+		if d.m.ws.closeCode != 4000 {
+			t.Errorf("close code %d, want the echoed 4000", d.m.ws.closeCode)
+		}
+	})
+
+	t.Run("by the server", func(t *testing.T) {
+		d := connect(t, srv, "close:1001:maintenance", frameText)
+		d.m = d.m.sendWSMessage()
+		d.until("the close", closed)
+		if f, _ := last(d.m, wsIn, "close"); f.note != "1001 going away · maintenance" {
+			t.Errorf("close frame received %q", f.note)
+		}
+		if d.m.ws.closeCode != 1001 {
+			t.Errorf("close code %d, want 1001", d.m.ws.closeCode)
+		}
+	})
+
+	t.Run("codes that cannot be sent", func(t *testing.T) {
+		d := connect(t, srv, "", frameText)
+		for _, code := range []string{"999", "1005", "1006", "1015", "5000", "x"} {
+			d.m.cmdError = ""
+			if d.m = d.m.execWS([]string{"close", code}); d.m.cmdError == "" || d.m.ws.state != wsOpen {
+				t.Errorf("close %s was accepted", code)
+			}
+		}
+	})
+}
+
+func TestWebSocketLogFilter(t *testing.T) {
+	m := New()
+	m.ws = &wsSession{frames: []wsFrame{
+		{dir: wsInfo, note: "connected"},
+		{dir: wsOut, kind: frameText, data: []byte(`{"op": "subscribe"}`)},
+		{dir: wsIn, kind: frameText, data: []byte(`{"op": "Subscribed"}`)},
+		{dir: wsIn, kind: "ping"},
+		{dir: wsOut, kind: "pong", note: "automatic"},
+		{dir: wsIn, kind: "close", note: "1000 normal closure"},
+	}}
+	tests := []struct {
+		dir    int
+		filter string
+		want   []int
+	}{
+		{wsShowAll, "", []int{0, 1, 2, 3, 4, 5}},
+		{wsShowIn, "", []int{2, 3, 5}},
+		{wsShowOut, "", []int{1, 4}},
+		{wsShowAll, "subscribe", []int{1, 2}},
+		{wsShowIn, "SUBSCRIBE", []int{2}},
+		{wsShowAll, "automatic", []int{4}},
+		{wsShowAll, "normal", []int{5}},
+		{wsShowOut, "close", nil},
+	}
+	for _, tt := range tests {
+		m.wsDirFilter, m.wsFilter = tt.dir, tt.filter
+		got := m.wsVisible()
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("direction %d, filter %q: %v, want %v", tt.dir, tt.filter, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	yamlKeyPattern    = regexp.MustCompile(`^("(?:[^"\\]|\\.)*"|'[^']*'|[^\s#'"{\[][^#]*?)\s*:(\s|$)`)
+	yamlNumberPattern = regexp.MustCompile(`^[-+]?(\d[\d_]*(\.\d*)?([eE][-+]?\d+)?|\.\d+|0x[0-9a-fA-F]+|\.inf|\.nan)$`)
+)
+
+// highlightYAML colors a YAML document line by line. YAML is already laid
+// out for reading, so lines keep their own indentation; only comments,
+// mapping keys, sequence markers, anchors and scalar types are picked out.
+// Lines of a block scalar (after | or >) are shown as strings.
+func highlightYAML(body []byte) []synLine {
+	var lines []synLine
+	blockIndent := -1 // indentation of the key that opened a block scalar
+	for _, raw := range strings.Split(strings.TrimRight(string(body), "\n"), "\n") {
+		raw = strings.TrimRight(raw, "\r")
+		trimmed := strings.TrimLeft(raw, " ")
+		indent := raw[:len(raw)-len(trimmed)]
+
+		if blockIndent >= 0 {
+			if trimmed == "" || len(indent) > blockIndent {
+				lines = append(lines, synLine{spans: []synSpan{{synString, raw}}})
+				continue
+			}
+			blockIndent = -1
+		}
+
+		spans := []synSpan{{synPlain, indent}}
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "#"):
+			spans = append(spans, synSpan{synComment, trimmed})
+		case trimmed == "---" || trimmed == "..." || strings.HasPrefix(trimmed, "--- "):
+			spans = append(spans, synSpan{synPunct, trimmed})
+		case strings.HasPrefix(trimmed, "%"):
+			spans = append(spans, synSpan{synAttr, trimmed})
+		default:
+			rest := trimmed
+			keyIndent := len(indent)
+			for strings.HasPrefix(rest, "- ") || rest == "-" {
+				spans = append(spans, synSpan{synPunct, "- "})
+				rest = strings.TrimLeft(strings.TrimPrefix(rest, "-"), " ")
+				keyIndent += 2
+			}
+			if m := yamlKeyPattern.FindStringSubmatch(rest); m != nil {
+				spans = append(spans, synSpan{synKey, m[1]}, synSpan{synPunct, ":"})
+				rest = strings.TrimPrefix(rest, m[0][:len(m[0])-len(m[2])])
+			}
+			value, comment := splitYAMLComment(rest)
+			spans = append(spans, yamlValueSpans(value)...)
+			if comment != "" {
+				spans = append(spans, synSpan{synComment, comment})
+			}
+			v := strings.TrimSpace(value)
+			if v != "" && (v[0] == '|' || v[0] == '>') {
+				blockIndent = keyIndent
+			}
+		}
+		lines = append(lines, synLine{spans: spans})
+	}
+	return lines
+}
+
+// splitYAMLComment splits a trailing " # comment" off a value, ignoring #
+// inside quotes.
+func splitYAMLComment(s string) (value, comment string) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i], s[i:]
+		}
+	}
+	return s, ""
+}
+
+func yamlValueSpans(value string) []synSpan {
+	v := strings.TrimSpace(value)
+	if v == "" {
+		return []synSpan{{synPlain, value}}
+	}
+	lead := value[:strings.Index(value, v)]
+	trail := value[len(lead)+len(v):]
+	var spans []synSpan
+	if lead != "" {
+		spans = append(spans, synSpan{synPlain, lead})
+	}
+	// Anchors, aliases and tags come before the scalar.
+	for v != "" && (v[0] == '&' || v[0] == '*' || v[0] == '!') {
+		tok, rest, _ := strings.Cut(v, " ")
+		spans = append(spans, synSpan{synAttr, tok})
+		v = strings.TrimLeft(rest, " ")
+		if v != "" {
+			spans = append(spans, synSpan{synPlain, " "})
+		}
+	}
+	if v != "" {
+		spans = append(spans, synSpan{yamlScalarKind(v), v})
+	}
+	if trail != "" {
+		spans = append(spans, synSpan{synPlain, trail})
+	}
+	return spans
+}
+
+func yamlScalarKind(v string) synKind {
+	switch strings.ToLower(v) {
+	case "true", "false", "yes", "no", "on", "off":
+		return synBool
+	case "null", "~":
+		return synNull
+	}
+	switch {
+	case v[0] == '|' || v[0] == '>':
+		return synPunct
+	case v[0] == '{' || v[0] == '[':
+		return synPlain // flow collections are left as written
+	case yamlNumberPattern.MatchString(v):
+		return synNumber
+	}
+	return synString
+}